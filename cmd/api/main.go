@@ -1,21 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"golang-auth/internal/config"
+	"golang-auth/internal/domain"
 	"golang-auth/internal/handler"
 	"golang-auth/internal/middleware"
 	"golang-auth/internal/pkg/logger"
+	"golang-auth/internal/pkg/mailer"
 	"golang-auth/internal/repository"
+	"golang-auth/internal/router"
 	"golang-auth/internal/service"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
-	"reflect"
 	"strings"
+	"time"
 
-	"github.com/go-playground/validator/v10"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
 )
@@ -40,54 +43,242 @@ func main()  {
 	defer db.Close()
 
 	// inisialisasi validator
-	validate := NewValidator()
+	validate := config.NewValidator()
+
+	// schema relasi untuk ReBAC (tuple gaya Zanzibar)
+	relationSchema, err := config.LoadRelationSchema()
+	if err != nil {
+		slog.Error("Gagal memuat schema relasi", "error", err)
+		return
+	}
 
 	// wiring repository
 	userRepo := repository.NewUserRepository(db)
 	tokenRepo := repository.NewPersonalAccessTokenRepository(db)
 	permissionRepo := repository.NewPermissionRepository(db)
 	roleRepo := repository.NewRoleRepository(db)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	resourcePermissionRepo := repository.NewResourcePermissionRepository(db)
+	relationTupleRepo := repository.NewRelationTupleRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	accessRequestRepo := repository.NewAccessRequestRepository(db)
+	sodConstraintRepo := repository.NewSoDConstraintRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	userAttributeRepo := repository.NewUserAttributeRepository(db)
+
+	// notifikasi email (SMTP dari env, kalau kosong hanya ditulis ke log)
+	mail := mailer.New()
+
+	// registry route: mencatat method, path & permission setiap endpoint
+	routes := router.NewRegistry()
 
 	// wiring service
-	userService := service.NewUserService(userRepo, db, validate)
-	tokenService := service.NewPersonalAccessTokenService(tokenRepo, db, validate)
-	permissionService := service.NewPermissionService(permissionRepo, db)
-	roleService := service.NewRoleService(roleRepo, db, validate)
+	userService := service.NewUserService(userRepo, roleRepo, auditRepo, sodConstraintRepo, tokenRepo, userAttributeRepo, mail, db, validate)
+	tokenService := service.NewPersonalAccessTokenService(tokenRepo, userRepo, auditRepo, db, validate)
+	permissionService := service.NewPermissionService(permissionRepo, userRepo, userAttributeRepo, routes, db, validate)
+	roleService := service.NewRoleService(roleRepo, permissionRepo, sodConstraintRepo, config.DefaultRoleTemplates(), db, validate)
+	organizationService := service.NewOrganizationService(organizationRepo, roleRepo, userRepo, sodConstraintRepo, db, validate)
+	resourcePermissionService := service.NewResourcePermissionService(resourcePermissionRepo, permissionRepo, validate)
+	relationService := service.NewRelationService(relationTupleRepo, relationSchema, validate)
+	accessRequestService := service.NewAccessRequestService(accessRequestRepo, userRepo, auditRepo, sodConstraintRepo, mail, db, validate)
+	sodConstraintService := service.NewSoDConstraintService(sodConstraintRepo, db, validate)
+	authzService := service.NewAuthzService(permissionRepo, userRepo, organizationRepo, validate)
+	userAttributeService := service.NewUserAttributeService(userAttributeRepo, userRepo, db, validate)
+	invitationService := service.NewInvitationService(invitationRepo, userRepo, userService, auditRepo, mail, db, validate)
+	userPrivacyService := service.NewUserPrivacyService(userRepo, tokenRepo, auditRepo, accessRequestRepo, invitationRepo, organizationRepo, resourcePermissionRepo, relationTupleRepo, db, validate)
 
 	// wiring handler & middleware
-	authHandler := handler.NewAuthHandler(userService, tokenService)
+	authHandler := handler.NewAuthHandler(userService, tokenService, organizationService)
 	userHandler := handler.NewUserHandler(userService)
 	permissionHandler := handler.NewPermissionHandler(permissionService)
 	roleHandler := handler.NewRoleHandler(roleService)
+	organizationHandler := handler.NewOrganizationHandler(organizationService)
+	resourcePermissionHandler := handler.NewResourcePermissionHandler(resourcePermissionService)
+	relationHandler := handler.NewRelationHandler(relationService)
+	accessRequestHandler := handler.NewAccessRequestHandler(accessRequestService)
+	sodConstraintHandler := handler.NewSoDConstraintHandler(sodConstraintService)
+	invitationHandler := handler.NewInvitationHandler(invitationService)
+	userAttributeHandler := handler.NewUserAttributeHandler(userAttributeService)
+	userPrivacyHandler := handler.NewUserPrivacyHandler(userPrivacyService)
+	routeHandler := handler.NewRouteHandler(routes, permissionService)
 
-	authMiddleware := middleware.NewAuthMiddleware(tokenService)
-	permMiddleware := middleware.NewPermissionMiddleware(permissionService, roleService)
+	authMiddleware := middleware.NewAuthMiddleware(tokenService, userService)
+	tenantMiddleware := middleware.NewTenantMiddleware(organizationService)
+	permMiddleware := middleware.NewPermissionMiddleware(permissionService, roleService, organizationService, resourcePermissionService)
+
+	// handler yang butuh PermissionMiddleware untuk batch check
+	authzHandler := handler.NewAuthzHandler(authzService, permMiddleware)
 
 	// routing mux utama (publik)
 	mux := http.NewServeMux()
 	// route publik
 	// mux.HandleFunc("GET /api/v1/", authHandler.TesPing)
-	mux.HandleFunc("POST /api/v1/register", userHandler.Register)
-	mux.HandleFunc("POST /api/v1/login", authHandler.Login)
+	public := routes.Group(mux, "", domain.RouteAccessPublic, nil)
+	public.Handle("POST /api/v1/register", userHandler.Register)
+	public.Handle("POST /api/v1/login", authHandler.Login)
+	public.Handle("POST /api/v1/console/login", authHandler.ConsoleLogin)
+	public.Handle("POST /api/v1/email/verify", authHandler.VerifyEmail)
+	public.Handle("POST /api/v1/invitations/accept", invitationHandler.Accept)
 
 	// route terproteksi middleware
-	Group(mux, "/api/v1/", authMiddleware.Authenticate, func(subMux *http.ServeMux) {
-		subMux.HandleFunc("POST /logout", authHandler.Logout)
-		subMux.HandleFunc("GET /user", userHandler.Profile)
+	Group(mux, "/api/v1/", Chain(authMiddleware.Authenticate, tenantMiddleware.Resolve), func(subMux *http.ServeMux) {
+		api := routes.Group(subMux, "/api/v1", domain.RouteAccessAuthenticated, permMiddleware)
+
+		api.Handle("POST /logout", authHandler.Logout)
+		api.Handle("GET /user", userHandler.Profile)
+		api.Handle("PUT /user", userHandler.UpdateProfile)
+		api.Handle("PUT /user/password", authHandler.ChangePassword)
+		api.Handle("PUT /user/attributes", userAttributeHandler.UpdateMine)
+		api.Handle("POST /user/export", userPrivacyHandler.Export)
+		api.Handle("POST /user/erase", userPrivacyHandler.Erase)
+
+		// manajemen user oleh admin
+		api.Require("GET /users", "users:view", userHandler.FindAll)
+		api.Require("POST /users", "users:manage", userHandler.Create)
+		api.Require("GET /users/search", "users:view", userHandler.Search)
+		api.Require("POST /users/import", "users:manage", userHandler.Import)
+		api.Require("GET /users/export", "users:manage", userHandler.Export)
+		api.Require("GET /users/{id}", "users:view", userHandler.FindByID)
+		api.Require("PUT /users/{id}", "users:manage", userHandler.Update)
+		api.Require("DELETE /users/{id}", "users:manage", userHandler.Delete)
+		api.Require("POST /users/{id}/suspend", "users:manage", userHandler.Suspend)
+		api.Require("POST /users/{id}/deactivate", "users:manage", userHandler.Deactivate)
+		api.Require("POST /users/{id}/restore", "users:manage", userHandler.Restore)
+		api.Require("PUT /users/{id}/attributes", "users:manage", userAttributeHandler.UpdateForUser)
+		api.Require("POST /users/{id}/export", "users:manage", userPrivacyHandler.ExportUser)
+		api.Require("POST /users/{id}/erase", domain.UserErasePermission, userPrivacyHandler.EraseUser)
+		api.Require("POST /users/{id}/service-tokens", domain.ServiceTokenPermission, authHandler.CreateServiceToken)
+
+		// definisi atribut profil tambahan (department, phone, dll), bisa dipakai di kondisi sebagai user.<key>
+		api.Handle("GET /user-attributes", userAttributeHandler.FindDefinitions)
+		api.Require("POST /user-attributes", "user-attributes:manage", userAttributeHandler.CreateDefinition)
+		api.Require("PUT /user-attributes/{id}", "user-attributes:manage", userAttributeHandler.UpdateDefinition)
+		api.Require("DELETE /user-attributes/{id}", "user-attributes:manage", userAttributeHandler.DeleteDefinition)
+
+		// undangan user baru lewat email
+		api.Require("GET /invitations", "invitations:manage", invitationHandler.FindAll)
+		api.Require("POST /invitations", "invitations:manage", invitationHandler.Create)
+		api.Require("DELETE /invitations/{id}", "invitations:manage", invitationHandler.Revoke)
+
+		api.Require("PUT /user/{id}/roles", "roles:manage", userHandler.AssignRole)
+		api.Require("PUT /user/{id}/permissions", "permissions:manage", userHandler.AssignPermission)
 
-		subMux.HandleFunc("PUT /user/{id}/roles", userHandler.AssignRole)
-		subMux.HandleFunc("PUT /user/{id}/permissions", userHandler.AssignPermission)
+		// perubahan sebagian, header If-Match berisi ETag dari response sebelumnya
+		api.Require("POST /user/{id}/roles/attach", "roles:manage", userHandler.AttachRoles)
+		api.Require("POST /user/{id}/roles/detach", "roles:manage", userHandler.DetachRoles)
+		api.Require("POST /user/{id}/permissions/attach", "permissions:manage", userHandler.AttachPermissions)
+		api.Require("POST /user/{id}/permissions/detach", "permissions:manage", userHandler.DetachPermissions)
 
-		subMux.HandleFunc("GET /roles", permMiddleware.Require("roles:view", roleHandler.FindAll))
-        subMux.HandleFunc("GET /roles/{id}", permMiddleware.Require("roles:view", roleHandler.FindByID))
-        subMux.HandleFunc("POST /roles", permMiddleware.Require("roles:manage", roleHandler.Create))
-        subMux.HandleFunc("PUT /roles/{id}", permMiddleware.Require("roles:manage", roleHandler.Update))
-        subMux.HandleFunc("DELETE /roles/{id}", permMiddleware.Require("roles:manage", roleHandler.Delete))
+		api.Require("GET /roles", "roles:view", roleHandler.FindAll)
+		api.Require("GET /roles/{id}", "roles:view", roleHandler.FindByID)
+		api.Require("POST /roles", "roles:manage", roleHandler.Create)
+		api.Require("PUT /roles/{id}", "roles:manage", roleHandler.Update)
+		api.Require("DELETE /roles/{id}", "roles:manage", roleHandler.Delete)
+		api.Require("POST /roles/{id}/permissions/attach", "roles:manage", roleHandler.AttachPermissions)
+		api.Require("POST /roles/{id}/permissions/detach", "roles:manage", roleHandler.DetachPermissions)
+		api.Require("POST /roles/{id}/clone", "roles:manage", roleHandler.Clone)
 
-        subMux.HandleFunc("GET /permissions", permMiddleware.Require("permissions:view", permissionHandler.FindAll))
-        subMux.HandleFunc("GET /permissions/user/{id}", permMiddleware.Require("permissions:view", permissionHandler.FindByUserID))
+		// template role bawaan (viewer, editor, admin)
+		api.Require("GET /role-templates", "roles:view", roleHandler.Templates)
+		api.Require("POST /role-templates/{key}/roles", "roles:manage", roleHandler.CreateFromTemplate)
+		api.Require("POST /role-templates/{key}/reapply", "roles:manage", roleHandler.ReapplyTemplate)
+
+		api.Require("GET /permissions", "permissions:view", permissionHandler.FindAll)
+		api.Require("GET /permissions/user/{id}", "permissions:view", permissionHandler.FindByUserID)
+		api.Require("GET /permissions/{id}", "permissions:view", permissionHandler.FindByID)
+		api.Require("POST /permissions", "permissions:manage", permissionHandler.Create)
+		api.Require("PUT /permissions/{id}", "permissions:manage", permissionHandler.Update)
+		api.Require("DELETE /permissions/{id}", "permissions:manage", permissionHandler.Delete)
+
+		// organisasi (tenant)
+		api.Handle("GET /user/organizations", organizationHandler.Mine)
+		api.Require("GET /organizations", "organizations:view", organizationHandler.FindAll)
+		api.Require("POST /organizations", "organizations:manage", organizationHandler.Create)
+		api.Require("GET /organizations/{org_id}", "organizations:view", organizationHandler.FindByID)
+		api.Require("PUT /organizations/{org_id}", "organizations:manage", organizationHandler.Update)
+		api.Require("DELETE /organizations/{org_id}", "organizations:manage", organizationHandler.Delete)
+
+		// member organisasi, permission-nya boleh dari grant global maupun grant di organisasi {org_id}.
+		// admin organisasi otomatis punya domain.OrganizationAdminPermissions di organisasinya
+		api.RequireInOrganization("GET /organizations/{org_id}/members", domain.OrganizationMembersViewPermission, organizationHandler.FindMembers)
+		api.RequireInOrganization("POST /organizations/{org_id}/members", domain.OrganizationMembersManagePermission, organizationHandler.AddMember)
+		api.RequireInOrganization("GET /organizations/{org_id}/members/{user_id}", domain.OrganizationMembersViewPermission, organizationHandler.FindMember)
+		api.RequireInOrganization("DELETE /organizations/{org_id}/members/{user_id}", domain.OrganizationMembersManagePermission, organizationHandler.RemoveMember)
+		api.RequireInOrganization("PUT /organizations/{org_id}/members/{user_id}/roles", domain.OrganizationGrantsManagePermission, organizationHandler.AssignRole)
+		api.RequireInOrganization("PUT /organizations/{org_id}/members/{user_id}/permissions", domain.OrganizationGrantsManagePermission, organizationHandler.AssignPermission)
+
+		// permission per resource (object level)
+		api.Handle("GET /user/resources/{resource_type}", resourcePermissionHandler.Mine)
+		api.Require("GET /resource-permissions", "resource-permissions:manage", resourcePermissionHandler.FindByResource)
+		api.Require("POST /resource-permissions/grant", "resource-permissions:manage", resourcePermissionHandler.Grant)
+		api.Require("POST /resource-permissions/revoke", "resource-permissions:manage", resourcePermissionHandler.Revoke)
+
+		// relationship-based access control (tuple), berjalan berdampingan dengan RBAC
+		api.Handle("GET /user/objects", relationHandler.MyObjects)
+		api.Require("GET /relations/schema", "relations:view", relationHandler.Schema)
+		api.Require("GET /relations/tuples", "relations:view", relationHandler.ReadTuples)
+		api.Require("POST /relations/tuples", "relations:manage", relationHandler.WriteTuple)
+		api.Require("POST /relations/tuples/delete", "relations:manage", relationHandler.DeleteTuple)
+		api.Require("POST /relations/check", "relations:view", relationHandler.Check)
+		api.Require("POST /relations/expand", "relations:view", relationHandler.Expand)
+		api.Require("POST /relations/list-objects", "relations:view", relationHandler.ListObjects)
+
+		// elevasi sementara (just-in-time) dengan persetujuan approver
+		api.Handle("GET /user/access-requests", accessRequestHandler.Mine)
+		api.Handle("POST /access-requests", accessRequestHandler.Create)
+		api.Handle("POST /access-requests/{id}/cancel", accessRequestHandler.Cancel)
+		api.Require("GET /access-requests", domain.AccessRequestApprovePermission, accessRequestHandler.FindAll)
+		api.Require("GET /access-requests/{id}", domain.AccessRequestApprovePermission, accessRequestHandler.FindByID)
+		api.Require("POST /access-requests/{id}/approve", domain.AccessRequestApprovePermission, accessRequestHandler.Approve)
+		api.Require("POST /access-requests/{id}/reject", domain.AccessRequestApprovePermission, accessRequestHandler.Reject)
+
+		// separation of duties (role / permission yang saling eksklusif)
+		api.Require("GET /sod-constraints", "sod-constraints:manage", sodConstraintHandler.FindAll)
+		api.Require("POST /sod-constraints", "sod-constraints:manage", sodConstraintHandler.Create)
+		api.Require("DELETE /sod-constraints/{id}", "sod-constraints:manage", sodConstraintHandler.Delete)
+		api.Require("GET /sod-constraints/violations", "sod-constraints:manage", sodConstraintHandler.Violations)
+
+		// daftar endpoint & permission yang memproteksinya
+		api.Require("GET /routes", "routes:view", routeHandler.FindAll)
+
+		// alasan keputusan otorisasi untuk support
+		api.Require("GET /authz/explain", domain.AuthzExplainPermission, authzHandler.Explain)
+
+		// hak akses milik user sendiri, untuk frontend
+		api.Handle("GET /user/permissions", authzHandler.MyPermissions)
+		api.Handle("POST /authz/check", authzHandler.Check)
 	})
 
+	// permission yang dipakai route tapi belum ada di database dibuat otomatis
+	syncResult, err := permissionService.SyncRoutePermissions(context.Background())
+	if err != nil {
+		slog.Error("Gagal sinkronisasi permission route", "error", err)
+		return
+	}
+	if len(syncResult.Created) > 0 {
+		slog.Info("Permission route ditambahkan", "permissions", syncResult.Created)
+	}
+	if len(syncResult.Orphans) > 0 {
+		slog.Warn("Permission tidak dipakai route manapun", "permissions", syncResult.Orphans)
+	}
+
+	// user yang dibuat sebelum ada kolom identifier ter-normalisasi belum bisa login sebelum diisi
+	normalized, conflicts, err := userService.NormalizeIdentifiers(context.Background())
+	if err != nil {
+		slog.Error("Gagal normalisasi identifier user", "error", err)
+	}
+	if normalized > 0 {
+		slog.Info("Identifier user dinormalisasi", "users", normalized)
+	}
+	if len(conflicts) > 0 {
+		slog.Warn("Username mirip dengan user lain, perlu ditinjau", "usernames", conflicts)
+	}
+
+	// sweeper untuk assignment role / permission yang sudah kedaluwarsa
+	sweeperCtx, stopSweeper := context.WithCancel(context.Background())
+	defer stopSweeper()
+	go RunAssignmentSweeper(sweeperCtx, userService, sweepInterval())
+
 	port := os.Getenv("APP_PORT")
 	if port == ""{
 		port = "8000"
@@ -119,21 +310,39 @@ func Group(mux *http.ServeMux, prefix string, middleware func(http.Handler) http
 	mux.Handle(prefix, http.StripPrefix(strings.TrimSuffix(prefix, "/"), middleware(subMux)))
 }
 
-func NewValidator() *validator.Validate {
-	v := validator.New()
-
-	// Beritahu validator untuk memakai tag "json" sebagai nama field
-	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
-		// Ambil nilai dari tag json 
-		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
-		
-		// Abaikan jika tag json-nya adalah "-"
-		if name == "-" {
-			return ""
+// Chain menggabungkan beberapa middleware, dieksekusi berurutan dari kiri ke kanan
+func Chain(middlewares ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
 		}
-		
-		return name
-	})
+		return next
+	}
+}
+
+// RunAssignmentSweeper menghapus assignment kedaluwarsa secara berkala sampai ctx dibatalkan
+func RunAssignmentSweeper(ctx context.Context, userService domain.UserService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	return v
-}
\ No newline at end of file
+	for {
+		if _, err := userService.SweepExpiredAssignments(ctx); err != nil {
+			slog.Error("Gagal membersihkan assignment kedaluwarsa", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// interval sweeper dari env ASSIGNMENT_SWEEP_INTERVAL (format time.Duration, misal "5m"), default 1 menit
+func sweepInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("ASSIGNMENT_SWEEP_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Minute
+	}
+	return interval
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+
+	"golang-auth/internal/config"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/repository"
+	"golang-auth/internal/service"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const usage = `Pemakaian: rbac <perintah> [opsi]
+
+Perintah:
+  export   tulis role & permission dari database ke YAML
+  plan     tampilkan selisih antara file policy dan database
+  apply    sinkronkan database dengan file policy (satu transaksi)
+
+Opsi:
+`
+
+func main() {
+
+	err := godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	file := flags.String("file", config.RBACPolicyFile(), "lokasi file policy YAML untuk plan & apply")
+	out := flags.String("out", "", "file tujuan export, kosong berarti stdout")
+	prune := flags.Bool("prune", false, "hapus role yang tidak ada di file (hanya guard yang tercantum di file)")
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[2:])
+
+	db, err := config.NewDB()
+	if err != nil {
+		slog.Error("Gagal terhubung ke database", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	policyService := newPolicyService(db)
+	ctx := context.Background()
+
+	switch command {
+	case "export":
+		err = export(ctx, policyService, *out)
+	case "plan", "apply":
+		err = sync(ctx, policyService, command, *file, *prune)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		slog.Error("Perintah rbac gagal", "command", command, "error", err)
+		os.Exit(1)
+	}
+}
+
+func newPolicyService(db *sql.DB) domain.RBACPolicyService {
+	permissionRepo := repository.NewPermissionRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	sodConstraintRepo := repository.NewSoDConstraintRepository(db)
+
+	return service.NewRBACPolicyService(permissionRepo, roleRepo, sodConstraintRepo, db)
+}
+
+func export(ctx context.Context, policyService domain.RBACPolicyService, path string) error {
+	policy, err := policyService.Export(ctx)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(policy); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func sync(ctx context.Context, policyService domain.RBACPolicyService, command string, path string, prune bool) error {
+	policy, err := config.LoadRBACPolicy(path)
+	if err != nil {
+		return err
+	}
+
+	var plan *domain.RBACPlan
+	if command == "apply" {
+		plan, err = policyService.Apply(ctx, *policy, prune)
+	} else {
+		plan, err = policyService.Plan(ctx, *policy, prune)
+	}
+	if err != nil {
+		return err
+	}
+
+	printPlan(os.Stdout, plan)
+
+	if command == "plan" && len(plan.Changes) > 0 {
+		fmt.Println("\nJalankan `rbac apply` untuk menerapkan perubahan di atas.")
+	}
+	if command == "apply" {
+		fmt.Printf("\n%d perubahan diterapkan dari %s\n", len(plan.Changes), path)
+	}
+	return nil
+}
+
+func printPlan(out io.Writer, plan *domain.RBACPlan) {
+	symbols := map[string]string{
+		domain.RBACActionCreate: "+",
+		domain.RBACActionAttach: "+",
+		domain.RBACActionUpdate: "~",
+		domain.RBACActionDelete: "-",
+		domain.RBACActionDetach: "-",
+	}
+
+	if len(plan.Changes) == 0 {
+		fmt.Fprintln(out, "Tidak ada perubahan, database sudah sesuai dengan file policy.")
+	}
+	for _, change := range plan.Changes {
+		line := fmt.Sprintf("%s %s %s/%s", symbols[change.Action], change.Kind, change.Guard, change.Name)
+		if change.Detail != "" {
+			line += " -> " + change.Detail
+		}
+		fmt.Fprintln(out, line)
+	}
+
+	if len(plan.Unmanaged) > 0 {
+		fmt.Fprintln(out, "\nTidak dikelola file policy (dibiarkan):")
+		for _, change := range plan.Unmanaged {
+			fmt.Fprintf(out, "  %s %s/%s\n", change.Kind, change.Guard, change.Name)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
 	"log/slog"
 
 	"golang-auth/internal/config"
+	"golang-auth/internal/repository"
 	"golang-auth/internal/seeder"
+	"golang-auth/internal/service"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
@@ -28,8 +31,23 @@ func main() {
 	}
 	defer db.Close() // Pastikan koneksi ditutup setelah seeder selesai
 
-	// 2. Jalankan fungsi Seeder
-	seeder.SeedPermissionsAndSuperadmin(db)
+	// 2. Sinkronkan role & permission dari file policy RBAC (tanpa prune)
+	policy, err := config.LoadRBACPolicy(config.RBACPolicyFile())
+	if err != nil {
+		slog.Error("Gagal membaca file policy RBAC", "file", config.RBACPolicyFile(), "error", err)
+		return
+	}
+
+	policyService := service.NewRBACPolicyService(repository.NewPermissionRepository(db), repository.NewRoleRepository(db), repository.NewSoDConstraintRepository(db), db)
+	plan, err := policyService.Apply(context.Background(), *policy, false)
+	if err != nil {
+		slog.Error("Gagal menerapkan policy RBAC", "error", err)
+		return
+	}
+	slog.Info("Policy RBAC diterapkan", "changes", len(plan.Changes))
+
+	// 3. Jalankan fungsi Seeder
+	seeder.SeedSuperadmin(db)
 
 	// Nanti kalau ada seeder lain, tinggal panggil di sini:
 	// seeder.SeedRoles(db)
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang-auth/internal/config"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"golang-auth/internal/pkg/mailer"
+	"golang-auth/internal/repository"
+	"golang-auth/internal/service"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/joho/godotenv"
+)
+
+const usage = `Pemakaian: users <perintah> [opsi]
+
+Perintah:
+  import     buat user dari file CSV / JSONL (per batch, satu transaksi per batch)
+  export     tulis semua user beserta role-nya ke CSV / JSONL
+  normalize  isi email & username ter-normalisasi untuk user lama (juga dijalankan saat API start)
+
+Opsi:
+`
+
+func main() {
+
+	err := godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	file := flags.String("file", "", "file import, wajib untuk import")
+	out := flags.String("out", "", "file tujuan export, kosong berarti stdout")
+	format := flags.String("format", "", "csv atau jsonl, kosong berarti dari ekstensi file (default csv)")
+	guard := flags.String("guard", "", "guard role yang dipakai, kosong berarti guard default")
+	dryRun := flags.Bool("dry-run", false, "import: validasi saja tanpa menyimpan")
+	batchSize := flags.Int("batch-size", domain.DefaultImportBatchSize, "import: jumlah user per transaksi")
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[2:])
+
+	db, err := config.NewDB()
+	if err != nil {
+		slog.Error("Gagal terhubung ke database", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	userService := newUserService(db)
+	ctx := context.Background()
+
+	switch command {
+	case "import":
+		err = importUsers(ctx, userService, *file, fileFormat(*format, *file), domain.UserImportOptions{
+			DryRun:    *dryRun,
+			BatchSize: *batchSize,
+			Guard:     *guard,
+		})
+	case "export":
+		err = exportUsers(ctx, userService, *out, fileFormat(*format, *out), *guard)
+	case "normalize":
+		err = normalizeUsers(ctx, userService)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		slog.Error("Perintah users gagal", "command", command, "error", err)
+		os.Exit(1)
+	}
+}
+
+func newUserService(db *sql.DB) domain.UserService {
+	return service.NewUserService(
+		repository.NewUserRepository(db),
+		repository.NewRoleRepository(db),
+		repository.NewAuditRepository(db),
+		repository.NewSoDConstraintRepository(db),
+		repository.NewPersonalAccessTokenRepository(db),
+		repository.NewUserAttributeRepository(db),
+		mailer.New(),
+		db,
+		config.NewValidator(),
+	)
+}
+
+// fileFormat memakai flag -format kalau diisi, selain itu dari ekstensi file
+func fileFormat(format string, path string) string {
+	if format != "" {
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return domain.UserTransferJSONL
+	}
+	return domain.UserTransferCSV
+}
+
+func importUsers(ctx context.Context, userService domain.UserService, path string, format string, opts domain.UserImportOptions) error {
+	if path == "" {
+		return fmt.Errorf("flag -file wajib diisi untuk import")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rows, err := helper.DecodeUserImport(file, format)
+	if err != nil {
+		return err
+	}
+
+	result, err := userService.Import(ctx, rows, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, rowErr := range result.Errors {
+		message, _ := json.Marshal(helper.TranslateError(rowErr.Err))
+		fmt.Printf("baris %d (%s): %s\n", rowErr.Line, rowErr.Email, message)
+	}
+
+	verb := "dibuat"
+	if result.DryRun {
+		verb = "akan dibuat (dry run, tidak ada yang disimpan)"
+	}
+	fmt.Printf("\n%d dari %d user %s, %d gagal. import id: %s\n", result.Created, result.Total, verb, result.Failed, result.ImportID)
+
+	if result.Failed > 0 {
+		return fmt.Errorf("%d baris gagal diimport", result.Failed)
+	}
+	return nil
+}
+
+func exportUsers(ctx context.Context, userService domain.UserService, path string, format string, guard string) error {
+	var out io.Writer = os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	writer, err := helper.NewUserExportWriter(out, format)
+	if err != nil {
+		return err
+	}
+
+	err = userService.Export(ctx, guard, writer.Write)
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+func normalizeUsers(ctx context.Context, userService domain.UserService) error {
+	updated, conflicts, err := userService.NormalizeIdentifiers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, username := range conflicts {
+		fmt.Printf("username %s mirip dengan user lain, perlu ditinjau\n", username)
+	}
+	fmt.Printf("\n%d user dinormalisasi, %d bentrok\n", updated, len(conflicts))
+	return nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"golang-auth/internal/domain"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RBACPolicyFile mengembalikan lokasi file policy RBAC dari env RBAC_POLICY_FILE (default rbac.yaml)
+func RBACPolicyFile() string {
+	path := os.Getenv("RBAC_POLICY_FILE")
+	if path == "" {
+		return "rbac.yaml"
+	}
+	return path
+}
+
+// LoadRBACPolicy membaca file policy RBAC (YAML), field yang tidak dikenal ditolak
+// supaya salah ketik tidak diam-diam diabaikan
+func LoadRBACPolicy(path string) (*domain.RBACPolicy, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	policy := &domain.RBACPolicy{}
+	if err := decoder.Decode(policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"golang-auth/internal/domain"
+	"os"
+)
+
+// LoadRelationSchema membaca schema relasi dari file JSON di env RELATION_SCHEMA_FILE,
+// kalau env kosong pakai schema bawaan (folder & document bertingkat)
+func LoadRelationSchema() (domain.RelationSchema, error) {
+	path := os.Getenv("RELATION_SCHEMA_FILE")
+	if path == "" {
+		return DefaultRelationSchema(), nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := domain.RelationSchema{}
+	if err := json.Unmarshal(content, &schema); err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// DefaultRelationSchema: akses ke document & folder bisa mengalir lewat parent folder
+// dan lewat member group (group:eng#member)
+func DefaultRelationSchema() domain.RelationSchema {
+	this := domain.RewriteRule{This: true}
+
+	// editor: ditulis langsung, owner, atau editor dari parent folder
+	editor := domain.RelationRewrite{Union: []domain.RewriteRule{
+		this,
+		{ComputedRelation: "owner"},
+		{TupleToUserset: &domain.TupleToUserset{Tupleset: "parent", ComputedRelation: "editor"}},
+	}}
+
+	// viewer: ditulis langsung, semua editor, atau viewer dari parent folder
+	viewer := domain.RelationRewrite{Union: []domain.RewriteRule{
+		this,
+		{ComputedRelation: "editor"},
+		{TupleToUserset: &domain.TupleToUserset{Tupleset: "parent", ComputedRelation: "viewer"}},
+	}}
+
+	return domain.RelationSchema{
+		"user": {Relations: map[string]domain.RelationRewrite{}},
+		"group": {Relations: map[string]domain.RelationRewrite{
+			"member": {},
+		}},
+		"folder": {Relations: map[string]domain.RelationRewrite{
+			"parent": {},
+			"owner":  {},
+			"editor": editor,
+			"viewer": viewer,
+		}},
+		"document": {Relations: map[string]domain.RelationRewrite{
+			"parent": {},
+			"owner":  {},
+			"editor": editor,
+			"viewer": viewer,
+		}},
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import "golang-auth/internal/domain"
+
+// DefaultRoleTemplates adalah katalog role bawaan, setiap template mencakup permission template sebelumnya.
+// mengubah isi template di sini lalu panggil POST /role-templates/{key}/reapply untuk role turunannya
+func DefaultRoleTemplates() []domain.RoleTemplate {
+	viewer := []string{
+		"users:view",
+		"roles:view",
+		"permissions:view",
+		"routes:view",
+		"organizations:view",
+		"relations:view",
+	}
+
+	editor := append(append([]string{}, viewer...),
+		"relations:manage",
+		"resource-permissions:manage",
+	)
+
+	admin := append(append([]string{}, editor...),
+		"users:manage",
+		"users:erase",
+		"service-tokens:manage",
+		"invitations:manage",
+		"user-attributes:manage",
+		"roles:manage",
+		"permissions:manage",
+		"organizations:manage",
+		domain.OrganizationMembersViewPermission,
+		domain.OrganizationMembersManagePermission,
+		domain.OrganizationGrantsManagePermission,
+		"access-requests:approve",
+		"sod-constraints:manage",
+		"authz:explain",
+	)
+
+	return []domain.RoleTemplate{
+		{Key: "viewer", Name: "Viewer", Description: "Hanya bisa melihat data", Permissions: viewer},
+		{Key: "editor", Name: "Editor", Description: "Viewer ditambah mengelola relasi & akses resource", Permissions: editor},
+		{Key: "admin", Name: "Admin", Description: "Mengelola user, role, permission dan organisasi", Permissions: admin},
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"golang-auth/internal/pkg/identifier"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator dipakai API & CLI supaya nama field di pesan error sama (mengikuti tag json)
+func NewValidator() *validator.Validate {
+	v := validator.New()
+
+	// Beritahu validator untuk memakai tag "json" sebagai nama field
+	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		// Ambil nilai dari tag json 
+		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+		
+		// Abaikan jika tag json-nya adalah "-"
+		if name == "-" {
+			return ""
+		}
+		
+		return name
+	})
+
+	// tag "username": tanpa "@", karakter tak terlihat, atau campuran script yang mirip Latin
+	v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return identifier.ValidateUsername(fl.Field().String()) == nil
+	})
+
+	return v
+}
\ No newline at end of file
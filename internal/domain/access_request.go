@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// status access request
+const (
+	AccessRequestPending   = "pending"
+	AccessRequestApproved  = "approved"
+	AccessRequestRejected  = "rejected"
+	AccessRequestCancelled = "cancelled"
+)
+
+// permission yang dibutuhkan untuk menyetujui / menolak access request
+const AccessRequestApprovePermission = "access-requests:approve"
+
+// AccessRequest adalah permintaan elevasi sementara (just-in-time) ke sebuah role / permission.
+// kalau disetujui, user mendapat assignment yang otomatis kedaluwarsa setelah DurationMinutes
+type AccessRequest struct {
+	ID              uuid.UUID  `json:"id"`
+	UserID          uuid.UUID  `json:"user_id"`
+	Username        string     `json:"username"`
+	Type            string     `json:"type"` // role, permission
+	TargetID        uuid.UUID  `json:"target_id"`
+	TargetName      string     `json:"target_name"`
+	Justification   string     `json:"justification"`
+	DurationMinutes int        `json:"duration_minutes"`
+	Status          string     `json:"status"`
+	ReviewerID      *uuid.UUID `json:"reviewer_id"`
+	ReviewNote      string     `json:"review_note,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	ExpiresAt       *time.Time `json:"expires_at"` // terisi saat disetujui
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// DTO
+type AccessRequestCreateRequest struct {
+	Type            string    `json:"type" validate:"required,oneof=role permission"`
+	TargetID        uuid.UUID `json:"target_id" validate:"required"`
+	Justification   string    `json:"justification" validate:"required,min=10,max=1000"`
+	DurationMinutes int       `json:"duration_minutes" validate:"required,min=1,max=10080"` // maksimal 7 hari
+}
+
+type AccessRequestReviewRequest struct {
+	Note string `json:"note" validate:"max=500"`
+}
+
+type AccessRequestRepository interface {
+	Create(ctx context.Context, req *AccessRequest) error
+	FindByID(ctx context.Context, id uuid.UUID) (*AccessRequest, error)
+	// status kosong berarti semua status
+	FindAll(ctx context.Context, status string) ([]AccessRequest, error)
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]AccessRequest, error)
+	// justification dikosongkan & request pending dibatalkan, dipakai saat data user dihapus
+	AnonymizeByUserID(ctx context.Context, userID uuid.UUID) error
+
+	// ubah status hanya kalau masih pending, supaya satu request tidak diproses dua kali
+	UpdateStatus(ctx context.Context, req *AccessRequest) error
+
+	// nama role / permission yang diminta, sekaligus memastikan datanya ada
+	FindTargetName(ctx context.Context, requestType string, targetID uuid.UUID) (string, error)
+
+	WithTx(tx *sql.Tx) AccessRequestRepository
+}
+
+type AccessRequestService interface {
+	Create(ctx context.Context, userID uuid.UUID, req AccessRequestCreateRequest) (*AccessRequest, error)
+	Approve(ctx context.Context, id uuid.UUID, reviewerID uuid.UUID, req AccessRequestReviewRequest) error
+	Reject(ctx context.Context, id uuid.UUID, reviewerID uuid.UUID, req AccessRequestReviewRequest) error
+	Cancel(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (*AccessRequest, error)
+	FindAll(ctx context.Context, status string) ([]AccessRequest, error)
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]AccessRequest, error)
+}
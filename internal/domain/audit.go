@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog mencatat perubahan penting (assign, revoke, dll) beserta pelakunya.
+// ActorID kosong berarti dilakukan oleh sistem, misal sweeper assignment kedaluwarsa
+type AuditLog struct {
+	ID          uuid.UUID      `json:"id"`
+	ActorID     *uuid.UUID     `json:"actor_id"`
+	Action      string         `json:"action"`
+	SubjectType string         `json:"subject_type"`
+	SubjectID   string         `json:"subject_id"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, log *AuditLog) error
+	// log yang dilakukan oleh user atau dengan subject user tsb, urut dari yang terlama
+	FindByUser(ctx context.Context, userID uuid.UUID) ([]AuditLog, error)
+	// AnonymizeUser membuang data pribadi (email, username, ip, user agent) dari metadata log milik user,
+	// termasuk log undangan ke email tsb. id actor / subject tidak diubah
+	AnonymizeUser(ctx context.Context, userID uuid.UUID, email string) error
+
+	WithTx(tx *sql.Tx) AuditRepository
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// permission admin untuk melihat alasan keputusan otorisasi user lain
+const AuthzExplainPermission = "authz:explain"
+
+// keputusan akhir explain
+const (
+	AuthzDecisionGranted     = "granted"
+	AuthzDecisionConditional = "conditional" // hanya lewat grant bersyarat, tergantung atribut request
+	AuthzDecisionDenied      = "denied"
+)
+
+// status satu jalur grant
+const (
+	AuthzStepGranted          = "granted"
+	AuthzStepConditional      = "conditional"
+	AuthzStepPending          = "pending"           // assignment belum mulai berlaku
+	AuthzStepExpired          = "expired"           // assignment sudah kedaluwarsa, menunggu sweeper
+	AuthzStepOtherGuard       = "other-guard"       // permission dengan nama sama tapi milik guard lain
+	AuthzStepOrgInactive      = "org-inactive"      // grant organisasi, hanya berlaku saat organisasi tsb aktif
+	AuthzStepInvalidCondition = "invalid-condition" // kondisi gagal di-compile, selalu ditolak
+)
+
+// AuthzExplainRequest diambil dari query string GET /authz/explain
+type AuthzExplainRequest struct {
+	UserID         uuid.UUID  `json:"user_id" validate:"required"`
+	Permission     string     `json:"permission" validate:"required,max=100"`
+	Guard          string     `json:"guard" validate:"omitempty,oneof=web api internal"` // kosong berarti DefaultGuard
+	OrganizationID *uuid.UUID `json:"organization_id"`                                   // organisasi aktif yang disimulasikan, untuk route organisasi
+}
+
+// AuthzStep adalah satu jalur derivasi permission beserta statusnya
+type AuthzStep struct {
+	PermissionGrant
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+type AuthzExplanation struct {
+	UserID         uuid.UUID   `json:"user_id"`
+	Permission     string      `json:"permission"`
+	Guard          string      `json:"guard"`
+	OrganizationID *uuid.UUID  `json:"organization_id,omitempty"`
+	Decision       string      `json:"decision"`
+	Reason         string      `json:"reason"`
+	Steps          []AuthzStep `json:"steps"`
+	EvaluatedAt    time.Time   `json:"evaluated_at"`
+}
+
+// AuthzCheckQuery adalah satu pertanyaan "boleh nggak?" dari frontend,
+// resource_type & resource_id diisi berpasangan untuk cek level resource.
+// in_organization untuk route RequireInOrganization, grant di organisasi aktif ikut dihitung
+type AuthzCheckQuery struct {
+	Permission     string `json:"permission" validate:"required,max=100"`
+	ResourceType   string `json:"resource_type,omitempty" validate:"required_with=ResourceID,max=100"`
+	ResourceID     string `json:"resource_id,omitempty" validate:"required_with=ResourceType,max=255"`
+	InOrganization bool   `json:"in_organization,omitempty"`
+}
+
+type AuthzCheckRequest struct {
+	Checks []AuthzCheckQuery `json:"checks" validate:"required,min=1,max=100,dive"`
+}
+
+type AuthzCheckResult struct {
+	AuthzCheckQuery
+	Allowed bool `json:"allowed"`
+}
+
+// AuthzChecker menjawab satu query untuk user yang sedang login,
+// diisi oleh handler dengan PermissionMiddleware.Allowed / AllowedInOrganization / AllowedResource
+type AuthzChecker func(query AuthzCheckQuery) (bool, error)
+
+// EffectivePermissions adalah permission tanpa syarat yang berlaku untuk user pada guard.
+// OrganizationPermissions hanya berlaku di route organisasi saat organisasi tsb aktif.
+// grant bersyarat dan per resource dicek lewat /authz/check
+type EffectivePermissions struct {
+	Guard                   string     `json:"guard"`
+	OrganizationID          *uuid.UUID `json:"organization_id,omitempty"`
+	Permissions             []string   `json:"permissions"`
+	OrganizationPermissions []string   `json:"organization_permissions,omitempty"`
+}
+
+type AuthzService interface {
+	// Explain menjelaskan kenapa user diizinkan / ditolak untuk satu permission,
+	// mengikuti urutan pengecekan PermissionMiddleware.Require
+	Explain(ctx context.Context, req AuthzExplainRequest) (*AuthzExplanation, error)
+
+	// Check menjalankan banyak query sekaligus, hasilnya berurutan sesuai request
+	Check(ctx context.Context, req AuthzCheckRequest, checker AuthzChecker) ([]AuthzCheckResult, error)
+
+	// EffectivePermissions mengambil permission milik user sendiri (guard dari context)
+	EffectivePermissions(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID) (*EffectivePermissions, error)
+}
@@ -0,0 +1,54 @@
+package domain
+
+import "context"
+
+// guard memisahkan namespace role & permission berdasarkan cara autentikasi,
+// nama permission yang sama bisa punya arti berbeda di guard yang berbeda
+const (
+	GuardWeb      = "web"      // sesi admin console
+	GuardAPI      = "api"      // personal access token milik user
+	GuardInternal = "internal" // service account antar layanan
+
+	DefaultGuard = GuardAPI
+)
+
+// Guards adalah daftar guard yang dikenal, urutannya dipakai saat sinkronisasi permission route
+var Guards = []string{GuardWeb, GuardAPI, GuardInternal}
+
+type guardContextKey struct{}
+
+// IsValidGuard mengecek nama guard, string kosong dianggap valid (berarti DefaultGuard)
+func IsValidGuard(guard string) bool {
+	if guard == "" {
+		return true
+	}
+	for _, g := range Guards {
+		if g == guard {
+			return true
+		}
+	}
+	return false
+}
+
+// GuardOrDefault mengembalikan DefaultGuard kalau guard kosong
+func GuardOrDefault(guard string) string {
+	if guard == "" {
+		return DefaultGuard
+	}
+	return guard
+}
+
+// WithGuard menyimpan guard aktif di context, diisi oleh middleware autentikasi
+func WithGuard(ctx context.Context, guard string) context.Context {
+	return context.WithValue(ctx, guardContextKey{}, GuardOrDefault(guard))
+}
+
+// GuardFromContext mengembalikan guard aktif, DefaultGuard kalau belum diset
+// (misal dipanggil dari seeder atau sweeper)
+func GuardFromContext(ctx context.Context) string {
+	guard, ok := ctx.Value(guardContextKey{}).(string)
+	if !ok || guard == "" {
+		return DefaultGuard
+	}
+	return guard
+}
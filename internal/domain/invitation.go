@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// status undangan
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired" // tidak disimpan, dihitung dari expires_at saat dibaca
+)
+
+// Invitation adalah undangan lewat email dengan role & permission yang sudah dipilih pengundang.
+// token hanya dikirim lewat email, yang disimpan cuma hash-nya dan hanya bisa dipakai sekali
+type Invitation struct {
+	ID             uuid.UUID   `json:"id"`
+	Email          string      `json:"email"`
+	TokenHash      string      `json:"-"`
+	InviterID      *uuid.UUID  `json:"inviter_id"`
+	Guard          string      `json:"guard"` // guard pengundang, grant-nya dicek ulang di guard ini saat diterima
+	RoleIDs        []uuid.UUID `json:"role_ids"`
+	PermissionIDs  []uuid.UUID `json:"permission_ids"`
+	Status         string      `json:"status"`
+	AcceptedUserID *uuid.UUID  `json:"accepted_user_id,omitempty"`
+	AcceptedAt     *time.Time  `json:"accepted_at,omitempty"`
+	RevokedAt      *time.Time  `json:"revoked_at,omitempty"`
+	ExpiresAt      time.Time   `json:"expires_at"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// DTO
+type InvitationCreateRequest struct {
+	Email         string      `json:"email" validate:"required,email,max=255"`
+	RoleIDs       []uuid.UUID `json:"role_ids" validate:"omitempty,max=50,dive,uuid"`
+	PermissionIDs []uuid.UUID `json:"permission_ids" validate:"omitempty,max=50,dive,uuid"`
+	ExpiresInHours int        `json:"expires_in_hours" validate:"omitempty,min=1,max=720"` // kosong berarti 72 jam
+}
+
+// email user diambil dari undangan, bukan dari request
+type InvitationAcceptRequest struct {
+	Token    string `json:"token" validate:"required,len=64"`
+	Username string `json:"username" validate:"required,min=3,max=50,username"`
+	Password string `json:"password" validate:"required,min=6"`
+	Attributes map[string]any `json:"attributes"` // hanya atribut user_editable
+}
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *Invitation) error
+	FindByID(ctx context.Context, id uuid.UUID) (*Invitation, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*Invitation, error)
+	// status kosong berarti semua status
+	FindAll(ctx context.Context, status string) ([]Invitation, error)
+	// undangan pending yang belum kedaluwarsa untuk email tsb
+	ExistsPending(ctx context.Context, email string) (bool, error)
+
+	// ubah status hanya kalau masih pending, supaya token tidak bisa dipakai dua kali
+	UpdateStatus(ctx context.Context, invitation *Invitation) error
+	// undangan yang diterima user atau dikirim ke email user, dipakai untuk export data user
+	FindByUser(ctx context.Context, userID uuid.UUID, email string) ([]Invitation, error)
+	// ganti email undangan yang diterima user atau dikirim ke email user, dipakai saat data user dihapus
+	AnonymizeEmail(ctx context.Context, userID uuid.UUID, email string, replacement string) error
+
+	WithTx(tx *sql.Tx) InvitationRepository
+}
+
+type InvitationService interface {
+	// Create mengembalikan undangan, token dikirim ke email tujuan
+	Create(ctx context.Context, inviterID uuid.UUID, req InvitationCreateRequest) (*Invitation, error)
+	// Accept membuat user baru dengan role & permission dari undangan
+	Accept(ctx context.Context, req InvitationAcceptRequest) (*User, error)
+	Revoke(ctx context.Context, id uuid.UUID, actorID uuid.UUID) error
+	FindAll(ctx context.Context, status string) ([]Invitation, error)
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// permission route member organisasi, bisa diberikan global (admin platform) maupun di organisasi
+const (
+	OrganizationMembersViewPermission   = "organization-members:view"
+	OrganizationMembersManagePermission = "organization-members:manage"
+	OrganizationGrantsManagePermission  = "organization-grants:manage"
+)
+
+// OrganizationAdminPermissions otomatis dimiliki admin organisasi (is_admin) di organisasinya sendiri
+var OrganizationAdminPermissions = []string{
+	OrganizationMembersViewPermission,
+	OrganizationMembersManagePermission,
+	OrganizationGrantsManagePermission,
+}
+
+// Organization adalah tenant, tiap customer punya organisasi sendiri
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OrganizationMember adalah user yang tergabung ke sebuah organisasi
+// beserta role & permission yang hanya berlaku di organisasi tersebut
+type OrganizationMember struct {
+	UserID      uuid.UUID    `json:"user_id"`
+	Username    string       `json:"username"`
+	Email       string       `json:"email"`
+	IsAdmin     bool         `json:"is_admin"`
+	Roles       []Role       `json:"roles"`
+	Permissions []Permission `json:"permissions"`
+	JoinedAt    time.Time    `json:"joined_at"`
+}
+
+// DTO untuk request organisasi
+type OrganizationCreateRequest struct {
+	Name string `json:"name" validate:"required,min=3,max=100"`
+	Slug string `json:"slug" validate:"required,min=3,max=100,lowercase"`
+}
+
+type OrganizationUpdateRequest struct {
+	ID   uuid.UUID `json:"-"`
+	Name string    `json:"name" validate:"required,min=3,max=100"`
+	Slug string    `json:"slug" validate:"required,min=3,max=100,lowercase"`
+}
+
+type OrganizationMemberRequest struct {
+	UserID  uuid.UUID `json:"user_id" validate:"required"`
+	IsAdmin bool      `json:"is_admin"`
+}
+
+// repository interface
+type OrganizationRepository interface {
+	Create(ctx context.Context, o *Organization) error
+	Update(ctx context.Context, o *Organization) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (*Organization, error)
+	FindAll(ctx context.Context) ([]Organization, error)
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]Organization, error)
+
+	// member management
+	AddMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, isAdmin bool) error
+	RemoveMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) error
+	FindMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) (*OrganizationMember, error)
+	FindMembers(ctx context.Context, orgID uuid.UUID) ([]OrganizationMember, error)
+
+	// role & permission yang scope-nya organisasi
+	AssignRoles(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, roleIDs []uuid.UUID) error
+	RemoveAllRoles(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) error
+	AssignPermissions(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, permissionIDs []uuid.UUID) error
+	RemoveAllPermissions(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) error
+	// permission tanpa syarat milik user di organisasi pada guard tertentu, langsung maupun lewat role organisasi
+	FindHeldPermissionIDs(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, guard string) ([]uuid.UUID, error)
+
+	WithTx(tx *sql.Tx) OrganizationRepository
+}
+
+// service interface
+type OrganizationService interface {
+	Create(ctx context.Context, req OrganizationCreateRequest) (*Organization, error)
+	Update(ctx context.Context, req OrganizationUpdateRequest) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (*Organization, error)
+	FindAll(ctx context.Context) ([]Organization, error)
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]Organization, error)
+
+	AddMember(ctx context.Context, orgID uuid.UUID, req OrganizationMemberRequest) error
+	RemoveMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) error
+	FindMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) (*OrganizationMember, error)
+	FindMembers(ctx context.Context, orgID uuid.UUID) ([]OrganizationMember, error)
+	IsMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) (bool, error)
+	IsAdmin(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) (bool, error)
+
+	// actorID hanya bisa memberikan role milik organisasi & permission yang dia miliki sendiri
+	AssignRoles(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, actorID uuid.UUID, req AssignRoleRequest) error
+	AssignPermissions(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, actorID uuid.UUID, req AssignPermissionRequest) error
+}
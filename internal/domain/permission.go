@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,23 +11,135 @@ import (
 type Permission struct {
 	ID 		  uuid.UUID `json:"id"`
 	Name 	  string 	`json:"name"`
+	Guard     string    `json:"guard"`
+	Description string  `json:"description,omitempty"`
+	Group     string    `json:"group,omitempty"` // grup tampilan di admin UI, default prefix resource (roles:view -> roles)
+	Condition string    `json:"condition,omitempty"` // hanya terisi saat dibaca sebagai assignment (ABAC)
+	StartsAt  *time.Time `json:"starts_at,omitempty"` // masa berlaku assignment, kosong berarti tanpa batas
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// PermissionGroup adalah kumpulan permission dengan grup yang sama, untuk GET /permissions?grouped=true
+type PermissionGroup struct {
+	Group       string       `json:"group"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// field yang bisa dipakai filter & sort di GET /permissions
+var PermissionQueryFields = []string{"name", "guard", "group", "created_at"}
+
+// DTO untuk create, update
+type PermissionCreateRequest struct {
+	Name        string `json:"name" validate:"required,min=3,max=100"`
+	Guard       string `json:"guard" validate:"omitempty,oneof=web api internal"` // kosong berarti DefaultGuard
+	Description string `json:"description" validate:"max=255"`
+	Group       string `json:"group" validate:"max=100"`
+}
+
+type PermissionUpdateRequest struct {
+	ID          uuid.UUID `json:"-"`
+	Name        string    `json:"name" validate:"required,min=3,max=100"`
+	Guard       string    `json:"guard" validate:"omitempty,oneof=web api internal"`
+	Description string    `json:"description" validate:"max=255"`
+	Group       string    `json:"group" validate:"max=100"`
+}
+
+// PermissionSyncResult adalah hasil sinkronisasi permission route ke database saat startup
+type PermissionSyncResult struct {
+	Created []string `json:"created"`
+	Orphans []string `json:"orphans"`
+}
+
+// AssignmentOptions berisi atribut tambahan saat assign role / permission
+type AssignmentOptions struct {
+	// id role / permission -> ekspresi kondisi, assignment tanpa kondisi selalu berlaku
+	Conditions map[uuid.UUID]string
+	// id role / permission -> masa berlaku, assignment tanpa jadwal berlaku selamanya
+	Schedules map[uuid.UUID]AssignmentSchedule
+}
+
+// AssignmentSchedule adalah masa berlaku sebuah assignment, misal role untuk kontraktor
+type AssignmentSchedule struct {
+	StartsAt  *time.Time `json:"starts_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// PermissionGrant adalah satu jalur pemberian permission ke user beserta kondisinya
+type PermissionGrant struct {
+	Permission string     `json:"permission"`
+	Source     string     `json:"source"` // direct, role, organization-direct, organization-role, organization-admin
+	RoleID     *uuid.UUID `json:"role_id,omitempty"`
+	RoleName   string     `json:"role_name,omitempty"`
+	// semua kondisi harus bernilai true (kondisi assignment role & kondisi role-permission)
+	Conditions []string   `json:"conditions,omitempty"`
+
+	// hanya terisi oleh FindGrantPathsByUserID (explain)
+	Guard          string     `json:"guard,omitempty"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	StartsAt       *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// ResourceAttributeResolver mengambil atribut resource (misal owner_id) untuk dievaluasi di kondisi
+type ResourceAttributeResolver func(ctx context.Context, resourceID string) (map[string]any, error)
+
 type PermissionRepository interface {
+	Create(ctx context.Context, p *Permission) error
+	Update(ctx context.Context, p *Permission) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (*Permission, error)
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]Permission, error)
 	FindAll(ctx context.Context) ([]Permission, error)
+	FindPage(ctx context.Context, spec QuerySpec) ([]Permission, *PageMeta, error)
 	// Ini yang akan dipakai oleh Middleware Routing nanti
 
     // Mengambil semua nama permission (misal: "user.create", "user.delete") 
     // baik dari Role maupun Direct Permission
 	// return list permission aja
-    GetPermissionsByUserID(ctx context.Context, userID uuid.UUID) ([]string, error)
-	GetPermissionsByRoleIDs(ctx context.Context, roleIDs []uuid.UUID) ([]string, error)
+	// hanya assignment tanpa kondisi, yang bersyarat diambil lewat GetConditionalGrantsByUserID
+	// semua query hak akses hanya melihat permission milik guard yang diberikan
+    GetPermissionsByUserID(ctx context.Context, userID uuid.UUID, guard string) ([]string, error)
+	GetPermissionsByRoleIDs(ctx context.Context, roleIDs []uuid.UUID, guard string) ([]string, error)
+
+	// permission yang hanya berlaku di dalam organisasi (tenant) tertentu, tanpa role-permission bersyarat
+	GetPermissionsByUserIDInOrganization(ctx context.Context, userID uuid.UUID, orgID uuid.UUID, guard string) ([]string, error)
+
+	// grant bersyarat (ABAC) untuk satu permission
+	GetConditionalGrantsByUserID(ctx context.Context, userID uuid.UUID, permission string, guard string) ([]PermissionGrant, error)
+	// grant bersyarat lewat role organisasi, kondisinya ada di role-permission
+	GetConditionalGrantsByUserIDInOrganization(ctx context.Context, userID uuid.UUID, orgID uuid.UUID, permission string, guard string) ([]PermissionGrant, error)
+
+	// semua jalur pemberian permission ke user di semua guard, termasuk yang bersyarat,
+	// belum berlaku maupun sudah kedaluwarsa. dipakai untuk explain, bukan untuk pengecekan akses
+	FindGrantPathsByUserID(ctx context.Context, userID uuid.UUID, permission string) ([]PermissionGrant, error)
+
+	WithTx(tx *sql.Tx) PermissionRepository
 }
 
 type PermissionService interface {
+	Create(ctx context.Context, req PermissionCreateRequest) (*Permission, error)
+	Update(ctx context.Context, req PermissionUpdateRequest) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (*Permission, error)
 	FindAll(ctx context.Context) ([]Permission, error)
+	FindPage(ctx context.Context, spec QuerySpec) ([]Permission, *PageMeta, error)
+	FindAllGrouped(ctx context.Context) ([]PermissionGroup, error)
+
+	// SyncRoutePermissions membuat permission yang dipakai route tapi belum ada di database,
+	// sekaligus melaporkan permission yang tidak dipakai route manapun (orphan)
+	SyncRoutePermissions(ctx context.Context) (*PermissionSyncResult, error)
+	FindOrphanPermissions(ctx context.Context) ([]Permission, error)
+
+	// method hak akses di bawah ini memakai guard aktif dari context (GuardFromContext)
 	GetPermissionsByUserID(ctx context.Context, userID uuid.UUID) ([]string, error)
 	GetPermissionsByRoleIDs(ctx context.Context, roleIDs []uuid.UUID) ([]string, error)
+	GetPermissionsByUserIDInOrganization(ctx context.Context, userID uuid.UUID, orgID uuid.UUID) ([]string, error)
+
+	// EvaluateConditionalGrants mengevaluasi grant bersyarat milik user terhadap atribut
+	// request & resource. atribut "user" diisi otomatis oleh service
+	EvaluateConditionalGrants(ctx context.Context, userID uuid.UUID, permission string, attributes map[string]any) (bool, error)
+	// sama seperti EvaluateConditionalGrants, untuk grant bersyarat milik user di organisasi
+	EvaluateConditionalGrantsInOrganization(ctx context.Context, userID uuid.UUID, orgID uuid.UUID, permission string, attributes map[string]any) (bool, error)
 }
\ No newline at end of file
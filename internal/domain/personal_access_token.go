@@ -11,6 +11,8 @@ type PersonalAccessToken struct {
     ID         uuid.UUID  `json:"id"`
 	TokenHash  string 	  `json:"token"`
 	UserID 	   uuid.UUID  `json:"user_id"`
+	OrganizationID *uuid.UUID `json:"organization_id"` // token yang dibatasi ke satu organisasi (tenant)
+	Guard      string     `json:"guard"` // guard aktif untuk semua pengecekan permission dengan token ini
 	TokenName  string 	  `json:"token_name"`
 	LastUsedAt *time.Time `json:"last_used_at"`
 	ExpiresAt  *time.Time `json:"expires_at"`
@@ -20,8 +22,22 @@ type PersonalAccessToken struct {
 type PersonalAccessTokenRequest struct {
 	UserID 	   uuid.UUID  `json:"user_id" validate:"required,uuid"`
 	TokenName      string 	  `json:"token" validate:"required,min=3,max=100"`
+	OrganizationID *uuid.UUID `json:"organization_id"`
+	Guard          string     `json:"-" validate:"omitempty,oneof=web api internal"` // ditentukan server dari jenis kredensial, bukan dari client
 }
 
+// ServiceTokenRequest untuk token guard internal (service account) yang dibuat admin,
+// satu-satunya cara mendapatkan token internal
+type ServiceTokenRequest struct {
+	UserID         uuid.UUID  `json:"-"` // service account pemilik token, dari path
+	ActorID        uuid.UUID  `json:"-"` // admin yang membuat token
+	Name           string     `json:"name"` // divalidasi sebagai nama token (3-100 karakter)
+	OrganizationID *uuid.UUID `json:"organization_id"`
+}
+
+// ServiceTokenPermission adalah permission admin untuk membuat token service account
+const ServiceTokenPermission = "service-tokens:manage"
+
 
 type PersonalAccessTokenRepository interface {
     // Digunakan saat Login
@@ -35,6 +51,12 @@ type PersonalAccessTokenRepository interface {
     
     // Digunakan untuk "Logout from all devices"
     DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+
+    // Digunakan untuk export data user, urut dari yang terbaru
+    FindByUserID(ctx context.Context, userID uuid.UUID) ([]PersonalAccessToken, error)
+
+    // Digunakan setelah ganti password, semua token user kecuali token yang sedang dipakai
+    DeleteOthersByUserID(ctx context.Context, userID uuid.UUID, keepToken string) error
     
     // Digunakan untuk update kolom last_used_at tiap kali user akses API
     UpdateLastUsed(ctx context.Context, token string) error
@@ -42,8 +64,12 @@ type PersonalAccessTokenRepository interface {
 
 type PersonalAccessTokenService interface {
     Create(ctx context.Context, req PersonalAccessTokenRequest) (string, time.Time, error)
+    // CreateServiceToken membuat token guard internal, grant internal pemilik token harus
+    // bagian dari grant internal admin pembuatnya. setiap token dicatat di audit log
+    CreateServiceToken(ctx context.Context, req ServiceTokenRequest) (string, time.Time, error)
     FindByToken(ctx context.Context, token string) (*PersonalAccessToken, error)
     Delete(ctx context.Context, token string) error
     DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+    DeleteOthersByUserID(ctx context.Context, userID uuid.UUID, keepToken string) error
     UpdateLastUsed(ctx context.Context, token string) error
 }
\ No newline at end of file
@@ -0,0 +1,37 @@
+package domain
+
+// ukuran halaman list endpoint
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// operator filter dari query string: name=admin, name~=admin (mengandung), name!=admin
+const (
+	FilterEqual    = "eq"
+	FilterContains = "contains"
+	FilterNotEqual = "ne"
+)
+
+type QueryFilter struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// QuerySpec adalah parameter list endpoint (cursor, limit, sort, filter) hasil parse query string.
+// nama field hanya dari whitelist tiap resource, repository yang menerjemahkannya ke kolom SQL
+type QuerySpec struct {
+	Cursor  string // next_cursor dari halaman sebelumnya, isinya tidak perlu dipahami client
+	Limit   int
+	Sort    string // nama field, kosong berarti urut id (UUIDv7 = urutan dibuat)
+	Desc    bool   // sort=-name
+	Filters []QueryFilter
+}
+
+// PageMeta dikirim di Response.Meta untuk list endpoint
+type PageMeta struct {
+	Limit      int    `json:"limit"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
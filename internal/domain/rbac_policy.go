@@ -0,0 +1,68 @@
+package domain
+
+import "context"
+
+// RBACPolicy adalah definisi role & permission yang disimpan sebagai file YAML (policy-as-code),
+// dikelompokkan per guard karena setiap guard punya namespace sendiri
+type RBACPolicy struct {
+	Guards map[string]RBACGuardPolicy `yaml:"guards" json:"guards"`
+}
+
+type RBACGuardPolicy struct {
+	Permissions []RBACPermissionPolicy `yaml:"permissions" json:"permissions"`
+	Roles       []RBACRolePolicy       `yaml:"roles,omitempty" json:"roles"`
+}
+
+type RBACPermissionPolicy struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Group       string `yaml:"group,omitempty" json:"group,omitempty"`
+}
+
+type RBACRolePolicy struct {
+	Name        string   `yaml:"name" json:"name"`
+	Permissions []string `yaml:"permissions" json:"permissions"`
+}
+
+// jenis perubahan pada plan
+const (
+	RBACActionCreate = "create"
+	RBACActionUpdate = "update"
+	RBACActionDelete = "delete"
+	RBACActionAttach = "attach"
+	RBACActionDetach = "detach"
+)
+
+// objek yang diubah
+const (
+	RBACKindPermission     = "permission"
+	RBACKindRole           = "role"
+	RBACKindRolePermission = "role-permission"
+)
+
+type RBACChange struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	Guard  string `json:"guard"`
+	Name   string `json:"name"`
+	Detail string `json:"detail,omitempty"` // misal nama permission untuk attach / detach
+}
+
+// RBACPlan adalah selisih antara file policy dan isi database
+type RBACPlan struct {
+	Changes []RBACChange `json:"changes"`
+	// role & permission di database yang tidak ada di file dan tidak dihapus
+	// (permission tidak pernah di-prune karena bisa dipakai route)
+	Unmanaged []RBACChange `json:"unmanaged"`
+}
+
+type RBACPolicyService interface {
+	Export(ctx context.Context) (*RBACPolicy, error)
+
+	// Plan menghitung perubahan tanpa menulis apapun. prune menghapus role yang tidak ada di file,
+	// hanya untuk guard yang tercantum di file
+	Plan(ctx context.Context, policy RBACPolicy, prune bool) (*RBACPlan, error)
+
+	// Apply menjalankan plan dalam satu transaksi
+	Apply(ctx context.Context, policy RBACPolicy, prune bool) (*RBACPlan, error)
+}
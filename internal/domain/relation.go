@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+/*
+	Relationship-based access control (gaya Zanzibar).
+	Satu tuple dibaca: <namespace>:<object_id>#<relation>@<subject>
+	contoh: document:42#viewer@group:eng#member
+	artinya semua member group eng adalah viewer document 42
+*/
+
+// namespace untuk user, subject user ditulis user:<uuid>
+const UserRelationNamespace = "user"
+
+// SubjectSet adalah subject sebuah tuple, bisa user langsung (user:123)
+// atau sekumpulan subject lewat relasi lain (group:eng#member)
+type SubjectSet struct {
+	Namespace string `json:"namespace"`
+	ID        string `json:"id"`
+	Relation  string `json:"relation,omitempty"`
+}
+
+func (s SubjectSet) String() string {
+	if s.Relation == "" {
+		return s.Namespace + ":" + s.ID
+	}
+	return s.Namespace + ":" + s.ID + "#" + s.Relation
+}
+
+type RelationTuple struct {
+	Namespace string     `json:"namespace"`
+	ObjectID  string     `json:"object_id"`
+	Relation  string     `json:"relation"`
+	Subject   SubjectSet `json:"subject"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (t RelationTuple) String() string {
+	return t.Namespace + ":" + t.ObjectID + "#" + t.Relation + "@" + t.Subject.String()
+}
+
+// ParseRelationTuple mengubah string "document:42#viewer@group:eng#member" menjadi RelationTuple
+func ParseRelationTuple(s string) (RelationTuple, error) {
+	objectPart, subjectPart, ok := strings.Cut(s, "@")
+	if !ok {
+		return RelationTuple{}, errors.New("format tuple tidak valid, contoh: document:42#viewer@user:1")
+	}
+
+	objectRef, relation, ok := strings.Cut(objectPart, "#")
+	if !ok || relation == "" {
+		return RelationTuple{}, errors.New("relasi pada tuple wajib diisi")
+	}
+
+	namespace, objectID, err := ParseObjectRef(objectRef)
+	if err != nil {
+		return RelationTuple{}, err
+	}
+
+	subject, err := ParseSubjectSet(subjectPart)
+	if err != nil {
+		return RelationTuple{}, err
+	}
+
+	return RelationTuple{
+		Namespace: namespace,
+		ObjectID:  objectID,
+		Relation:  relation,
+		Subject:   subject,
+	}, nil
+}
+
+// ParseObjectRef mengubah "document:42" menjadi namespace dan id
+func ParseObjectRef(s string) (string, string, error) {
+	namespace, id, ok := strings.Cut(s, ":")
+	if !ok || namespace == "" || id == "" {
+		return "", "", errors.New("format object tidak valid, contoh: document:42")
+	}
+	return namespace, id, nil
+}
+
+// ParseSubjectSet mengubah "user:1" atau "group:eng#member" menjadi SubjectSet
+func ParseSubjectSet(s string) (SubjectSet, error) {
+	ref, relation, _ := strings.Cut(s, "#")
+	namespace, id, err := ParseObjectRef(ref)
+	if err != nil {
+		return SubjectSet{}, errors.New("format subject tidak valid, contoh: user:1 atau group:eng#member")
+	}
+	return SubjectSet{
+		Namespace: namespace,
+		ID:        id,
+		Relation:  relation,
+	}, nil
+}
+
+// ==========================================
+// SCHEMA
+// ==========================================
+
+// RelationSchema berisi definisi relasi per namespace
+type RelationSchema map[string]NamespaceDefinition
+
+type NamespaceDefinition struct {
+	// nama relasi -> aturan untuk menghitung relasi tersebut
+	Relations map[string]RelationRewrite `json:"relations"`
+}
+
+// RelationRewrite adalah gabungan (union) beberapa aturan,
+// kalau kosong artinya relasi hanya dari tuple yang ditulis langsung (this)
+type RelationRewrite struct {
+	Union []RewriteRule `json:"union"`
+}
+
+// RewriteRule hanya boleh mengisi salah satu field
+type RewriteRule struct {
+	// tuple yang ditulis langsung ke relasi ini
+	This bool `json:"this,omitempty"`
+	// relasi lain pada object yang sama, misal editor otomatis viewer
+	ComputedRelation string `json:"computed_relation,omitempty"`
+	// ikuti relasi ke object lain, misal parent->viewer
+	TupleToUserset *TupleToUserset `json:"tuple_to_userset,omitempty"`
+}
+
+type TupleToUserset struct {
+	Tupleset         string `json:"tupleset"`
+	ComputedRelation string `json:"computed_relation"`
+}
+
+// ExpandNode adalah pohon hasil Expand, menunjukkan dari mana saja subject sebuah relasi berasal
+type ExpandNode struct {
+	Type     string       `json:"type"` // union, this, computed_relation, tuple_to_userset
+	Object   string       `json:"object"`
+	Subjects []string     `json:"subjects,omitempty"`
+	Children []ExpandNode `json:"children,omitempty"`
+}
+
+// DTO
+type RelationTupleRequest struct {
+	Tuple string `json:"tuple" validate:"required,max=400"`
+}
+
+type RelationCheckRequest struct {
+	Object   string `json:"object" validate:"required"`
+	Relation string `json:"relation" validate:"required"`
+	Subject  string `json:"subject" validate:"required"`
+}
+
+type RelationExpandRequest struct {
+	Object   string `json:"object" validate:"required"`
+	Relation string `json:"relation" validate:"required"`
+}
+
+type RelationListObjectsRequest struct {
+	Namespace string `json:"namespace" validate:"required"`
+	Relation  string `json:"relation" validate:"required"`
+	Subject   string `json:"subject" validate:"required"`
+}
+
+// RelationTupleFilter, field yang kosong tidak dipakai sebagai filter
+type RelationTupleFilter struct {
+	Namespace        string
+	ObjectID         string
+	Relation         string
+	SubjectNamespace string
+	SubjectID        string
+	SubjectRelation  *string
+}
+
+type RelationTupleRepository interface {
+	Write(ctx context.Context, t *RelationTuple) error
+	Delete(ctx context.Context, t *RelationTuple) error
+	Read(ctx context.Context, filter RelationTupleFilter) ([]RelationTuple, error)
+	// semua object id yang punya tuple di namespace tersebut, dipakai ListObjects
+	FindObjectIDs(ctx context.Context, namespace string) ([]string, error)
+}
+
+type RelationService interface {
+	WriteTuple(ctx context.Context, req RelationTupleRequest) error
+	DeleteTuple(ctx context.Context, req RelationTupleRequest) error
+	ReadTuples(ctx context.Context, filter RelationTupleFilter) ([]RelationTuple, error)
+
+	Check(ctx context.Context, req RelationCheckRequest) (bool, error)
+	Expand(ctx context.Context, req RelationExpandRequest) (*ExpandNode, error)
+	ListObjects(ctx context.Context, req RelationListObjectsRequest) ([]string, error)
+	Schema() RelationSchema
+}
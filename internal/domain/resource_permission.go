@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// subject yang bisa menerima permission per resource
+const (
+	SubjectTypeUser = "user"
+	SubjectTypeRole = "role"
+)
+
+// ResourcePermission adalah permission yang hanya berlaku untuk satu resource,
+// misal "user X boleh documents:edit pada document 42"
+type ResourcePermission struct {
+	SubjectType    string    `json:"subject_type"`
+	SubjectID      uuid.UUID `json:"subject_id"`
+	SubjectName    string    `json:"subject_name"`
+	PermissionID   uuid.UUID `json:"permission_id"`
+	PermissionName string    `json:"permission_name"`
+	ResourceType   string    `json:"resource_type"`
+	ResourceID     string    `json:"resource_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// DTO untuk grant & revoke
+type ResourcePermissionRequest struct {
+	SubjectType  string    `json:"subject_type" validate:"required,oneof=user role"`
+	SubjectID    uuid.UUID `json:"subject_id" validate:"required"`
+	PermissionID uuid.UUID `json:"permission_id" validate:"required"`
+	ResourceType string    `json:"resource_type" validate:"required,max=50"`
+	ResourceID   string    `json:"resource_id" validate:"required,max=100"`
+}
+
+type ResourcePermissionRepository interface {
+	Grant(ctx context.Context, rp *ResourcePermission) error
+	Revoke(ctx context.Context, rp *ResourcePermission) error
+	FindByResource(ctx context.Context, resourceType string, resourceID string) ([]ResourcePermission, error)
+	// grant per resource yang diberikan langsung ke user, dipakai untuk export data user
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]ResourcePermission, error)
+
+	// cek grant per resource milik user, baik langsung maupun lewat role tanpa kondisi.
+	// hanya permission milik guard yang diberikan
+	HasResourcePermission(ctx context.Context, userID uuid.UUID, permission string, resourceType string, resourceID string, guard string) (bool, error)
+	// semua id resource yang boleh diakses user untuk permission tertentu
+	GetResourceIDs(ctx context.Context, userID uuid.UUID, permission string, resourceType string, guard string) ([]string, error)
+}
+
+type ResourcePermissionService interface {
+	Grant(ctx context.Context, req ResourcePermissionRequest) error
+	Revoke(ctx context.Context, req ResourcePermissionRequest) error
+	FindByResource(ctx context.Context, resourceType string, resourceID string) ([]ResourcePermission, error)
+
+	// Can bernilai true kalau user punya permission secara global (semua resource)
+	// atau punya grant khusus untuk resource tersebut
+	Can(ctx context.Context, userID uuid.UUID, permission string, resourceType string, resourceID string) (bool, error)
+
+	// ListResourceIDs mengembalikan id resource yang boleh diakses user.
+	// all bernilai true kalau user punya permission global, artinya semua resource boleh diakses
+	ListResourceIDs(ctx context.Context, userID uuid.UUID, permission string, resourceType string) (ids []string, all bool, err error)
+
+	// FilterResourceIDs menyaring daftar id sehingga yang tersisa hanya yang boleh diakses user
+	FilterResourceIDs(ctx context.Context, userID uuid.UUID, permission string, resourceType string, resourceIDs []string) ([]string, error)
+}
@@ -4,4 +4,5 @@ type Response struct {
 	Code int `json:"code"`
 	Status string `json:"status"`
 	Data any `json:"data"`
-}
\ No newline at end of file
+	Meta any `json:"meta,omitempty"` // misal PageMeta untuk list endpoint
+}
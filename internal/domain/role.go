@@ -11,6 +11,13 @@ import (
 type Role struct {
 	ID   	  uuid.UUID `json:"id"`
 	Name 	  string	`json:"name"`
+	Guard     string    `json:"guard"`
+	TemplateKey    string     `json:"template_key,omitempty"`    // template asal role, untuk re-apply
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"` // role khusus satu organisasi (tenant)
+	Version   int       `json:"version"` // naik setiap role / permission-nya berubah, dipakai sebagai ETag
+	Condition string    `json:"condition,omitempty"` // hanya terisi saat dibaca sebagai assignment (ABAC)
+	StartsAt  *time.Time `json:"starts_at,omitempty"` // masa berlaku assignment, kosong berarti tanpa batas
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -18,31 +25,88 @@ type Role struct {
 type RoleWithUsersAndPermissions struct {
 	ID 			uuid.UUID 	 `json:"id"`
 	Name 		string 		 `json:"name"`
+	Guard       string       `json:"guard"`
+	TemplateKey    string     `json:"template_key,omitempty"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	Version     int          `json:"version"`
 	Users 		[]User 		 `json:"users"`
 	Permissions []Permission `json:"permissions"`
 }
 
+// field yang bisa dipakai filter & sort di GET /roles
+var RoleQueryFields = []string{"name", "guard", "created_at"}
+
 // DTO untuk request create, update
 type RoleCreateRequest struct {
 	Name		  string		`json:"name" validate:"required,min=3,max=100"`		
+	Guard         string        `json:"guard" validate:"omitempty,oneof=web api internal"` // kosong berarti DefaultGuard
 	PermissionIDs []uuid.UUID	`json:"permission_ids" validate:"required,dive,uuid"`
+	Conditions    map[uuid.UUID]string `json:"conditions" validate:"omitempty,dive,required,max=2000"` // permission id -> kondisi
 }
 
 type RoleUpdateRequest struct {
 	ID		uuid.UUID			`json:"-"`
+	Version int                 `json:"-"` // dari header If-Match, 0 berarti tanpa pengecekan
 	Name	string				`json:"name" validate:"required,min=3,max=100"`
+	Guard   string              `json:"guard" validate:"omitempty,oneof=web api internal"` // kosong berarti tetap memakai guard yang tersimpan
 	PermissionIDs []uuid.UUID	`json:"permission_ids" validate:"required,dive,uuid"`
+	Conditions    map[uuid.UUID]string `json:"conditions" validate:"omitempty,dive,required,max=2000"` // permission id -> kondisi
+}
+
+// DTO untuk attach / detach sebagian permission tanpa mengganti seluruh isi role
+type RoleAttachPermissionRequest struct {
+	ID            uuid.UUID            `json:"-"`
+	Version       int                  `json:"-"`
+	PermissionIDs []uuid.UUID          `json:"permission_ids" validate:"required,min=1,dive,uuid"`
+	Conditions    map[uuid.UUID]string `json:"conditions" validate:"omitempty,dive,required,max=2000"` // permission id -> kondisi
+}
+
+type RoleDetachPermissionRequest struct {
+	ID            uuid.UUID   `json:"-"`
+	Version       int         `json:"-"`
+	PermissionIDs []uuid.UUID `json:"permission_ids" validate:"required,min=1,dive,uuid"`
+}
+
+type RoleCloneRequest struct {
+	ID   uuid.UUID `json:"-"`
+	Name string    `json:"name" validate:"required,min=3,max=100"`
+}
+
+// RoleTemplate adalah role bawaan (viewer, editor, admin) yang bisa dibuat per tenant
+type RoleTemplate struct {
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleFromTemplateRequest struct {
+	TemplateKey    string     `json:"-"`
+	Name           string     `json:"name" validate:"required_with=OrganizationID,omitempty,min=3,max=100"` // default key template untuk role global
+	Guard          string     `json:"guard" validate:"omitempty,oneof=web api internal"`
+	OrganizationID *uuid.UUID `json:"organization_id"`
 }
 
 // repository interface
 type RoleRepository interface {
 	Create(ctx context.Context, r *Role) error
-	AssignPermission(ctx context.Context, roleID uuid.UUID, permID []uuid.UUID) error
+	AssignPermission(ctx context.Context, roleID uuid.UUID, permID []uuid.UUID, opts AssignmentOptions) error
     RemoveAllPermissions(ctx context.Context, roleID uuid.UUID) error
+	// attach menimpa kondisi kalau permission sudah terpasang, detach mengabaikan yang tidak terpasang
+	AttachPermissions(ctx context.Context, roleID uuid.UUID, permIDs []uuid.UUID, opts AssignmentOptions) error
+	DetachPermissions(ctx context.Context, roleID uuid.UUID, permIDs []uuid.UUID) error
+	// BumpVersion mengembalikan ErrVersionConflict kalau expected (> 0) tidak sama dengan versi di database
+	BumpVersion(ctx context.Context, id uuid.UUID, expected int) error
 	FindById(ctx context.Context, id uuid.UUID) (*RoleWithUsersAndPermissions, error)
 	FindAll(ctx context.Context) ([]Role, error)
-	GetRoleByUserID(ctx context.Context, userID uuid.UUID) ([]string, error)
+	FindPage(ctx context.Context, spec QuerySpec) ([]Role, *PageMeta, error)
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]Role, error)
+	FindByTemplateKey(ctx context.Context, templateKey string) ([]Role, error)
+	// hanya role milik guard yang sedang aktif
+	GetRoleByUserID(ctx context.Context, userID uuid.UUID, guard string) ([]string, error)
 	Update(ctx context.Context, r *Role) error
+	// IsAssigned true kalau role masih dipegang user, global maupun organisasi, termasuk yang belum aktif / sudah kadaluarsa
+	IsAssigned(ctx context.Context, id uuid.UUID) (bool, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 
 	WithTx(tx *sql.Tx) RoleRepository
@@ -54,6 +118,18 @@ type RoleService interface {
 	Update(ctx context.Context, req RoleUpdateRequest) error
 	FindById(ctx context.Context, id uuid.UUID) (*RoleWithUsersAndPermissions, error)
 	FindAll(ctx context.Context) ([]Role, error)
+	FindPage(ctx context.Context, spec QuerySpec) ([]Role, *PageMeta, error)
 	GetRoleByUserID(ctx context.Context, userID uuid.UUID) ([]string, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+
+	// attach / detach mengembalikan role beserta permission setelah perubahan
+	AttachPermissions(ctx context.Context, req RoleAttachPermissionRequest) (*RoleWithUsersAndPermissions, error)
+	DetachPermissions(ctx context.Context, req RoleDetachPermissionRequest) (*RoleWithUsersAndPermissions, error)
+
+	// Clone menduplikasi role beserta permission & kondisinya dengan nama baru
+	Clone(ctx context.Context, req RoleCloneRequest) (*Role, error)
+	Templates() []RoleTemplate
+	CreateFromTemplate(ctx context.Context, req RoleFromTemplateRequest) (*Role, error)
+	// ReapplyTemplate menyamakan permission semua role turunan template dengan isi template saat ini
+	ReapplyTemplate(ctx context.Context, templateKey string) ([]Role, error)
 }
\ No newline at end of file
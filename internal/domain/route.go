@@ -0,0 +1,29 @@
+package domain
+
+// jenis proteksi sebuah route
+const (
+	RouteAccessPublic        = "public"
+	RouteAccessAuthenticated = "authenticated"
+	RouteAccessPermission    = "permission"
+	RouteAccessOrganization  = "organization" // permission global atau di organisasi aktif
+	RouteAccessResource      = "resource"
+)
+
+// Route adalah satu endpoint beserta permission yang memproteksinya
+type Route struct {
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	Access       string `json:"access"`
+	Permission   string `json:"permission,omitempty"`
+	ResourceType string `json:"resource_type,omitempty"`
+}
+
+// RouteCatalog adalah daftar route yang terdaftar di aplikasi, diisi saat startup
+type RouteCatalog interface {
+	Routes() []Route
+
+	// HasPermission bernilai true kalau permission dipakai untuk memproteksi minimal satu route,
+	// permission seperti ini tidak boleh dihapus / di-rename lewat API
+	HasPermission(name string) bool
+	Permissions() []string
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// tipe constraint separation of duties
+const (
+	SoDTypeRole       = "role"
+	SoDTypePermission = "permission"
+)
+
+// SoDConstraint (separation of duties) melarang satu user memiliki dua role
+// atau dua permission sekaligus, misal "payments-initiator" dan "payments-approver"
+type SoDConstraint struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"` // role, permission
+	FirstID     uuid.UUID `json:"first_id"`
+	FirstName   string    `json:"first_name"`
+	SecondID    uuid.UUID `json:"second_id"`
+	SecondName  string    `json:"second_name"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SoDViolation adalah user yang saat ini melanggar sebuah constraint
+type SoDViolation struct {
+	UserID     uuid.UUID     `json:"user_id"`
+	Username   string        `json:"username"`
+	Constraint SoDConstraint `json:"constraint"`
+}
+
+// DTO
+type SoDConstraintCreateRequest struct {
+	Name        string    `json:"name" validate:"required,min=3,max=100"`
+	Type        string    `json:"type" validate:"required,oneof=role permission"`
+	FirstID     uuid.UUID `json:"first_id" validate:"required"`
+	SecondID    uuid.UUID `json:"second_id" validate:"required,nefield=FirstID"`
+	Description string    `json:"description" validate:"max=500"`
+}
+
+type SoDConstraintRepository interface {
+	Create(ctx context.Context, c *SoDConstraint) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (*SoDConstraint, error)
+	FindAll(ctx context.Context) ([]SoDConstraint, error)
+
+	// constraint yang kedua sisinya ada di dalam ids, misal kumpulan permission sebuah role
+	FindConflicts(ctx context.Context, constraintType string, ids []uuid.UUID) ([]SoDConstraint, error)
+
+	// pelanggaran oleh user tertentu, userIDs kosong berarti semua user
+	FindViolations(ctx context.Context, userIDs []uuid.UUID) ([]SoDViolation, error)
+	// pelanggaran oleh user yang memiliki role tertentu
+	FindViolationsByRoleID(ctx context.Context, roleID uuid.UUID) ([]SoDViolation, error)
+
+	WithTx(tx *sql.Tx) SoDConstraintRepository
+}
+
+type SoDConstraintService interface {
+	Create(ctx context.Context, req SoDConstraintCreateRequest) (*SoDConstraint, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindAll(ctx context.Context) ([]SoDConstraint, error)
+	FindViolations(ctx context.Context) ([]SoDViolation, error)
+}
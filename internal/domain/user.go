@@ -12,31 +12,60 @@ type User struct {
 	ID          uuid.UUID    `json:"id"`
 	Username    string 	     `json:"username"`
 	Email       string 	     `json:"email"`
+	PendingEmail string      `json:"pending_email,omitempty"` // email baru yang menunggu verifikasi
 	Password    string 	     `json:"-"`
+	Status      string       `json:"status"`
+	SuspendedReason string     `json:"suspended_reason,omitempty"`
+	SuspendedUntil  *time.Time `json:"suspended_until,omitempty"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
 	Roles       []Role       `json:"roles"`
 	Permissions []Permission `json:"permissions"`
+	Attributes  map[string]any `json:"attributes,omitempty"` // atribut profil tambahan, lihat UserAttributeDefinition
+	Version     int          `json:"version"` // naik setiap data / role / permission user berubah, dipakai sebagai ETag
 	CreatedAt   time.Time    `json:"created_at"`
 	UpdatedAt   time.Time    `json:"updated_at"`
 }
 
+// field yang bisa dipakai filter & sort di GET /users
+// user berstatus deleted / erased hanya muncul kalau difilter dengan status tsb
+var UserQueryFields = []string{"username", "email", "status", "created_at"}
+
+// UserSearchQuery dari GET /users/search, semua kriteria opsional dan digabung dengan AND
+type UserSearchQuery struct {
+	Query          string     `validate:"max=100"` // potongan username / email
+	Role           string     `validate:"max=100"` // nama role yang dimiliki
+	Permission     string     `validate:"max=100"` // permission yang dimiliki, langsung atau lewat role
+	CreatedAfter   *time.Time
+	OrganizationID *uuid.UUID // tenant aktif, hasil dibatasi ke member organisasi tsb
+}
+
 // DTO (Data Transfer Objects)
 type UserCreateRequest struct {
-	Username	string		`json:"username" validate:"required,min=3,max=50"`
+	Username	string		`json:"username" validate:"required,min=3,max=50,username"`
 	Email		string  	`json:"email" validate:"required,email"`
 	Password	string  	`json:"password" validate:"required,min=6"`
 	RoleIDs		[]uuid.UUID `json:"role_ids" validate:"omitempty,dive,uuid"`
 	PermissionIDs []uuid.UUID `json:"permission_ids" validate:"omitempty,dive,uuid"`
+	Attributes    map[string]any `json:"attributes"` // atribut profil, semua atribut wajib harus diisi
+	// ActorID hanya bisa memberikan role & permission yang dia miliki, kosong berarti sistem
+	// (registrasi). undangan memakai pengundang sebagai ActorID
+	ActorID *uuid.UUID `json:"-"`
+	// SelfService untuk user yang mendaftar sendiri (register, terima undangan), hanya atribut
+	// user_editable yang boleh & wajib diisi
+	SelfService bool `json:"-"`
 }
 
 type UserRegisterRequest struct {
-	Username	string		`json:"username" validate:"required,min=3,max=50"`
+	Username	string		`json:"username" validate:"required,min=3,max=50,username"`
 	Email		string  	`json:"email" validate:"required,email"`
 	Password	string  	`json:"password" validate:"required,min=6"`
+	Attributes  map[string]any `json:"attributes"` // hanya atribut user_editable
 }
 
 type UserUpdateRequest struct {
 	ID		 uuid.UUID   `json:"-"`
-	Username string	     `json:"username" validate:"required,min=3,max=50"`
+	Version  int         `json:"-"` // dari header If-Match, 0 berarti tanpa pengecekan
+	Username string	     `json:"username" validate:"required,min=3,max=50,username"`
 	Email 	 string		 `json:"email" validate:"required,email"`
 }
 
@@ -46,11 +75,29 @@ type UserChangePasswordRequest struct {
     
     // eqfield=NewPassword memastikan input ini sama persis ketikannya dengan NewPassword
     ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
+
+    // logout dari semua perangkat lain, token yang sedang dipakai tetap berlaku
+    RevokeOtherTokens bool `json:"revoke_other_tokens"`
 }
 
+// DTO update profil oleh user sendiri, email baru baru dipakai setelah diverifikasi
+type UserProfileUpdateRequest struct {
+	ID       uuid.UUID `json:"-"`
+	Username string    `json:"username" validate:"required,min=3,max=50,username"`
+	Email    string    `json:"email" validate:"required,email"`
+}
+
+type EmailVerifyRequest struct {
+	Token string `json:"token" validate:"required,len=64"`
+}
+
+// Login boleh berisi email atau username, tidak peka huruf besar / kecil.
+// field email tetap diterima untuk client lama
 type UserLoginRequest struct {
-	Email	 string `json:"email" validate:"required,email"`
+	Login	 string `json:"login" validate:"required_without=Email,max=255"`
+	Email	 string `json:"email" validate:"omitempty,max=255"`
 	Password string `json:"password" validate:"required,min=3,max=100"`
+	OrganizationID *uuid.UUID `json:"organization_id"` // opsional, untuk token yang dibatasi ke satu organisasi
 }
 
 type UserLoginResponse struct {
@@ -63,44 +110,185 @@ type UserLoginResponse struct {
 
 type AssignRoleRequest struct {
 	ID	uuid.UUID `json:"-"`
+	Version int   `json:"-"` // dari header If-Match, hanya untuk assignment global
 	RoleIDs  []uuid.UUID `json:"role_ids" validate:"omitempty,dive,uuid"`
+	Conditions map[uuid.UUID]string `json:"conditions" validate:"omitempty,dive,required,max=2000"` // role id -> kondisi
+	Schedules map[uuid.UUID]AssignmentSchedule `json:"schedules"` // role id -> masa berlaku
 }
 
 type AssignPermissionRequest struct {
 	ID uuid.UUID `json:"-"`
+	Version int  `json:"-"`
 	PermissionIDs []uuid.UUID `json:"permission_ids" validate:"omitempty,dive,uuid"`
+	Conditions map[uuid.UUID]string `json:"conditions" validate:"omitempty,dive,required,max=2000"` // permission id -> kondisi
+	Schedules map[uuid.UUID]AssignmentSchedule `json:"schedules"` // permission id -> masa berlaku
+}
+
+// DTO untuk attach / detach sebagian role & permission user, Version dari header If-Match
+type UserAttachRoleRequest struct {
+	ID         uuid.UUID                        `json:"-"`
+	Version    int                              `json:"-"`
+	RoleIDs    []uuid.UUID                      `json:"role_ids" validate:"required,min=1,dive,uuid"`
+	Conditions map[uuid.UUID]string             `json:"conditions" validate:"omitempty,dive,required,max=2000"` // role id -> kondisi
+	Schedules  map[uuid.UUID]AssignmentSchedule `json:"schedules"`                                              // role id -> masa berlaku
+}
+
+type UserDetachRoleRequest struct {
+	ID      uuid.UUID   `json:"-"`
+	Version int         `json:"-"`
+	RoleIDs []uuid.UUID `json:"role_ids" validate:"required,min=1,dive,uuid"`
+}
+
+type UserAttachPermissionRequest struct {
+	ID            uuid.UUID                        `json:"-"`
+	Version       int                              `json:"-"`
+	PermissionIDs []uuid.UUID                      `json:"permission_ids" validate:"required,min=1,dive,uuid"`
+	Conditions    map[uuid.UUID]string             `json:"conditions" validate:"omitempty,dive,required,max=2000"` // permission id -> kondisi
+	Schedules     map[uuid.UUID]AssignmentSchedule `json:"schedules"`                                              // permission id -> masa berlaku
+}
+
+type UserDetachPermissionRequest struct {
+	ID            uuid.UUID   `json:"-"`
+	Version       int         `json:"-"`
+	PermissionIDs []uuid.UUID `json:"permission_ids" validate:"required,min=1,dive,uuid"`
+}
+
+// ExpiredAssignment adalah assignment role / permission user yang masa berlakunya sudah habis
+type ExpiredAssignment struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Type      string    `json:"type"` // role, permission
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 type UserRepository interface {
 	Create(ctx context.Context, u *User) error
 	FindByID(ctx context.Context, id uuid.UUID) (*User, error)
+	// email & username dicocokkan dengan bentuk ter-normalisasi (NFKC + case folding)
 	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByUsername(ctx context.Context, username string) (*User, error)
+	// daftar user tanpa role & permission, untuk admin
+	FindAll(ctx context.Context) ([]User, error)
+	FindPage(ctx context.Context, spec QuerySpec) ([]User, *PageMeta, error)
+	// hasil pencarian sudah berisi role tiap user, urut id terbaru
+	Search(ctx context.Context, query UserSearchQuery, spec QuerySpec) ([]User, *PageMeta, error)
 	Update(ctx context.Context, u *User) error
+	// hard delete, dipakai hanya untuk penghapusan permanen
 	Delete(ctx context.Context, id uuid.UUID) error
+	// status, alasan, suspended_until & deleted_at diambil dari u
+	UpdateStatus(ctx context.Context, u *User) error
+	// hanya id & kolom status, dipakai di setiap request terautentikasi
+	FindStatus(ctx context.Context, id uuid.UUID) (*User, error)
 
 	// role management
-	AssignRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID) error
+	AssignRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID, opts AssignmentOptions) error
 	RemoveAllRoles(ctx context.Context, userID uuid.UUID) error
+	// attach menimpa kondisi & masa berlaku kalau role sudah terpasang
+	AttachRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID, opts AssignmentOptions) error
+	DetachRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID) error
 
 	// permission management
-	AssignPermissions(ctx context.Context, userID uuid.UUID, permissionIDs []uuid.UUID) error
+	AssignPermissions(ctx context.Context, userID uuid.UUID, permissionIDs []uuid.UUID, opts AssignmentOptions) error
 	RemoveAllPermissions(ctx context.Context, userID uuid.UUID) error
+	AttachPermissions(ctx context.Context, userID uuid.UUID, permissionIDs []uuid.UUID, opts AssignmentOptions) error
+	DetachPermissions(ctx context.Context, userID uuid.UUID, permissionIDs []uuid.UUID) error
+
+	// BumpVersion mengembalikan ErrVersionConflict kalau expected (> 0) tidak sama dengan versi di database
+	BumpVersion(ctx context.Context, id uuid.UUID, expected int) error
+
+	// assignment yang sudah kedaluwarsa sebelum waktu tertentu, untuk dibersihkan sweeper
+	FindExpiredAssignments(ctx context.Context, before time.Time) ([]ExpiredAssignment, error)
+	DeleteExpiredAssignment(ctx context.Context, assignment ExpiredAssignment) error
+
+	// assignment sementara (misal hasil access request), kalau assignment sudah ada masa berlakunya
+	// diperpanjang, assignment permanen tidak diubah
+	GrantTemporaryAssignment(ctx context.Context, userID uuid.UUID, assignmentType string, id uuid.UUID, expiresAt time.Time) error
+
+	// user yang memiliki permission tertentu (global, tanpa kondisi), misal untuk mencari approver
+	FindByPermission(ctx context.Context, permission string) ([]User, error)
+
+	// role global & permission (langsung atau lewat role) tanpa syarat yang sedang berlaku,
+	// dipakai untuk membatasi apa yang boleh diberikan user ini ke orang lain. hanya milik guard yang diberikan
+	FindHeldRoleIDs(ctx context.Context, userID uuid.UUID, guard string) ([]uuid.UUID, error)
+	FindHeldPermissionIDs(ctx context.Context, userID uuid.UUID, guard string) ([]uuid.UUID, error)
+
+	// email ter-normalisasi & skeleton username yang sudah terpakai dari daftar yang diberikan, untuk cek duplikat import
+	FindTakenIdentifiers(ctx context.Context, emails []string, usernames []string) (map[string]bool, map[string]bool, error)
+	// Export memanggil fn untuk setiap user yang belum dihapus beserta nama role global pada guard,
+	// baris dibaca satu per satu dari database jadi tidak ada yang ditampung di memory
+	Export(ctx context.Context, guard string, fn func(row UserExportRow) error) error
 
 	// password management
 	ChangePassword(ctx context.Context, id uuid.UUID, newPassword string) error
 
+	// verifikasi perubahan email, token disimpan dalam bentuk hash
+	SetPendingEmail(ctx context.Context, id uuid.UUID, email string, tokenHash string, expiresAt time.Time) error
+	// hanya token yang belum kedaluwarsa
+	FindByEmailVerification(ctx context.Context, tokenHash string) (*User, error)
+	ConfirmPendingEmail(ctx context.Context, id uuid.UUID) error
+
+	// Erase menimpa username, email & status dari u, mengosongkan password dan data pribadi lain,
+	// lalu menghapus assignment, keanggotaan organisasi & atribut user
+	Erase(ctx context.Context, u *User) error
+
+	// user setelah id after yang kolom identifier ter-normalisasinya masih kosong (dibuat sebelum kolom tsb ada)
+	FindUnnormalized(ctx context.Context, after uuid.UUID, limit int) ([]User, error)
+	// skipSkeleton mengosongkan skeleton, dipakai kalau user lama ternyata kembar dengan user lain
+	SetNormalizedIdentifiers(ctx context.Context, u *User, skipSkeleton bool) error
+
 	WithTx(tx *sql.Tx) UserRepository
 }
 
 
 type UserService interface {
 	Create(ctx context.Context, req UserCreateRequest) error
+	// CreateWith sama dengan Create, fn dijalankan di transaksi yang sama setelah user & assignment-nya
+	// tersimpan. error dari fn membatalkan pembuatan user
+	CreateWith(ctx context.Context, req UserCreateRequest, fn func(tx *sql.Tx, user *User) error) error
 	Update(ctx context.Context, req UserUpdateRequest) error
 	AssignRoles(ctx context.Context, id uuid.UUID, req AssignRoleRequest) error
 	AssignPermissions(ctx context.Context, id uuid.UUID, req AssignPermissionRequest) error
+
+	// attach / detach mengembalikan user beserta role & permission setelah perubahan
+	AttachRoles(ctx context.Context, req UserAttachRoleRequest) (*User, error)
+	DetachRoles(ctx context.Context, req UserDetachRoleRequest) (*User, error)
+	AttachPermissions(ctx context.Context, req UserAttachPermissionRequest) (*User, error)
+	DetachPermissions(ctx context.Context, req UserDetachPermissionRequest) (*User, error)
+
 	FindByID(ctx context.Context, id uuid.UUID) (*User, error)
 	FindByEmail(ctx context.Context, email string) (*User, error)
-	Delete(ctx context.Context, id uuid.UUID) error
+	// FindByLogin mencari berdasarkan email kalau login mengandung "@", selain itu berdasarkan username
+	FindByLogin(ctx context.Context, login string) (*User, error)
+	FindPage(ctx context.Context, spec QuerySpec) ([]User, *PageMeta, error)
+	Search(ctx context.Context, query UserSearchQuery, spec QuerySpec) ([]User, *PageMeta, error)
+
+	// perubahan status akun, semua dicatat di audit log dan (selain restore) mencabut semua token user
+	Suspend(ctx context.Context, req UserSuspendRequest) error
+	Deactivate(ctx context.Context, req UserStatusRequest) error
+	// Delete adalah soft delete
+	Delete(ctx context.Context, req UserStatusRequest) error
+	Restore(ctx context.Context, req UserStatusRequest) error
+	// CheckActive dipakai AuthMiddleware, error kalau user tidak boleh memakai token
+	CheckActive(ctx context.Context, id uuid.UUID) error
+	// RecordLogin mencatat login berhasil ke audit log (AuditActionLogin), dipakai sebagai riwayat login
+	RecordLogin(ctx context.Context, id uuid.UUID, metadata map[string]any) error
+
+	// Import memvalidasi semua baris dulu, lalu membuat user per batch dalam transaksi terpisah.
+	// error yang dikembalikan hanya untuk kegagalan di luar baris (misal database mati)
+	Import(ctx context.Context, rows []UserImportRow, opts UserImportOptions) (*UserImportResult, error)
+	Export(ctx context.Context, guard string, fn func(row UserExportRow) error) error
 
 	ChangePassword(ctx context.Context, id uuid.UUID, req UserChangePasswordRequest) error	
+
+	// UpdateProfile mengubah username langsung, email baru dikirimi token verifikasi dulu
+	UpdateProfile(ctx context.Context, req UserProfileUpdateRequest) (*User, error)
+	VerifyEmail(ctx context.Context, req EmailVerifyRequest) error
+
+	// SweepExpiredAssignments menghapus assignment yang kedaluwarsa dan mencatatnya di audit log
+	SweepExpiredAssignments(ctx context.Context) (int, error)
+
+	// NormalizeIdentifiers mengisi kolom identifier ter-normalisasi untuk user lama, mengembalikan jumlah user
+	// yang diisi dan user yang bentrok dengan user lain (kembar secara visual atau hanya beda huruf besar / kecil)
+	NormalizeIdentifiers(ctx context.Context) (int, []string, error)
 }
\ No newline at end of file
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// tipe nilai atribut profil
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeDate    = "date" // YYYY-MM-DD
+)
+
+// key yang sudah dipakai atribut bawaan user.* di kondisi otorisasi, tidak boleh dipakai definisi
+var ReservedAttributeKeys = []string{"id", "username", "email", "roles", "status", "created_at"}
+
+// UserAttributeDefinition adalah atribut profil tambahan yang didefinisikan admin, misal department atau phone.
+// nilainya tersedia di kondisi otorisasi sebagai user.<key>
+type UserAttributeDefinition struct {
+	ID           uuid.UUID `json:"id"`
+	Key          string    `json:"key"`
+	Label        string    `json:"label"`
+	Type         string    `json:"type"`
+	Required     bool      `json:"required"`
+	Unique       bool      `json:"unique"`
+	UserEditable bool      `json:"user_editable"` // false berarti hanya admin yang bisa mengisi, atribut user_editable tidak tersedia di kondisi otorisasi
+	Rule         string    `json:"rule,omitempty"` // tag validator, misal "e164" atau "oneof=id en"
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// UserAttributeValue adalah nilai mentah yang disimpan, Key & Type ikut dibaca dari definisinya
+type UserAttributeValue struct {
+	UserID       uuid.UUID
+	DefinitionID uuid.UUID
+	Key          string
+	Type         string
+	Value        string
+	UserEditable bool // diambil dari definisi, tidak ikut ke user.* pada evaluasi kondisi
+}
+
+// Typed mengubah nilai tersimpan ke tipe yang dipakai JSON & ekspresi kondisi
+// (number -> float64, boolean -> bool, date -> time.Time), nilai yang gagal di-parse tetap string
+func (v UserAttributeValue) Typed() any {
+	switch v.Type {
+	case AttributeTypeNumber:
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil {
+			return f
+		}
+	case AttributeTypeBoolean:
+		if b, err := strconv.ParseBool(v.Value); err == nil {
+			return b
+		}
+	case AttributeTypeDate:
+		if t, err := time.Parse(time.DateOnly, v.Value); err == nil {
+			return t
+		}
+	}
+	return v.Value
+}
+
+// DTO
+type UserAttributeDefinitionCreateRequest struct {
+	Key          string `json:"key" validate:"required,min=2,max=50"` // huruf kecil, angka & underscore, dipakai sebagai user.<key>
+	Label        string `json:"label" validate:"required,max=100"`
+	Type         string `json:"type" validate:"required,oneof=string number boolean date"`
+	Required     bool   `json:"required"`
+	Unique       bool   `json:"unique"`
+	UserEditable bool   `json:"user_editable"`
+	Rule         string `json:"rule" validate:"max=255"`
+}
+
+// key & type tidak bisa diubah karena sudah dipakai kondisi dan nilai yang tersimpan
+type UserAttributeDefinitionUpdateRequest struct {
+	ID           uuid.UUID `json:"-"`
+	Label        string    `json:"label" validate:"required,max=100"`
+	Required     bool      `json:"required"`
+	Unique       bool      `json:"unique"`
+	UserEditable bool      `json:"user_editable"`
+	Rule         string    `json:"rule" validate:"max=255"`
+}
+
+// nilai null menghapus atribut, key yang tidak dikirim tidak diubah
+type UserAttributesUpdateRequest struct {
+	UserID      uuid.UUID      `json:"-"`
+	SelfService bool           `json:"-"` // dari PUT /user, hanya atribut user_editable yang boleh diubah
+	Attributes  map[string]any `json:"attributes" validate:"required"`
+}
+
+type UserAttributeRepository interface {
+	CreateDefinition(ctx context.Context, definition *UserAttributeDefinition) error
+	UpdateDefinition(ctx context.Context, definition *UserAttributeDefinition) error
+	DeleteDefinition(ctx context.Context, id uuid.UUID) error
+	FindDefinitionByID(ctx context.Context, id uuid.UUID) (*UserAttributeDefinition, error)
+	FindDefinitions(ctx context.Context) ([]UserAttributeDefinition, error)
+
+	// SyncUnique mengisi / mengosongkan unique_value semua nilai definisi tsb,
+	// gagal dengan error duplikat kalau nilai yang sudah ada bentrok
+	SyncUnique(ctx context.Context, definitionID uuid.UUID, unique bool) error
+
+	FindValues(ctx context.Context, userID uuid.UUID) ([]UserAttributeValue, error)
+	// unique menentukan apakah unique_value ikut diisi
+	SetValue(ctx context.Context, value UserAttributeValue, unique bool) error
+	DeleteValue(ctx context.Context, userID uuid.UUID, definitionID uuid.UUID) error
+
+	WithTx(tx *sql.Tx) UserAttributeRepository
+}
+
+type UserAttributeService interface {
+	FindDefinitions(ctx context.Context) ([]UserAttributeDefinition, error)
+	CreateDefinition(ctx context.Context, req UserAttributeDefinitionCreateRequest) (*UserAttributeDefinition, error)
+	UpdateDefinition(ctx context.Context, req UserAttributeDefinitionUpdateRequest) (*UserAttributeDefinition, error)
+	DeleteDefinition(ctx context.Context, id uuid.UUID) error
+
+	// SetUserAttributes memvalidasi lalu menyimpan nilai, mengembalikan semua atribut user setelah perubahan
+	SetUserAttributes(ctx context.Context, req UserAttributesUpdateRequest) (map[string]any, error)
+}
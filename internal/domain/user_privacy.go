@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// action audit log untuk setiap login berhasil, dipakai sebagai riwayat login
+const AuditActionLogin = "auth.login"
+
+// permission admin untuk menghapus data pribadi user lain
+const UserErasePermission = "users:erase"
+
+// UserDataExport adalah archive semua data yang disimpan tentang satu user (permintaan akses data / GDPR)
+type UserDataExport struct {
+	GeneratedAt         time.Time              `json:"generated_at"`
+	Profile             *User                  `json:"profile"` // termasuk role, permission & atribut
+	Organizations       []UserDataOrganization `json:"organizations"`
+	ResourcePermissions []ResourcePermission   `json:"resource_permissions"` // grant per resource yang diberikan langsung ke user
+	RelationTuples      []RelationTuple        `json:"relation_tuples"`      // tuple dengan user:<id> sebagai subject
+	Invitations         []Invitation           `json:"invitations"`          // undangan yang diterima user atau dikirim ke emailnya
+	Tokens              []UserDataToken        `json:"tokens"`
+	LoginHistory        []AuditLog             `json:"login_history"`
+	AuditEntries        []AuditLog             `json:"audit_entries"` // dilakukan oleh user atau terhadap user, selain login
+	AccessRequests      []AccessRequest        `json:"access_requests"`
+}
+
+// UserDataOrganization adalah keanggotaan user di satu organisasi beserta role & permission organisasinya
+type UserDataOrganization struct {
+	Organization
+	IsAdmin     bool         `json:"is_admin"`
+	Roles       []Role       `json:"roles"`
+	Permissions []Permission `json:"permissions"`
+	JoinedAt    time.Time    `json:"joined_at"`
+}
+
+// UserDataToken adalah metadata token tanpa hash-nya
+type UserDataToken struct {
+	ID             uuid.UUID  `json:"id"`
+	Name           string     `json:"name"`
+	Guard          string     `json:"guard"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// UserEraseRequest untuk menghapus akun sendiri (password wajib) atau oleh admin
+type UserEraseRequest struct {
+	ID       uuid.UUID `json:"-"`
+	ActorID  uuid.UUID `json:"-"`
+	Password string    `json:"password" validate:"max=100"` // wajib kalau ID == ActorID
+	Reason   string    `json:"reason" validate:"max=255"`
+}
+
+type UserPrivacyService interface {
+	// Export menyusun archive data user, actorID dicatat di audit log
+	Export(ctx context.Context, userID uuid.UUID, actorID uuid.UUID) (*UserDataExport, error)
+
+	// Erase menganonimkan user & data terkait dalam satu transaksi. baris user dan id di audit log tetap ada
+	// supaya jejak audit tetap utuh, tapi email, username, password, token, assignment, relation tuple
+	// dan data pribadi lain dihapus
+	Erase(ctx context.Context, req UserEraseRequest) error
+}
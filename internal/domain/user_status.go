@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// status akun user
+const (
+	UserStatusActive      = "active"
+	UserStatusSuspended   = "suspended"   // diblokir admin, dengan alasan & opsional sampai tanggal tertentu
+	UserStatusDeactivated = "deactivated" // dinonaktifkan, bisa diaktifkan lagi lewat restore
+	UserStatusDeleted     = "deleted"     // soft delete, data & audit trail tetap ada
+	UserStatusErased      = "erased"      // data pribadi sudah dianonimkan (GDPR), tidak bisa di-restore
+)
+
+// UserStatusRequest untuk deactivate, delete dan restore oleh admin
+type UserStatusRequest struct {
+	ID      uuid.UUID `json:"-"`
+	ActorID uuid.UUID `json:"-"`
+	Reason  string    `json:"reason" validate:"max=255"`
+}
+
+type UserSuspendRequest struct {
+	ID      uuid.UUID  `json:"-"`
+	ActorID uuid.UUID  `json:"-"`
+	Reason  string     `json:"reason" validate:"required,max=255"`
+	Until   *time.Time `json:"until"` // kosong berarti sampai di-restore
+}
+
+// ActiveError mengembalikan alasan user tidak boleh login / memakai token, nil kalau boleh.
+// suspend yang sudah melewati suspended_until dianggap aktif lagi
+func (u *User) ActiveError(now time.Time) error {
+	switch u.Status {
+	case UserStatusActive, "":
+		return nil
+	case UserStatusSuspended:
+		if u.SuspendedUntil != nil && !now.Before(*u.SuspendedUntil) {
+			return nil
+		}
+		message := "Akun sedang ditangguhkan"
+		if u.SuspendedUntil != nil {
+			message += " sampai " + u.SuspendedUntil.Format(time.RFC3339)
+		}
+		if u.SuspendedReason != "" {
+			message += ": " + u.SuspendedReason
+		}
+		return errors.New(message)
+	case UserStatusDeactivated:
+		return errors.New("Akun sudah dinonaktifkan")
+	case UserStatusDeleted, UserStatusErased:
+		return errors.New("Akun sudah dihapus")
+	}
+	return fmt.Errorf("status akun tidak dikenal: %s", u.Status)
+}
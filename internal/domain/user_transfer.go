@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// format file import / export user
+const (
+	UserTransferCSV   = "csv"   // header: username,email,password,roles (role dipisah "|")
+	UserTransferJSONL = "jsonl" // satu objek JSON per baris
+)
+
+// batas satu kali import, file yang lebih besar dipecah oleh pemanggil
+const (
+	MaxImportRows          = 10000
+	DefaultImportBatchSize = 100
+	MaxImportBatchSize     = 1000
+)
+
+// UserImportRow adalah satu baris file import, Line diisi decoder untuk laporan error
+type UserImportRow struct {
+	Line     int      `json:"-"`
+	Username string   `json:"username" validate:"required,min=3,max=45,username"`
+	Email    string   `json:"email" validate:"required,email,max=255"`
+	Password string   `json:"password" validate:"required,min=6"`
+	Roles    []string `json:"roles" validate:"omitempty,max=50,dive,required,max=100"` // nama role pada guard import
+	Attributes map[string]any `json:"attributes"` // atribut profil, hanya bisa diisi lewat JSON Lines
+}
+
+type UserImportOptions struct {
+	DryRun    bool       // hanya validasi, tidak ada yang ditulis
+	BatchSize int        // jumlah user per transaksi, kosong berarti DefaultImportBatchSize
+	Guard     string     // guard role yang dicari berdasarkan nama, kosong berarti DefaultGuard
+	ActorID   *uuid.UUID // kosong berarti dijalankan sistem (CLI)
+}
+
+// UserImportError adalah error satu baris. Message berisi err.Error(), handler / CLI menggantinya
+// dengan hasil terjemahan Err (validasi menjadi map field -> pesan)
+type UserImportError struct {
+	Line    int    `json:"line"`
+	Email   string `json:"email,omitempty"`
+	Message any    `json:"error"`
+	Err     error  `json:"-"`
+}
+
+type UserImportResult struct {
+	ImportID uuid.UUID         `json:"import_id"`
+	DryRun   bool              `json:"dry_run"`
+	Total    int               `json:"total"`
+	Created  int               `json:"created"` // saat dry run berisi jumlah yang akan dibuat
+	Failed   int               `json:"failed"`
+	Errors   []UserImportError `json:"errors"`
+}
+
+// UserExportRow adalah satu user di file export, kolomnya sama dengan import kecuali password
+type UserExportRow struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Status    string    `json:"status"`
+	Roles     []string  `json:"roles"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,7 @@
+package domain
+
+import "errors"
+
+// ErrVersionConflict dikembalikan saat versi di header If-Match tidak sama dengan versi di database,
+// artinya data sudah diubah request lain sejak terakhir dibaca
+var ErrVersionConflict = errors.New("data sudah diubah oleh admin lain, muat ulang lalu coba lagi")
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"golang-auth/internal/middleware"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type AccessRequestHandler struct {
+	accessRequestService domain.AccessRequestService
+}
+
+func NewAccessRequestHandler(accessRequestService domain.AccessRequestService) *AccessRequestHandler {
+	return &AccessRequestHandler{
+		accessRequestService: accessRequestService,
+	}
+}
+
+// user yang login mengajukan elevasi sementara untuk dirinya sendiri
+func (h *AccessRequestHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Gagal mengambil identitas user")
+		return
+	}
+
+	createReq := &domain.AccessRequestCreateRequest{}
+	err := json.NewDecoder(r.Body).Decode(createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	data, err := h.accessRequestService.Create(r.Context(), userID, *createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, data)
+}
+
+// access request milik user yang login
+func (h *AccessRequestHandler) Mine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Gagal mengambil identitas user")
+		return
+	}
+
+	data, err := h.accessRequestService.FindByUserID(r.Context(), userID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+// list untuk approver, bisa difilter ?status=pending
+func (h *AccessRequestHandler) FindAll(w http.ResponseWriter, r *http.Request) {
+	data, err := h.accessRequestService.FindAll(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *AccessRequestHandler) FindByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Access Request tidak valid")
+		return
+	}
+
+	data, err := h.accessRequestService.FindByID(r.Context(), id)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *AccessRequestHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	id, reviewerID, reviewReq, ok := parseAccessRequestReview(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.accessRequestService.Approve(r.Context(), id, reviewerID, reviewReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Access request berhasil disetujui")
+}
+
+func (h *AccessRequestHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	id, reviewerID, reviewReq, ok := parseAccessRequestReview(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.accessRequestService.Reject(r.Context(), id, reviewerID, reviewReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Access request berhasil ditolak")
+}
+
+func (h *AccessRequestHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Gagal mengambil identitas user")
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Access Request tidak valid")
+		return
+	}
+
+	err = h.accessRequestService.Cancel(r.Context(), id, userID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Access request berhasil dibatalkan")
+}
+
+// ambil id dari path, reviewer dari context dan catatan (opsional) dari body
+func parseAccessRequestReview(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, domain.AccessRequestReviewRequest, bool) {
+	reviewReq := domain.AccessRequestReviewRequest{}
+
+	reviewerID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Gagal mengambil identitas user")
+		return uuid.Nil, uuid.Nil, reviewReq, false
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Access Request tidak valid")
+		return uuid.Nil, uuid.Nil, reviewReq, false
+	}
+
+	// body boleh kosong kalau reviewer tidak memberi catatan
+	err = json.NewDecoder(r.Body).Decode(&reviewReq)
+	if err != nil && !errors.Is(err, io.EOF) {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return uuid.Nil, uuid.Nil, reviewReq, false
+	}
+
+	return id, reviewerID, reviewReq, true
+}
@@ -5,21 +5,26 @@ import (
 	"golang-auth/internal/domain"
 	"golang-auth/internal/helper"
 	"golang-auth/internal/middleware"
+	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandler struct {
 	userService  domain.UserService
 	tokenService domain.PersonalAccessTokenService
+	organizationService domain.OrganizationService
 }
 
 // constructor
-func NewAuthHandler(userService domain.UserService, tokenService domain.PersonalAccessTokenService) *AuthHandler {
+func NewAuthHandler(userService domain.UserService, tokenService domain.PersonalAccessTokenService, organizationService domain.OrganizationService) *AuthHandler {
 	return &AuthHandler{
 		userService: userService,
 		tokenService: tokenService,
+		organizationService: organizationService,
 	}
 }
 
@@ -29,8 +34,18 @@ func (h *AuthHandler) TesPing(w http.ResponseWriter, r *http.Request) {
 	helper.ResponseOK(w, "Success")
 }
 
-// method login
+// login untuk token API milik user (guard api)
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	h.login(w, r, domain.GuardAPI)
+}
+
+// login sesi admin console (guard web)
+func (h *AuthHandler) ConsoleLogin(w http.ResponseWriter, r *http.Request) {
+	h.login(w, r, domain.GuardWeb)
+}
+
+// guard ditentukan dari endpoint login, bukan dari body request. guard internal hanya lewat CreateServiceToken
+func (h *AuthHandler) login(w http.ResponseWriter, r *http.Request, guard string) {
 	
 	// set header di awal, agar semua response otomatis berformat JSON
 	w.Header().Set("Content-Type", "application/json")
@@ -49,29 +64,67 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		deviceName = "Unknown Device"
 	}
 
-	user, err := h.userService.FindByEmail(r.Context(), loginRequest.Email)
+	// login bisa pakai email atau username, field email untuk client lama
+	login := loginRequest.Login
+	if login == "" {
+		login = loginRequest.Email
+	}
+
+	user, err := h.userService.FindByLogin(r.Context(), login)
 	if err != nil {
-		helper.ResponseUnauthorized(w, "Email atau password salah")
+		helper.ResponseUnauthorized(w, "Email / username atau password salah")
 		return
 	}
 
 	// cek password request dan password asli
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginRequest.Password))
 	if err != nil {
-		helper.ResponseUnauthorized(w, "Email atau password salah")
+		helper.ResponseUnauthorized(w, "Email / username atau password salah")
+		return
+	}
+
+	// status dicek setelah password supaya status akun tidak bocor ke orang yang tidak tau passwordnya
+	err = user.ActiveError(time.Now())
+	if err != nil {
+		helper.ResponseForbidden(w, err.Error())
 		return
 	}
 
+	// kalau login untuk organisasi tertentu, pastikan user memang anggotanya
+	if loginRequest.OrganizationID != nil {
+		isMember, err := h.organizationService.IsMember(r.Context(), *loginRequest.OrganizationID, user.ID)
+		if err != nil {
+			helper.ResponseInternalError(w, "Gagal memverifikasi keanggotaan organisasi")
+			return
+		}
+		if !isMember {
+			helper.ResponseForbidden(w, "Anda bukan anggota organisasi ini")
+			return
+		}
+	}
+
 	// kalau password benar generate tokennya
 	token, expiresAt, err := h.tokenService.Create(r.Context(), domain.PersonalAccessTokenRequest{
 		UserID: user.ID,
 		TokenName: deviceName,
+		OrganizationID: loginRequest.OrganizationID,
+		Guard: guard,
 	})
 	if err != nil {
 		helper.ResponseInternalError(w, "Gagal membuat token sistem")
 		return
 	}
 
+	// riwayat login untuk export data user, gagal mencatat tidak membatalkan login
+	err = h.userService.RecordLogin(r.Context(), user.ID, map[string]any{
+		"ip": helper.ClientIP(r),
+		"user_agent": deviceName,
+		"guard": guard,
+	})
+	if err != nil {
+		slog.Error("Gagal mencatat riwayat login", "user_id", user.ID, "error", err)
+	}
+
 	loginResponse := domain.UserLoginResponse{
 		Id: user.ID.String(),
 		Username: user.Username,
@@ -98,4 +151,110 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	helper.ResponseOK(w, "Logout berhasil")
-}
\ No newline at end of file
+}
+// ganti password user yang sedang login, opsional logout dari semua perangkat lain
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Gagal mengambil identitas user")
+		return
+	}
+	tokenString := r.Context().Value(middleware.TokenContextKey).(string)
+
+	changeReq := &domain.UserChangePasswordRequest{}
+	err := json.NewDecoder(r.Body).Decode(changeReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	err = h.userService.ChangePassword(r.Context(), userID, *changeReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	if changeReq.RevokeOtherTokens {
+		err = h.tokenService.DeleteOthersByUserID(r.Context(), userID, tokenString)
+		if err != nil {
+			helper.ResponseInternalError(w, "Password berhasil diubah, tapi gagal logout dari perangkat lain")
+			return
+		}
+	}
+
+	helper.ResponseOK(w, "Password berhasil diubah")
+}
+
+// admin membuat token guard internal untuk service account, token hanya ditampilkan sekali di response
+func (h *AuthHandler) CreateServiceToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID User tidak valid")
+		return
+	}
+
+	tokenReq := &domain.ServiceTokenRequest{}
+	err = json.NewDecoder(r.Body).Decode(tokenReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	user, err := h.userService.FindByID(r.Context(), userID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+	err = user.ActiveError(time.Now())
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
+
+	if tokenReq.OrganizationID != nil {
+		isMember, err := h.organizationService.IsMember(r.Context(), *tokenReq.OrganizationID, user.ID)
+		if err != nil {
+			helper.ResponseInternalError(w, "Gagal memverifikasi keanggotaan organisasi")
+			return
+		}
+		if !isMember {
+			helper.ResponseBadRequest(w, "User bukan anggota organisasi ini")
+			return
+		}
+	}
+
+	tokenReq.UserID = user.ID
+	tokenReq.ActorID, _ = r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+
+	token, expiresAt, err := h.tokenService.CreateServiceToken(r.Context(), *tokenReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, domain.UserLoginResponse{
+		Id: user.ID.String(),
+		Username: user.Username,
+		Email: user.Email,
+		Token: token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// verifikasi email baru dengan token yang dikirim ke alamat tersebut, tidak perlu login
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	verifyReq := &domain.EmailVerifyRequest{}
+	err := json.NewDecoder(r.Body).Decode(verifyReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	err = h.userService.VerifyEmail(r.Context(), *verifyReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Email berhasil diverifikasi")
+}
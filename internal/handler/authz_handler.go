@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"golang-auth/internal/middleware"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type AuthzHandler struct {
+	authzService domain.AuthzService
+	permMiddleware *middleware.PermissionMiddleware
+}
+
+// permMiddleware dipakai untuk batch check supaya hasilnya sama persis dengan pengecekan route
+func NewAuthzHandler(authzService domain.AuthzService, permMiddleware *middleware.PermissionMiddleware) *AuthzHandler {
+	return &AuthzHandler{
+		authzService: authzService,
+		permMiddleware: permMiddleware,
+	}
+}
+
+// alasan keputusan otorisasi, ?user_id=...&permission=roles:view[&guard=web][&organization_id=...]
+func (h *AuthzHandler) Explain(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	userID, err := uuid.Parse(query.Get("user_id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID User tidak valid")
+		return
+	}
+
+	explainReq := domain.AuthzExplainRequest{
+		UserID: userID,
+		Permission: query.Get("permission"),
+		Guard: query.Get("guard"),
+	}
+
+	if rawOrgID := query.Get("organization_id"); rawOrgID != "" {
+		orgID, err := uuid.Parse(rawOrgID)
+		if err != nil {
+			helper.ResponseBadRequest(w, "Format ID Organisasi tidak valid")
+			return
+		}
+		explainReq.OrganizationID = &orgID
+	}
+
+	data, err := h.authzService.Explain(r.Context(), explainReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+// cek banyak permission sekaligus untuk user yang sedang login, misal untuk menyembunyikan tombol di SPA.
+// atribut request.* pada kondisi (ABAC) diambil dari request check ini
+func (h *AuthzHandler) Check(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Sesi tidak valid atau tidak ditemukan")
+		return
+	}
+
+	checkReq := &domain.AuthzCheckRequest{}
+	err := json.NewDecoder(r.Body).Decode(checkReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	data, err := h.authzService.Check(r.Context(), *checkReq, func(query domain.AuthzCheckQuery) (bool, error) {
+		if query.ResourceID != "" {
+			return h.permMiddleware.AllowedResource(r, userID, query.Permission, query.ResourceType, query.ResourceID)
+		}
+		if query.InOrganization {
+			return h.permMiddleware.AllowedInOrganization(r, userID, query.Permission)
+		}
+		return h.permMiddleware.Allowed(r, userID, query.Permission)
+	})
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+// permission efektif milik user yang sedang login, tidak butuh permissions:view
+func (h *AuthzHandler) MyPermissions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Sesi tidak valid atau tidak ditemukan")
+		return
+	}
+
+	var orgID *uuid.UUID
+	if activeOrgID, ok := middleware.OrganizationFromRequest(r); ok {
+		orgID = &activeOrgID
+	}
+
+	data, err := h.authzService.EffectivePermissions(r.Context(), userID, orgID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
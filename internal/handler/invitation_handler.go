@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"golang-auth/internal/middleware"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type InvitationHandler struct {
+	invitationService domain.InvitationService
+}
+
+func NewInvitationHandler(invitationService domain.InvitationService) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+	}
+}
+
+// undang user baru lewat email, role & permission dibatasi yang dimiliki pengundang
+func (h *InvitationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	inviterID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Gagal mengambil identitas user")
+		return
+	}
+
+	createReq := &domain.InvitationCreateRequest{}
+	err := json.NewDecoder(r.Body).Decode(createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	data, err := h.invitationService.Create(r.Context(), inviterID, *createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, data)
+}
+
+// route publik, penerima undangan belum punya akun
+func (h *InvitationHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	acceptReq := &domain.InvitationAcceptRequest{}
+	err := json.NewDecoder(r.Body).Decode(acceptReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	data, err := h.invitationService.Accept(r.Context(), *acceptReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, data)
+}
+
+// list undangan, bisa difilter ?status=pending
+func (h *InvitationHandler) FindAll(w http.ResponseWriter, r *http.Request) {
+	data, err := h.invitationService.FindAll(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *InvitationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Gagal mengambil identitas user")
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Undangan tidak valid")
+		return
+	}
+
+	err = h.invitationService.Revoke(r.Context(), id, actorID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Undangan berhasil dibatalkan")
+}
@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"encoding/json"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"golang-auth/internal/middleware"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type OrganizationHandler struct {
+	organizationService domain.OrganizationService
+}
+
+func NewOrganizationHandler(organizationService domain.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+	}
+}
+
+func (h *OrganizationHandler) FindAll(w http.ResponseWriter, r *http.Request) {
+	data, err := h.organizationService.FindAll(r.Context())
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+// organisasi milik user yang sedang login
+func (h *OrganizationHandler) Mine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Gagal mengambil identitas user")
+		return
+	}
+
+	data, err := h.organizationService.FindByUserID(r.Context(), userID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *OrganizationHandler) FindByID(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(r.PathValue("org_id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Organisasi tidak valid")
+		return
+	}
+
+	data, err := h.organizationService.FindByID(r.Context(), orgID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	createReq := &domain.OrganizationCreateRequest{}
+	err := json.NewDecoder(r.Body).Decode(createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	data, err := h.organizationService.Create(r.Context(), *createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, data)
+}
+
+func (h *OrganizationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(r.PathValue("org_id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Organisasi tidak valid")
+		return
+	}
+
+	updateReq := &domain.OrganizationUpdateRequest{}
+	err = json.NewDecoder(r.Body).Decode(updateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	updateReq.ID = orgID
+
+	err = h.organizationService.Update(r.Context(), *updateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Organisasi berhasil diperbarui")
+}
+
+func (h *OrganizationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(r.PathValue("org_id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Organisasi tidak valid")
+		return
+	}
+
+	err = h.organizationService.Delete(r.Context(), orgID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Data berhasil terhapus")
+}
+
+func (h *OrganizationHandler) FindMembers(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(r.PathValue("org_id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Organisasi tidak valid")
+		return
+	}
+
+	data, err := h.organizationService.FindMembers(r.Context(), orgID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *OrganizationHandler) FindMember(w http.ResponseWriter, r *http.Request) {
+	orgID, userID, ok := parseOrganizationMemberPath(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.organizationService.FindMember(r.Context(), orgID, userID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *OrganizationHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(r.PathValue("org_id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Organisasi tidak valid")
+		return
+	}
+
+	memberReq := &domain.OrganizationMemberRequest{}
+	err = json.NewDecoder(r.Body).Decode(memberReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	err = h.organizationService.AddMember(r.Context(), orgID, *memberReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, "Member berhasil ditambahkan ke organisasi")
+}
+
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	orgID, userID, ok := parseOrganizationMemberPath(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.organizationService.RemoveMember(r.Context(), orgID, userID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Member berhasil dikeluarkan dari organisasi")
+}
+
+func (h *OrganizationHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	orgID, userID, ok := parseOrganizationMemberPath(w, r)
+	if !ok {
+		return
+	}
+
+	assignRoleReq := &domain.AssignRoleRequest{}
+	err := json.NewDecoder(r.Body).Decode(assignRoleReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	actorID, _ := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	err = h.organizationService.AssignRoles(r.Context(), orgID, userID, actorID, *assignRoleReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, "Role organisasi pada user berhasil diubah")
+}
+
+func (h *OrganizationHandler) AssignPermission(w http.ResponseWriter, r *http.Request) {
+	orgID, userID, ok := parseOrganizationMemberPath(w, r)
+	if !ok {
+		return
+	}
+
+	assignPermReq := &domain.AssignPermissionRequest{}
+	err := json.NewDecoder(r.Body).Decode(assignPermReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	actorID, _ := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	err = h.organizationService.AssignPermissions(r.Context(), orgID, userID, actorID, *assignPermReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, "Permission organisasi pada user berhasil diubah")
+}
+
+// ambil {org_id} dan {user_id} dari path, kalau gagal response error langsung ditulis
+func parseOrganizationMemberPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	orgID, err := uuid.Parse(r.PathValue("org_id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Organisasi tidak valid")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID User tidak valid")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return orgID, userID, true
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/json"
 	"golang-auth/internal/domain"
 	"golang-auth/internal/helper"
 	"net/http"
@@ -19,7 +20,42 @@ func NewPermissionHandler(permissionService domain.PermissionService) *Permissio
 }
 
 func (h *PermissionHandler) FindAll(w http.ResponseWriter, r *http.Request) {
-	data, err := h.permissionService.FindAll(r.Context())
+	// ?grouped=true untuk admin UI, dikelompokkan per resource
+	if r.URL.Query().Get("grouped") == "true" {
+		data, err := h.permissionService.FindAllGrouped(r.Context())
+		if err != nil {
+			helper.ResponseBadRequest(w, helper.TranslateError(err))
+			return
+		}
+
+		helper.ResponseOK(w, data)
+		return
+	}
+
+	// selain grouped, list dipaginasi: ?limit=&cursor=&sort=name&name~=roles&group=roles
+	spec, err := helper.ParseQuerySpec(r.URL.Query(), domain.PermissionQueryFields)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
+
+	data, meta, err := h.permissionService.FindPage(r.Context(), spec)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponsePage(w, data, meta)
+}
+
+func (h *PermissionHandler) FindByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Permission tidak valid")
+		return
+	}
+
+	data, err := h.permissionService.FindByID(r.Context(), id)
 	if err != nil {
 		helper.ResponseBadRequest(w, helper.TranslateError(err))
 		return
@@ -28,6 +64,64 @@ func (h *PermissionHandler) FindAll(w http.ResponseWriter, r *http.Request) {
 	helper.ResponseOK(w, data)
 }
 
+func (h *PermissionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	createReq := &domain.PermissionCreateRequest{}
+	err := json.NewDecoder(r.Body).Decode(createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	data, err := h.permissionService.Create(r.Context(), *createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, data)
+}
+
+func (h *PermissionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Permission tidak valid")
+		return
+	}
+
+	updateReq := &domain.PermissionUpdateRequest{}
+	err = json.NewDecoder(r.Body).Decode(updateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	updateReq.ID = id
+
+	err = h.permissionService.Update(r.Context(), *updateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Permission berhasil diperbarui")
+}
+
+func (h *PermissionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Permission tidak valid")
+		return
+	}
+
+	err = h.permissionService.Delete(r.Context(), id)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Data berhasil terhapus")
+}
+
 func (h *PermissionHandler) FindByUserID(w http.ResponseWriter, r *http.Request) {
 	userIDStr := r.PathValue("id")
 	userID, err := uuid.Parse(userIDStr)
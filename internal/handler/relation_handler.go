@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"encoding/json"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"golang-auth/internal/middleware"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type RelationHandler struct {
+	relationService domain.RelationService
+}
+
+func NewRelationHandler(relationService domain.RelationService) *RelationHandler {
+	return &RelationHandler{
+		relationService: relationService,
+	}
+}
+
+func (h *RelationHandler) Schema(w http.ResponseWriter, r *http.Request) {
+	helper.ResponseOK(w, h.relationService.Schema())
+}
+
+// list tuple, semua query param opsional: ?namespace=&object_id=&relation=&subject=
+func (h *RelationHandler) ReadTuples(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.RelationTupleFilter{
+		Namespace: query.Get("namespace"),
+		ObjectID:  query.Get("object_id"),
+		Relation:  query.Get("relation"),
+	}
+
+	if subjectStr := query.Get("subject"); subjectStr != "" {
+		subject, err := domain.ParseSubjectSet(subjectStr)
+		if err != nil {
+			helper.ResponseBadRequest(w, helper.TranslateError(err))
+			return
+		}
+		filter.SubjectNamespace = subject.Namespace
+		filter.SubjectID = subject.ID
+		filter.SubjectRelation = &subject.Relation
+	}
+
+	data, err := h.relationService.ReadTuples(r.Context(), filter)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *RelationHandler) WriteTuple(w http.ResponseWriter, r *http.Request) {
+	tupleReq := &domain.RelationTupleRequest{}
+	err := json.NewDecoder(r.Body).Decode(tupleReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	err = h.relationService.WriteTuple(r.Context(), *tupleReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, "Relasi berhasil ditambahkan")
+}
+
+func (h *RelationHandler) DeleteTuple(w http.ResponseWriter, r *http.Request) {
+	tupleReq := &domain.RelationTupleRequest{}
+	err := json.NewDecoder(r.Body).Decode(tupleReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	err = h.relationService.DeleteTuple(r.Context(), *tupleReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Relasi berhasil dihapus")
+}
+
+func (h *RelationHandler) Check(w http.ResponseWriter, r *http.Request) {
+	checkReq := &domain.RelationCheckRequest{}
+	err := json.NewDecoder(r.Body).Decode(checkReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	allowed, err := h.relationService.Check(r.Context(), *checkReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, map[string]bool{"allowed": allowed})
+}
+
+func (h *RelationHandler) Expand(w http.ResponseWriter, r *http.Request) {
+	expandReq := &domain.RelationExpandRequest{}
+	err := json.NewDecoder(r.Body).Decode(expandReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	data, err := h.relationService.Expand(r.Context(), *expandReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *RelationHandler) ListObjects(w http.ResponseWriter, r *http.Request) {
+	listReq := &domain.RelationListObjectsRequest{}
+	err := json.NewDecoder(r.Body).Decode(listReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	data, err := h.relationService.ListObjects(r.Context(), *listReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+// object yang bisa diakses user yang login, ?namespace=document&relation=viewer
+func (h *RelationHandler) MyObjects(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Gagal mengambil identitas user")
+		return
+	}
+
+	data, err := h.relationService.ListObjects(r.Context(), domain.RelationListObjectsRequest{
+		Namespace: r.URL.Query().Get("namespace"),
+		Relation:  r.URL.Query().Get("relation"),
+		Subject:   "user:" + userID.String(),
+	})
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
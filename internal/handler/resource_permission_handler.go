@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"golang-auth/internal/middleware"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type ResourcePermissionHandler struct {
+	resourcePermissionService domain.ResourcePermissionService
+}
+
+func NewResourcePermissionHandler(resourcePermissionService domain.ResourcePermissionService) *ResourcePermissionHandler {
+	return &ResourcePermissionHandler{
+		resourcePermissionService: resourcePermissionService,
+	}
+}
+
+// list grant pada satu resource, ?resource_type=document&resource_id=42
+func (h *ResourcePermissionHandler) FindByResource(w http.ResponseWriter, r *http.Request) {
+	resourceType := r.URL.Query().Get("resource_type")
+	resourceID := r.URL.Query().Get("resource_id")
+	if resourceType == "" || resourceID == "" {
+		helper.ResponseBadRequest(w, "resource_type dan resource_id wajib diisi")
+		return
+	}
+
+	data, err := h.resourcePermissionService.FindByResource(r.Context(), resourceType, resourceID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *ResourcePermissionHandler) Grant(w http.ResponseWriter, r *http.Request) {
+	grantReq := &domain.ResourcePermissionRequest{}
+	err := json.NewDecoder(r.Body).Decode(grantReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	err = h.resourcePermissionService.Grant(r.Context(), *grantReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, "Permission resource berhasil diberikan")
+}
+
+func (h *ResourcePermissionHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	revokeReq := &domain.ResourcePermissionRequest{}
+	err := json.NewDecoder(r.Body).Decode(revokeReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	err = h.resourcePermissionService.Revoke(r.Context(), *revokeReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Permission resource berhasil dicabut")
+}
+
+// daftar id resource yang boleh diakses user yang login, ?permission=documents:edit
+func (h *ResourcePermissionHandler) Mine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Gagal mengambil identitas user")
+		return
+	}
+
+	permission := r.URL.Query().Get("permission")
+	if permission == "" {
+		helper.ResponseBadRequest(w, "permission wajib diisi")
+		return
+	}
+
+	ids, all, err := h.resourcePermissionService.ListResourceIDs(r.Context(), userID, permission, r.PathValue("resource_type"))
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, map[string]any{
+		"all":          all,
+		"resource_ids": ids,
+	})
+}
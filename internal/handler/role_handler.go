@@ -19,14 +19,21 @@ func NewRoleHandler(roleService domain.RoleService) *RoleHandler {
 	}
 }
 
+// ?limit=&cursor=&sort=name&name~=admin&guard=api
 func (h *RoleHandler) FindAll(w http.ResponseWriter, r *http.Request) {
-	data, err := h.roleService.FindAll(r.Context())
+	spec, err := helper.ParseQuerySpec(r.URL.Query(), domain.RoleQueryFields)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
+
+	data, meta, err := h.roleService.FindPage(r.Context(), spec)
 	if err != nil {
 		helper.ResponseBadRequest(w, helper.TranslateError(err))
 		return
 	}
 
-	helper.ResponseOK(w, data)
+	helper.ResponsePage(w, data, meta)
 }
 
 func (h *RoleHandler) FindByID(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +49,8 @@ func (h *RoleHandler) FindByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// versi role, dikirim balik lewat If-Match saat update
+	helper.SetETag(w, data.Version)
 	helper.ResponseOK(w, data)
 
 }
@@ -82,10 +91,15 @@ func (h *RoleHandler) Update(w http.ResponseWriter, r *http.Request){
 	}
 
 	updateRoleReq.ID = roleID
+	updateRoleReq.Version, err = helper.IfMatchVersion(r)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
 
 	err = h.roleService.Update(r.Context(), *updateRoleReq)
 	if err != nil {
-		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		helper.ResponseUpdateError(w, err)
 		return
 	}
 
@@ -108,4 +122,125 @@ func (h *RoleHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	helper.ResponseOK(w, "Data berhasil terhapus")
-}
\ No newline at end of file
+}
+// tambah sebagian permission ke role, mengembalikan role setelah perubahan beserta ETag barunya
+func (h *RoleHandler) AttachPermissions(w http.ResponseWriter, r *http.Request) {
+	roleID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Role tidak valid")
+		return
+	}
+
+	attachReq := &domain.RoleAttachPermissionRequest{}
+	err = json.NewDecoder(r.Body).Decode(attachReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	attachReq.ID = roleID
+	attachReq.Version, err = helper.IfMatchVersion(r)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
+
+	data, err := h.roleService.AttachPermissions(r.Context(), *attachReq)
+	if err != nil {
+		helper.ResponseUpdateError(w, err)
+		return
+	}
+
+	helper.SetETag(w, data.Version)
+	helper.ResponseOK(w, data)
+}
+
+func (h *RoleHandler) DetachPermissions(w http.ResponseWriter, r *http.Request) {
+	roleID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Role tidak valid")
+		return
+	}
+
+	detachReq := &domain.RoleDetachPermissionRequest{}
+	err = json.NewDecoder(r.Body).Decode(detachReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	detachReq.ID = roleID
+	detachReq.Version, err = helper.IfMatchVersion(r)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
+
+	data, err := h.roleService.DetachPermissions(r.Context(), *detachReq)
+	if err != nil {
+		helper.ResponseUpdateError(w, err)
+		return
+	}
+
+	helper.SetETag(w, data.Version)
+	helper.ResponseOK(w, data)
+}
+
+func (h *RoleHandler) Clone(w http.ResponseWriter, r *http.Request) {
+	roleID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Role tidak valid")
+		return
+	}
+
+	cloneReq := &domain.RoleCloneRequest{}
+	err = json.NewDecoder(r.Body).Decode(cloneReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	cloneReq.ID = roleID
+
+	data, err := h.roleService.Clone(r.Context(), *cloneReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, data)
+}
+
+func (h *RoleHandler) Templates(w http.ResponseWriter, r *http.Request) {
+	helper.ResponseOK(w, h.roleService.Templates())
+}
+
+func (h *RoleHandler) CreateFromTemplate(w http.ResponseWriter, r *http.Request) {
+	templateReq := &domain.RoleFromTemplateRequest{}
+	err := json.NewDecoder(r.Body).Decode(templateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	templateReq.TemplateKey = r.PathValue("key")
+
+	data, err := h.roleService.CreateFromTemplate(r.Context(), *templateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, data)
+}
+
+// terapkan ulang isi template ke semua role turunannya, mengembalikan role yang diperbarui
+func (h *RoleHandler) ReapplyTemplate(w http.ResponseWriter, r *http.Request) {
+	data, err := h.roleService.ReapplyTemplate(r.Context(), r.PathValue("key"))
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
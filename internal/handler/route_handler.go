@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"net/http"
+)
+
+type RouteHandler struct {
+	routeCatalog      domain.RouteCatalog
+	permissionService domain.PermissionService
+}
+
+func NewRouteHandler(routeCatalog domain.RouteCatalog, permissionService domain.PermissionService) *RouteHandler {
+	return &RouteHandler{
+		routeCatalog:      routeCatalog,
+		permissionService: permissionService,
+	}
+}
+
+// daftar endpoint beserta permission yang memproteksinya, plus permission yang tidak dipakai route
+func (h *RouteHandler) FindAll(w http.ResponseWriter, r *http.Request) {
+	orphans, err := h.permissionService.FindOrphanPermissions(r.Context())
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, map[string]any{
+		"routes":             h.routeCatalog.Routes(),
+		"orphan_permissions": orphans,
+	})
+}
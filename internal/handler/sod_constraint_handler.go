@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type SoDConstraintHandler struct {
+	sodConstraintService domain.SoDConstraintService
+}
+
+func NewSoDConstraintHandler(sodConstraintService domain.SoDConstraintService) *SoDConstraintHandler {
+	return &SoDConstraintHandler{
+		sodConstraintService: sodConstraintService,
+	}
+}
+
+func (h *SoDConstraintHandler) FindAll(w http.ResponseWriter, r *http.Request) {
+	data, err := h.sodConstraintService.FindAll(r.Context())
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *SoDConstraintHandler) Create(w http.ResponseWriter, r *http.Request) {
+	createReq := &domain.SoDConstraintCreateRequest{}
+	err := json.NewDecoder(r.Body).Decode(createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	data, err := h.sodConstraintService.Create(r.Context(), *createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, data)
+}
+
+func (h *SoDConstraintHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Constraint tidak valid")
+		return
+	}
+
+	err = h.sodConstraintService.Delete(r.Context(), id)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Data berhasil terhapus")
+}
+
+// laporan user yang saat ini melanggar constraint
+func (h *SoDConstraintHandler) Violations(w http.ResponseWriter, r *http.Request) {
+	data, err := h.sodConstraintService.FindViolations(r.Context())
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
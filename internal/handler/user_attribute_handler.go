@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"golang-auth/internal/middleware"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type UserAttributeHandler struct {
+	userAttributeService domain.UserAttributeService
+}
+
+func NewUserAttributeHandler(userAttributeService domain.UserAttributeService) *UserAttributeHandler {
+	return &UserAttributeHandler{
+		userAttributeService: userAttributeService,
+	}
+}
+
+// daftar definisi atribut, dipakai frontend untuk membangun form profil
+func (h *UserAttributeHandler) FindDefinitions(w http.ResponseWriter, r *http.Request) {
+	data, err := h.userAttributeService.FindDefinitions(r.Context())
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *UserAttributeHandler) CreateDefinition(w http.ResponseWriter, r *http.Request) {
+	createReq := &domain.UserAttributeDefinitionCreateRequest{}
+	err := json.NewDecoder(r.Body).Decode(createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	data, err := h.userAttributeService.CreateDefinition(r.Context(), *createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, data)
+}
+
+func (h *UserAttributeHandler) UpdateDefinition(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Atribut tidak valid")
+		return
+	}
+
+	updateReq := &domain.UserAttributeDefinitionUpdateRequest{}
+	err = json.NewDecoder(r.Body).Decode(updateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+	updateReq.ID = id
+
+	data, err := h.userAttributeService.UpdateDefinition(r.Context(), *updateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+func (h *UserAttributeHandler) DeleteDefinition(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID Atribut tidak valid")
+		return
+	}
+
+	err = h.userAttributeService.DeleteDefinition(r.Context(), id)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Atribut berhasil dihapus")
+}
+
+// user mengisi atributnya sendiri, hanya atribut user_editable
+func (h *UserAttributeHandler) UpdateMine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Gagal mengambil identitas user")
+		return
+	}
+
+	h.update(w, r, userID, true)
+}
+
+// admin mengisi atribut user lain, termasuk yang dipakai kondisi otorisasi
+func (h *UserAttributeHandler) UpdateForUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID User tidak valid")
+		return
+	}
+
+	h.update(w, r, userID, false)
+}
+
+func (h *UserAttributeHandler) update(w http.ResponseWriter, r *http.Request, userID uuid.UUID, selfService bool) {
+	updateReq := &domain.UserAttributesUpdateRequest{}
+	err := json.NewDecoder(r.Body).Decode(updateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+	updateReq.UserID = userID
+	updateReq.SelfService = selfService
+
+	data, err := h.userAttributeService.SetUserAttributes(r.Context(), *updateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
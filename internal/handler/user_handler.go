@@ -2,10 +2,16 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"golang-auth/internal/domain"
 	"golang-auth/internal/helper"
 	"golang-auth/internal/middleware"
+	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -33,6 +39,8 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request){
 		Username: registerReq.Username,
 		Email: registerReq.Email,
 		Password: registerReq.Password,
+		Attributes: registerReq.Attributes,
+		SelfService: true,
 	}
 
 	// service
@@ -63,10 +71,255 @@ func (h *UserHandler) Profile(w http.ResponseWriter, r *http.Request){
 
 }
 
+// update profil user yang sedang login, email baru menunggu verifikasi
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Gagal mengambil identitas user")
+		return
+	}
+
+	updateReq := &domain.UserProfileUpdateRequest{}
+	err := json.NewDecoder(r.Body).Decode(updateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	updateReq.ID = userID
+
+	data, err := h.userService.UpdateProfile(r.Context(), *updateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+// daftar user untuk admin, ?limit=&cursor=&sort=-created_at&username~=budi
+func (h *UserHandler) FindAll(w http.ResponseWriter, r *http.Request) {
+	spec, err := helper.ParseQuerySpec(r.URL.Query(), domain.UserQueryFields)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
+
+	data, meta, err := h.userService.FindPage(r.Context(), spec)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponsePage(w, data, meta)
+}
+
+// pencarian user untuk support: ?q=budi&role=editor&permission=roles:view&created_after=2026-01-01&limit=&cursor=
+// saat ada organisasi aktif, hasil dibatasi ke member organisasi tersebut
+func (h *UserHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	spec, err := helper.ParseQuerySpec(query, nil)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
+
+	search := domain.UserSearchQuery{
+		Query: query.Get("q"),
+		Role: query.Get("role"),
+		Permission: query.Get("permission"),
+	}
+
+	if rawCreatedAfter := query.Get("created_after"); rawCreatedAfter != "" {
+		createdAfter, err := time.Parse(time.RFC3339, rawCreatedAfter)
+		if err != nil {
+			createdAfter, err = time.Parse(time.DateOnly, rawCreatedAfter)
+		}
+		if err != nil {
+			helper.ResponseBadRequest(w, "Format created_after tidak valid, gunakan YYYY-MM-DD atau RFC3339")
+			return
+		}
+		search.CreatedAfter = &createdAfter
+	}
+
+	if orgID, ok := middleware.OrganizationFromRequest(r); ok {
+		search.OrganizationID = &orgID
+	}
+
+	data, meta, err := h.userService.Search(r.Context(), search, spec)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponsePage(w, data, meta)
+}
+
+func (h *UserHandler) FindByID(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID User tidak valid")
+		return
+	}
+
+	data, err := h.userService.FindByID(r.Context(), userID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	// versi user, dikirim balik lewat If-Match saat update
+	helper.SetETag(w, data.Version)
+	helper.ResponseOK(w, data)
+}
+
+// admin membuat user, role & permission bisa langsung diberikan
+func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
+	createReq := &domain.UserCreateRequest{}
+	err := json.NewDecoder(r.Body).Decode(createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	if actorID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID); ok {
+		createReq.ActorID = &actorID
+	}
+
+	err = h.userService.Create(r.Context(), *createReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseCreated(w, "User berhasil ditambahkan")
+}
+
 // update
 func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
-	
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID User tidak valid")
+		return
+	}
+
+	updateReq := &domain.UserUpdateRequest{}
+	err = json.NewDecoder(r.Body).Decode(updateReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	updateReq.ID = userID
+	updateReq.Version, err = helper.IfMatchVersion(r)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
+
+	err = h.userService.Update(r.Context(), *updateReq)
+	if err != nil {
+		helper.ResponseUpdateError(w, err)
+		return
+	}
 
+	helper.ResponseOK(w, "User berhasil diperbarui")
+}
+
+// soft delete, body {"reason": "..."} opsional
+func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	statusReq, ok := decodeUserStatus(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.userService.Delete(r.Context(), *statusReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Data berhasil terhapus")
+}
+
+// suspend user, semua tokennya langsung dicabut
+func (h *UserHandler) Suspend(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID User tidak valid")
+		return
+	}
+
+	suspendReq := &domain.UserSuspendRequest{}
+	err = json.NewDecoder(r.Body).Decode(suspendReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	suspendReq.ID = userID
+	suspendReq.ActorID, _ = r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+
+	err = h.userService.Suspend(r.Context(), *suspendReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "User berhasil di-suspend")
+}
+
+func (h *UserHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	statusReq, ok := decodeUserStatus(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.userService.Deactivate(r.Context(), *statusReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "User berhasil dinonaktifkan")
+}
+
+// aktifkan lagi user yang di-suspend, dinonaktifkan atau dihapus
+func (h *UserHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	statusReq, ok := decodeUserStatus(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.userService.Restore(r.Context(), *statusReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "User berhasil dipulihkan")
+}
+
+// decodeUserStatus membaca id dari path dan body opsional {"reason": "..."}
+func decodeUserStatus(w http.ResponseWriter, r *http.Request) (*domain.UserStatusRequest, bool) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID User tidak valid")
+		return nil, false
+	}
+
+	statusReq := &domain.UserStatusRequest{}
+	err = json.NewDecoder(r.Body).Decode(statusReq)
+	if err != nil && err != io.EOF {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return nil, false
+	}
+
+	statusReq.ID = userID
+	statusReq.ActorID, _ = r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+
+	return statusReq, true
 }
 
 // assign role
@@ -86,9 +339,15 @@ func (h *UserHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	assignRoleReq.Version, err = helper.IfMatchVersion(r)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
+
 	err = h.userService.AssignRoles(r.Context(), userID, *assignRoleReq)
 	if err != nil {
-		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		helper.ResponseUpdateError(w, err)
 		return
 	}
 
@@ -111,11 +370,220 @@ func (h *UserHandler) AssignPermission(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	assignPermReq.Version, err = helper.IfMatchVersion(r)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
+
 	err = h.userService.AssignPermissions(r.Context(), userID, *assignPermReq)
 	if err != nil {
-		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		helper.ResponseUpdateError(w, err)
 		return
 	}
 
 	helper.ResponseCreated(w, "Permission pada user berhasil diubah")
-}
\ No newline at end of file
+}
+// attach / detach sebagian role & permission, mengembalikan user setelah perubahan beserta ETag barunya
+func (h *UserHandler) AttachRoles(w http.ResponseWriter, r *http.Request) {
+	attachReq := &domain.UserAttachRoleRequest{}
+	if !decodeUserPatch(w, r, attachReq, &attachReq.ID, &attachReq.Version) {
+		return
+	}
+
+	data, err := h.userService.AttachRoles(r.Context(), *attachReq)
+	respondUserPatch(w, data, err)
+}
+
+func (h *UserHandler) DetachRoles(w http.ResponseWriter, r *http.Request) {
+	detachReq := &domain.UserDetachRoleRequest{}
+	if !decodeUserPatch(w, r, detachReq, &detachReq.ID, &detachReq.Version) {
+		return
+	}
+
+	data, err := h.userService.DetachRoles(r.Context(), *detachReq)
+	respondUserPatch(w, data, err)
+}
+
+func (h *UserHandler) AttachPermissions(w http.ResponseWriter, r *http.Request) {
+	attachReq := &domain.UserAttachPermissionRequest{}
+	if !decodeUserPatch(w, r, attachReq, &attachReq.ID, &attachReq.Version) {
+		return
+	}
+
+	data, err := h.userService.AttachPermissions(r.Context(), *attachReq)
+	respondUserPatch(w, data, err)
+}
+
+func (h *UserHandler) DetachPermissions(w http.ResponseWriter, r *http.Request) {
+	detachReq := &domain.UserDetachPermissionRequest{}
+	if !decodeUserPatch(w, r, detachReq, &detachReq.ID, &detachReq.Version) {
+		return
+	}
+
+	data, err := h.userService.DetachPermissions(r.Context(), *detachReq)
+	respondUserPatch(w, data, err)
+}
+
+// decodeUserPatch mengisi body, id user dari path & versi dari If-Match, false kalau response error sudah ditulis
+func decodeUserPatch(w http.ResponseWriter, r *http.Request, body any, id *uuid.UUID, version *int) bool {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID User tidak valid")
+		return false
+	}
+
+	err = json.NewDecoder(r.Body).Decode(body)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return false
+	}
+
+	*id = userID
+	*version, err = helper.IfMatchVersion(r)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return false
+	}
+	return true
+}
+
+func respondUserPatch(w http.ResponseWriter, data *domain.User, err error) {
+	if err != nil {
+		helper.ResponseUpdateError(w, err)
+		return
+	}
+
+	helper.SetETag(w, data.Version)
+	helper.ResponseOK(w, data)
+}
+
+// batas ukuran body import
+const maxImportBodyBytes = 10 << 20
+
+// import user massal dari CSV / JSONL: ?format=csv|jsonl&dry_run=true&batch_size=100&guard=api.
+// format bisa juga dari Content-Type (text/csv, application/x-ndjson). response berisi laporan per baris
+func (h *UserHandler) Import(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := transferFormat(query.Get("format"), r.Header.Get("Content-Type"))
+	if format == "" {
+		helper.ResponseBadRequest(w, "Format import tidak didukung, gunakan csv atau jsonl")
+		return
+	}
+
+	opts := domain.UserImportOptions{
+		DryRun: query.Get("dry_run") == "true" || query.Get("dry_run") == "1",
+		Guard: query.Get("guard"),
+	}
+	if rawBatchSize := query.Get("batch_size"); rawBatchSize != "" {
+		batchSize, err := strconv.Atoi(rawBatchSize)
+		if err != nil || batchSize < 1 {
+			helper.ResponseBadRequest(w, "batch_size harus berupa angka positif")
+			return
+		}
+		opts.BatchSize = batchSize
+	}
+	if actorID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID); ok {
+		opts.ActorID = &actorID
+	}
+
+	rows, err := helper.DecodeUserImport(http.MaxBytesReader(w, r.Body, maxImportBodyBytes), format)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
+
+	data, err := h.userService.Import(r.Context(), rows, opts)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+	for i := range data.Errors {
+		data.Errors[i].Message = helper.TranslateError(data.Errors[i].Err)
+	}
+
+	helper.ResponseOK(w, data)
+}
+
+// export semua user (kecuali yang dihapus) beserta role-nya, ditulis bertahap ke response: ?format=csv|jsonl&guard=api
+func (h *UserHandler) Export(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := domain.UserTransferCSV
+	if rawFormat := query.Get("format"); rawFormat != "" {
+		format = transferFormat(rawFormat, "")
+		if format == "" {
+			helper.ResponseBadRequest(w, "Format export tidak didukung, gunakan csv atau jsonl")
+			return
+		}
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == domain.UserTransferJSONL {
+		contentType = "application/x-ndjson"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="users-%s.%s"`, time.Now().Format("20060102"), format))
+
+	writer, err := helper.NewUserExportWriter(w, format)
+	if err != nil {
+		helper.ResponseBadRequest(w, err.Error())
+		return
+	}
+	controller := http.NewResponseController(w)
+
+	// header HTTP baru terkirim saat flush pertama, jadi error sebelum itu masih bisa dijawab 400
+	count := 0
+	flushed := false
+	err = h.userService.Export(r.Context(), query.Get("guard"), func(row domain.UserExportRow) error {
+		err := writer.Write(row)
+		if err != nil {
+			return err
+		}
+		count++
+		if count%100 == 0 {
+			flushed = true
+			err = writer.Flush()
+			if err != nil {
+				return err
+			}
+			return controller.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !flushed {
+			w.Header().Del("Content-Disposition")
+			helper.ResponseBadRequest(w, helper.TranslateError(err))
+			return
+		}
+		// sebagian file sudah terkirim, client menerima file terpotong
+		slog.Error("Export user terhenti di tengah jalan", "rows", count, "error", err)
+		return
+	}
+
+	writer.Flush()
+}
+
+// transferFormat menentukan format file dari parameter format, lalu dari Content-Type
+func transferFormat(format string, contentType string) string {
+	switch strings.ToLower(format) {
+	case domain.UserTransferCSV:
+		return domain.UserTransferCSV
+	case domain.UserTransferJSONL, "ndjson":
+		return domain.UserTransferJSONL
+	case "":
+	default:
+		return ""
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "text/csv":
+		return domain.UserTransferCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return domain.UserTransferJSONL
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"golang-auth/internal/middleware"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type UserPrivacyHandler struct {
+	userPrivacyService domain.UserPrivacyService
+}
+
+func NewUserPrivacyHandler(userPrivacyService domain.UserPrivacyService) *UserPrivacyHandler {
+	return &UserPrivacyHandler{
+		userPrivacyService: userPrivacyService,
+	}
+}
+
+// download archive data milik user yang sedang login
+func (h *UserPrivacyHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Sesi tidak valid atau tidak ditemukan")
+		return
+	}
+
+	h.export(w, r, userID, userID)
+}
+
+// download archive data user lain, untuk menjawab permintaan yang masuk lewat admin
+func (h *UserPrivacyHandler) ExportUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID User tidak valid")
+		return
+	}
+
+	actorID, _ := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	h.export(w, r, userID, actorID)
+}
+
+func (h *UserPrivacyHandler) export(w http.ResponseWriter, r *http.Request, userID uuid.UUID, actorID uuid.UUID) {
+	data, err := h.userPrivacyService.Export(r.Context(), userID, actorID)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	// dikirim sebagai file, bukan dibungkus format response biasa
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-data-%s-%s.json"`, userID, data.GeneratedAt.Format("20060102")))
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(data)
+}
+
+// hapus data pribadi akun sendiri, wajib konfirmasi password
+func (h *UserPrivacyHandler) Erase(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	if !ok {
+		helper.ResponseUnauthorized(w, "Sesi tidak valid atau tidak ditemukan")
+		return
+	}
+
+	eraseReq := &domain.UserEraseRequest{}
+	err := json.NewDecoder(r.Body).Decode(eraseReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	eraseReq.ID, eraseReq.ActorID = userID, userID
+	h.erase(w, r, eraseReq)
+}
+
+// hapus data pribadi user lain oleh admin, body (reason) opsional
+func (h *UserPrivacyHandler) EraseUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helper.ResponseBadRequest(w, "Format ID User tidak valid")
+		return
+	}
+
+	eraseReq := &domain.UserEraseRequest{}
+	err = json.NewDecoder(r.Body).Decode(eraseReq)
+	if err != nil && err != io.EOF {
+		helper.ResponseBadRequest(w, "Format JSON tidak valid")
+		return
+	}
+
+	eraseReq.ID = userID
+	eraseReq.ActorID, _ = r.Context().Value(middleware.UserContextKey).(uuid.UUID)
+	h.erase(w, r, eraseReq)
+}
+
+func (h *UserPrivacyHandler) erase(w http.ResponseWriter, r *http.Request, eraseReq *domain.UserEraseRequest) {
+	err := h.userPrivacyService.Erase(r.Context(), *eraseReq)
+	if err != nil {
+		helper.ResponseBadRequest(w, helper.TranslateError(err))
+		return
+	}
+
+	helper.ResponseOK(w, "Data pribadi user berhasil dihapus")
+}
@@ -17,6 +17,8 @@ var globalConstraintMessages = map[string]string{
 	"username": "Username sudah digunakan oleh orang lain",
 	"phone":    "Nomor telepon sudah terdaftar",
 	"code":     "Kode ini sudah ada di sistem",
+	"attr_definitions": "Key atribut sudah digunakan",
+	"attr_values":      "Nilai atribut tersebut sudah dipakai user lain",
 }
 
 // TranslateError adalah pintu utama untuk memproses semua jenis error
@@ -95,8 +97,18 @@ func FormatValidationError(ve validator.ValidationErrors) map[string]string {
 			result[field] = fmt.Sprintf("Minimal harus %s karakter", param)
 		case "max":
 			result[field] = fmt.Sprintf("Maksimal %s karakter saja", param)
+		case "len":
+			result[field] = fmt.Sprintf("Harus tepat %s karakter", param)
 		case "uuid":
 			result[field] = "Format ID tidak valid"
+		case "oneof":
+			result[field] = fmt.Sprintf("Nilai harus salah satu dari: %s", param)
+		case "nefield":
+			result[field] = fmt.Sprintf("Tidak boleh sama dengan %s", param)
+		case "username":
+			result[field] = "Hanya boleh huruf, angka, titik, underscore dan strip, tanpa mencampur huruf Latin, Cyrillic dan Yunani"
+		case "required_with":
+			result[field] = fmt.Sprintf("Wajib diisi jika %s diisi", param)
 		default:
 			// Jika ada tag lain yang belum terdaftar tapi punya param
 			if param != "" {
@@ -0,0 +1,61 @@
+package helper
+
+import (
+	"fmt"
+	"golang-auth/internal/domain"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ParseQuerySpec membaca ?cursor=&limit=&sort=-name&name~=adm&guard=api.
+// hanya field di fields yang dipakai sebagai filter / sort, query lain (misal grouped) diabaikan
+func ParseQuerySpec(query url.Values, fields []string) (domain.QuerySpec, error) {
+	spec := domain.QuerySpec{
+		Cursor: query.Get("cursor"),
+		Limit:  domain.DefaultPageLimit,
+	}
+
+	allowed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		allowed[field] = true
+	}
+
+	if rawLimit := query.Get("limit"); rawLimit != "" {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 || limit > domain.MaxPageLimit {
+			return spec, fmt.Errorf("limit harus antara 1 dan %d", domain.MaxPageLimit)
+		}
+		spec.Limit = limit
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		spec.Desc = strings.HasPrefix(sort, "-")
+		spec.Sort = strings.TrimPrefix(sort, "-")
+		if spec.Sort != "id" && !allowed[spec.Sort] {
+			return spec, fmt.Errorf("sort tidak didukung: %s", spec.Sort)
+		}
+		if spec.Sort == "id" {
+			spec.Sort = ""
+		}
+	}
+
+	for key, values := range query {
+		field, op := key, domain.FilterEqual
+		// name~=adm diterima sebagai key "name~", name!=adm sebagai "name!"
+		if strings.HasSuffix(key, "~") {
+			field, op = strings.TrimSuffix(key, "~"), domain.FilterContains
+		} else if strings.HasSuffix(key, "!") {
+			field, op = strings.TrimSuffix(key, "!"), domain.FilterNotEqual
+		}
+		if !allowed[field] {
+			continue
+		}
+
+		for _, value := range values {
+			spec.Filters = append(spec.Filters, domain.QueryFilter{Field: field, Op: op, Value: value})
+		}
+	}
+
+	return spec, nil
+}
@@ -0,0 +1,40 @@
+package helper
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ClientIP mengambil IP client dari RemoteAddr (tanpa port).
+// header X-Forwarded-For sengaja tidak dipercaya karena bisa dipalsukan client
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// IfMatchVersion membaca versi dari header If-Match ("3" atau W/"3").
+// header kosong atau "*" menghasilkan 0, artinya update tanpa pengecekan versi
+func IfMatchVersion(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, errors.New("Header If-Match tidak valid")
+	}
+	return version, nil
+}
+
+// SetETag menulis versi data sebagai ETag, dikirim balik client lewat If-Match saat update
+func SetETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", `"`+strconv.Itoa(version)+`"`)
+}
@@ -2,6 +2,7 @@ package helper
 
 import (
 	"encoding/json"
+	"errors"
 	"golang-auth/internal/domain"
 	"net/http"
 )
@@ -24,6 +25,19 @@ func ResponseOK(w http.ResponseWriter, data any) {
     WriteJSON(w, http.StatusOK, "OK", data)
 }
 
+// Helper list endpoint (200 OK) dengan metadata halaman
+func ResponsePage(w http.ResponseWriter, data any, meta *domain.PageMeta) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_ = json.NewEncoder(w).Encode(domain.Response{
+		Code: http.StatusOK,
+		Status: "OK",
+		Data: data,
+		Meta: meta,
+	})
+}
+
 // Helper Khusus Created (201 Created)
 func ResponseCreated(w http.ResponseWriter, data any) {
     WriteJSON(w, http.StatusCreated, "Created", data)
@@ -44,6 +58,20 @@ func ResponseForbidden(w http.ResponseWriter, data any) {
     WriteJSON(w, http.StatusForbidden, "Forbidden", data)
 }
 
+// Helper Khusus Versi Tidak Cocok (412 Precondition Failed), misal If-Match sudah usang
+func ResponsePreconditionFailed(w http.ResponseWriter, data any) {
+    WriteJSON(w, http.StatusPreconditionFailed, "Precondition Failed", data)
+}
+
+// Helper untuk error dari update yang memakai If-Match: konflik versi jadi 412, selain itu 400
+func ResponseUpdateError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrVersionConflict) {
+		ResponsePreconditionFailed(w, err.Error())
+		return
+	}
+	ResponseBadRequest(w, TranslateError(err))
+}
+
 // Helper Khusus Server Error (500 Internal Server Error)
 func ResponseInternalError(w http.ResponseWriter, data any) {
     WriteJSON(w, http.StatusInternalServerError, "Internal Server Error", data)
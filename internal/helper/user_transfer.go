@@ -0,0 +1,170 @@
+package helper
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-auth/internal/domain"
+	"io"
+	"strings"
+	"time"
+)
+
+// pemisah nama role dalam satu kolom CSV
+const csvRoleSeparator = "|"
+
+// DecodeUserImport membaca file import CSV / JSONL. error di sini berarti file-nya rusak,
+// validasi isi setiap baris dilakukan service supaya bisa dilaporkan per baris
+func DecodeUserImport(r io.Reader, format string) ([]domain.UserImportRow, error) {
+	switch format {
+	case domain.UserTransferCSV:
+		return decodeUserCSV(r)
+	case domain.UserTransferJSONL:
+		return decodeUserJSONL(r)
+	}
+	return nil, fmt.Errorf("format %q tidak didukung, gunakan csv atau jsonl", format)
+}
+
+func decodeUserCSV(r io.Reader) ([]domain.UserImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file import kosong")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"username", "email", "password"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("kolom %s tidak ada di header CSV", required)
+		}
+	}
+	// kolom roles boleh tidak ada, FieldsPerRecord memastikan jumlah kolom setiap baris sama
+	reader.FieldsPerRecord = len(header)
+
+	value := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []domain.UserImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(rows) >= domain.MaxImportRows {
+			return nil, fmt.Errorf("maksimal %d user per import", domain.MaxImportRows)
+		}
+
+		row := domain.UserImportRow{
+			Line:     line,
+			Username: value(record, "username"),
+			Email:    value(record, "email"),
+			Password: value(record, "password"),
+		}
+		if roles := value(record, "roles"); roles != "" {
+			for _, role := range strings.Split(roles, csvRoleSeparator) {
+				row.Roles = append(row.Roles, strings.TrimSpace(role))
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func decodeUserJSONL(r io.Reader) ([]domain.UserImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []domain.UserImportRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+		if len(rows) >= domain.MaxImportRows {
+			return nil, fmt.Errorf("maksimal %d user per import", domain.MaxImportRows)
+		}
+
+		row := domain.UserImportRow{}
+		err := json.Unmarshal([]byte(raw), &row)
+		if err != nil {
+			return nil, fmt.Errorf("baris %d bukan JSON yang valid", line)
+		}
+		row.Line = line
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("file import kosong")
+	}
+
+	return rows, nil
+}
+
+// UserExportWriter menulis user satu per satu, Flush wajib dipanggil setelah baris terakhir
+type UserExportWriter struct {
+	csv  *csv.Writer
+	json *json.Encoder
+	out  *bufio.Writer
+}
+
+func NewUserExportWriter(w io.Writer, format string) (*UserExportWriter, error) {
+	out := bufio.NewWriter(w)
+	switch format {
+	case domain.UserTransferCSV:
+		writer := &UserExportWriter{csv: csv.NewWriter(out), out: out}
+		err := writer.csv.Write([]string{"id", "username", "email", "status", "roles", "created_at"})
+		return writer, err
+	case domain.UserTransferJSONL:
+		return &UserExportWriter{json: json.NewEncoder(out), out: out}, nil
+	}
+	return nil, fmt.Errorf("format %q tidak didukung, gunakan csv atau jsonl", format)
+}
+
+func (w *UserExportWriter) Write(row domain.UserExportRow) error {
+	if w.json != nil {
+		return w.json.Encode(row)
+	}
+	return w.csv.Write([]string{
+		row.ID.String(),
+		row.Username,
+		row.Email,
+		row.Status,
+		strings.Join(row.Roles, csvRoleSeparator),
+		row.CreatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// Flush mengirim isi buffer ke writer tujuan, juga dipanggil berkala saat streaming response
+func (w *UserExportWriter) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return w.out.Flush()
+}
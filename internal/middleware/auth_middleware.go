@@ -15,11 +15,14 @@ const TokenContextKey contextKey = "token"
 
 type AuthMiddleware struct {
 	tokenService domain.PersonalAccessTokenService
+	userService domain.UserService
 }
 
-func NewAuthMiddleware(tokenService domain.PersonalAccessTokenService) *AuthMiddleware {
+// userService dipakai untuk menolak token milik user yang di-suspend / dinonaktifkan / dihapus
+func NewAuthMiddleware(tokenService domain.PersonalAccessTokenService, userService domain.UserService) *AuthMiddleware {
 	return &AuthMiddleware{
 		tokenService: tokenService,
+		userService: userService,
 	}
 }
 
@@ -41,11 +44,26 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 			return 
 		}
 
+		// token dicabut saat status berubah, tapi tetap dicek di sini untuk jaga-jaga
+		err = m.userService.CheckActive(r.Context(), tokenData.UserID)
+		if err != nil {
+			helper.ResponseUnauthorized(w, err.Error())
+			return
+		}
+
 		// masukkan userID kedalam context
 		// supaya handler selanjutnya bisa tahu siapa yang sedang login
 		ctx := context.WithValue(r.Context(), UserContextKey, tokenData.UserID)
 		ctx = context.WithValue(ctx, TokenContextKey, tokenString)
 
+		// guard token menentukan namespace role & permission yang dicek PermissionMiddleware
+		ctx = domain.WithGuard(ctx, tokenData.Guard)
+
+		// token yang dibuat untuk organisasi tertentu, dipakai TenantMiddleware
+		if tokenData.OrganizationID != nil {
+			ctx = context.WithValue(ctx, TokenOrganizationContextKey, *tokenData.OrganizationID)
+		}
+
 		// update waktu penggunaan terakhir (menggunakan goroutine agar jalan secara asinkron)
 		go m.tokenService.UpdateLastUsed(context.Background(), tokenString)
 
@@ -1,9 +1,13 @@
 package middleware
 
 import (
+	"fmt"
 	"golang-auth/internal/domain"
 	"golang-auth/internal/helper"
+	"log/slog"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -11,17 +15,40 @@ import (
 type PermissionMiddleware struct {
 	permissionService domain.PermissionService
 	roleService domain.RoleService
+	organizationService domain.OrganizationService
+	resourcePermissionService domain.ResourcePermissionService
+	resourceResolvers map[string]domain.ResourceAttributeResolver
 }
 
-func NewPermissionMiddleware(ps domain.PermissionService, rs domain.RoleService) *PermissionMiddleware {
+func NewPermissionMiddleware(ps domain.PermissionService, rs domain.RoleService, os domain.OrganizationService, rps domain.ResourcePermissionService) *PermissionMiddleware {
 	return &PermissionMiddleware{
 		permissionService: ps,
 		roleService: rs,
+		organizationService: os,
+		resourcePermissionService: rps,
+		resourceResolvers: make(map[string]domain.ResourceAttributeResolver),
 	}
 }
 
-// Require adalah fungsi dinamis (wrapper) handler
+// RegisterResourceResolver mendaftarkan pengambil atribut resource (misal owner_id) untuk
+// dipakai di kondisi sebagai resource.<atribut> pada RequireResource
+func (m *PermissionMiddleware) RegisterResourceResolver(resourceType string, resolver domain.ResourceAttributeResolver) {
+	m.resourceResolvers[resourceType] = resolver
+}
+
+// Require adalah fungsi dinamis (wrapper) handler.
+// hanya grant global yang dihitung, grant organisasi tidak berlaku untuk route platform
 func (m *PermissionMiddleware) Require(requirePerm string, next http.HandlerFunc) http.HandlerFunc {
+	return m.require(requirePerm, m.Allowed, next)
+}
+
+// RequireInOrganization untuk route yang datanya milik organisasi aktif (tenant),
+// permission boleh berasal dari grant global maupun grant di organisasi aktif
+func (m *PermissionMiddleware) RequireInOrganization(requirePerm string, next http.HandlerFunc) http.HandlerFunc {
+	return m.require(requirePerm, m.AllowedInOrganization, next)
+}
+
+func (m *PermissionMiddleware) require(requirePerm string, allow func(r *http.Request, userID uuid.UUID, requirePerm string) (bool, error), next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 1. Ambil userID dari context
 		userID, ok := r.Context().Value(UserContextKey).(uuid.UUID)
@@ -30,38 +57,50 @@ func (m *PermissionMiddleware) Require(requirePerm string, next http.HandlerFunc
 			return
 		}
 
-		// ==========================================
-		// TAHAP 1: Cek DIRECT PERMISSION terlebih dahulu
-		// ==========================================
-		directPermissions, err := m.permissionService.GetPermissionsByUserID(r.Context(), userID)
+		allowed, err := allow(r, userID, requirePerm)
 		if err != nil {
-			helper.ResponseInternalError(w, "Gagal memverifikasi hak akses langsung")
+			slog.Error("Gagal memverifikasi hak akses", "permission", requirePerm, "error", err)
+			helper.ResponseInternalError(w, "Gagal memverifikasi hak akses")
 			return
 		}
-
-		// Jika ketemu di direct permission, langsung beri akses dan BERHENTI (Early Return)
-		for _, p := range directPermissions {
-			if p == requirePerm {
-				next(w, r)
-				return // Hemat 2 query database!
-			}
-		}
-
-		// ==========================================
-		// TAHAP 2: Jika gagal, cari secara INDIRECT lewat ROLE
-		// ==========================================
-		roles, err := m.roleService.GetRoleByUserID(r.Context(), userID)
-		if err != nil {
-			helper.ResponseInternalError(w, "Gagal memverifikasi daftar role")
+		if !allowed {
+			logDenied(r, userID, requirePerm)
+			helper.ResponseForbidden(w, "Anda tidak memiliki izin untuk mengakses fitur ini")
 			return
 		}
 
-		// Guard clause: Kalau user ternyata nggak punya role sama sekali, langsung tolak
-		if len(roles) == 0 {
-			helper.ResponseForbidden(w, "Anda tidak memiliki izin untuk mengakses fitur ini")
-			return
+		next(w, r)
+	}
+}
+
+// Allowed menjalankan tahapan pengecekan Require tanpa menulis response,
+// dipakai juga oleh batch check supaya hasilnya selalu sama dengan middleware
+func (m *PermissionMiddleware) Allowed(r *http.Request, userID uuid.UUID, requirePerm string) (bool, error) {
+	// ==========================================
+	// TAHAP 1: Cek DIRECT PERMISSION terlebih dahulu
+	// ==========================================
+	directPermissions, err := m.permissionService.GetPermissionsByUserID(r.Context(), userID)
+	if err != nil {
+		return false, fmt.Errorf("hak akses langsung: %w", err)
+	}
+
+	// Jika ketemu di direct permission, langsung beri akses dan BERHENTI (Early Return)
+	for _, p := range directPermissions {
+		if p == requirePerm {
+			return true, nil // Hemat 2 query database!
 		}
+	}
 
+	// ==========================================
+	// TAHAP 2: Jika gagal, cari secara INDIRECT lewat ROLE
+	// ==========================================
+	roles, err := m.roleService.GetRoleByUserID(r.Context(), userID)
+	if err != nil {
+		return false, fmt.Errorf("daftar role: %w", err)
+	}
+
+	// Kalau user nggak punya role global sama sekali, langsung lanjut cek organisasi
+	if len(roles) > 0 {
 		// Konversi roles string ke roles uuid
 		var rolesUuid []uuid.UUID
 		for _, role := range roles {
@@ -75,21 +114,170 @@ func (m *PermissionMiddleware) Require(requirePerm string, next http.HandlerFunc
 		// Ambil permissions dari kumpulan role tersebut
 		permissionsFromRoles, err := m.permissionService.GetPermissionsByRoleIDs(r.Context(), rolesUuid)
 		if err != nil {
-			helper.ResponseInternalError(w, "Gagal memverifikasi hak akses role")
-			return
+			return false, fmt.Errorf("hak akses role: %w", err)
 		}
 
 		// Cek apakah requirePerm ada di daftar permission dari role
 		for _, p := range permissionsFromRoles {
 			if p == requirePerm {
-				next(w, r)
-				return // Beri akses dan berhenti
+				return true, nil
+			}
+		}
+	}
+
+	// ==========================================
+	// TAHAP 3: Cek assignment BERSYARAT (ABAC), kondisi dievaluasi terhadap atribut request
+	// ==========================================
+	return m.hasConditionalPermission(r, userID, requirePerm, "", "")
+}
+
+// AllowedInOrganization menjalankan tahapan RequireInOrganization: semua tahap Allowed,
+// lalu permission milik user di organisasi aktif
+func (m *PermissionMiddleware) AllowedInOrganization(r *http.Request, userID uuid.UUID, requirePerm string) (bool, error) {
+	allowed, err := m.Allowed(r, userID, requirePerm)
+	if err != nil || allowed {
+		return allowed, err
+	}
+
+	return m.hasOrganizationPermission(r, userID, requirePerm)
+}
+
+// RequireResource sama seperti Require, tapi permission-nya boleh juga berasal dari grant
+// per resource. id resource diambil dari r.PathValue(pathParam), misal "/documents/{id}"
+func (m *PermissionMiddleware) RequireResource(requirePerm string, resourceType string, pathParam string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(UserContextKey).(uuid.UUID)
+		if !ok {
+			helper.ResponseUnauthorized(w, "Sesi tidak valid atau tidak ditemukan")
+			return
+		}
+
+		resourceID := r.PathValue(pathParam)
+		if resourceID == "" {
+			helper.ResponseBadRequest(w, "ID resource tidak ditemukan")
+			return
+		}
+
+		allowed, err := m.AllowedResource(r, userID, requirePerm, resourceType, resourceID)
+		if err != nil {
+			slog.Error("Gagal memverifikasi hak akses resource", "permission", requirePerm, "error", err)
+			helper.ResponseInternalError(w, "Gagal memverifikasi hak akses resource")
+			return
+		}
+		if !allowed {
+			logDenied(r, userID, requirePerm)
+			helper.ResponseForbidden(w, "Anda tidak memiliki izin untuk mengakses resource ini")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// AllowedResource menjalankan tahapan pengecekan RequireResource tanpa menulis response
+func (m *PermissionMiddleware) AllowedResource(r *http.Request, userID uuid.UUID, requirePerm string, resourceType string, resourceID string) (bool, error) {
+	// permission global / per resource
+	allowed, err := m.resourcePermissionService.Can(r.Context(), userID, requirePerm, resourceType, resourceID)
+	if err != nil || allowed {
+		return allowed, err
+	}
+
+	// grant organisasi sengaja tidak dihitung, resource tidak terikat ke organisasi sehingga kalau dihitung
+	// admin organisasi bisa menjangkau resource organisasi lain
+
+	// assignment bersyarat, kondisi bisa memakai atribut resource (resource.owner_id, dll)
+	return m.hasConditionalPermission(r, userID, requirePerm, resourceType, resourceID)
+}
+
+// cek permission user di organisasi aktif, kalau request tidak membawa organisasi hasilnya false
+func (m *PermissionMiddleware) hasOrganizationPermission(r *http.Request, userID uuid.UUID, requirePerm string) (bool, error) {
+	orgID, ok := OrganizationFromRequest(r)
+	if !ok {
+		return false, nil
+	}
+
+	// token yang dibatasi ke satu organisasi hanya berlaku di organisasi tersebut
+	if tokenOrgID, scoped := r.Context().Value(TokenOrganizationContextKey).(uuid.UUID); scoped && tokenOrgID != orgID {
+		return false, nil
+	}
+
+	// admin organisasi otomatis boleh mengelola member & grant organisasinya sendiri
+	if slices.Contains(domain.OrganizationAdminPermissions, requirePerm) {
+		isAdmin, err := m.organizationService.IsAdmin(r.Context(), orgID, userID)
+		if err != nil {
+			return false, err
+		}
+		if isAdmin {
+			return true, nil
+		}
+	}
+
+	orgPermissions, err := m.permissionService.GetPermissionsByUserIDInOrganization(r.Context(), userID, orgID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range orgPermissions {
+		if p == requirePerm {
+			return true, nil
+		}
+	}
+
+	// role organisasi yang link permission-nya bersyarat
+	attributes, err := m.requestAttributes(r, "", "")
+	if err != nil {
+		return false, err
+	}
+	return m.permissionService.EvaluateConditionalGrantsInOrganization(r.Context(), userID, orgID, requirePerm, attributes)
+}
+
+// hasConditionalPermission mengevaluasi assignment yang punya kondisi.
+// atribut yang tersedia di ekspresi: request.{ip, method, path, time}, resource.* dan user.*
+func (m *PermissionMiddleware) hasConditionalPermission(r *http.Request, userID uuid.UUID, requirePerm string, resourceType string, resourceID string) (bool, error) {
+	attributes, err := m.requestAttributes(r, resourceType, resourceID)
+	if err != nil {
+		return false, err
+	}
+	return m.permissionService.EvaluateConditionalGrants(r.Context(), userID, requirePerm, attributes)
+}
+
+// atribut request & resource untuk evaluasi kondisi, atribut user diisi oleh service
+func (m *PermissionMiddleware) requestAttributes(r *http.Request, resourceType string, resourceID string) (map[string]any, error) {
+	attributes := map[string]any{
+		"request": map[string]any{
+			"ip":     helper.ClientIP(r),
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"time":   time.Now(),
+		},
+	}
+
+	if resourceID != "" {
+		resource := map[string]any{}
+
+		if resolver, ok := m.resourceResolvers[resourceType]; ok {
+			resolved, err := resolver(r.Context(), resourceID)
+			if err != nil {
+				return nil, err
+			}
+			for key, value := range resolved {
+				resource[key] = value
 			}
 		}
 
-		// ==========================================
-		// TAHAP 3: Tolak akses jika tidak ditemukan di mana-mana
-		// ==========================================
-		helper.ResponseForbidden(w, "Anda tidak memiliki izin untuk mengakses fitur ini")
+		resource["type"] = resourceType
+		resource["id"] = resourceID
+		attributes["resource"] = resource
 	}
-}
\ No newline at end of file
+
+	return attributes, nil
+}
+
+func logDenied(r *http.Request, userID uuid.UUID, requirePerm string) {
+	slog.Info("AUTHZ_DENIED",
+		slog.String("user_id", userID.String()),
+		slog.String("permission", requirePerm),
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+	)
+}
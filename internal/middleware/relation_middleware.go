@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type RelationMiddleware struct {
+	relationService domain.RelationService
+}
+
+func NewRelationMiddleware(relationService domain.RelationService) *RelationMiddleware {
+	return &RelationMiddleware{
+		relationService: relationService,
+	}
+}
+
+// Require meloloskan request kalau user yang login punya relasi tertentu pada object di path,
+// misal Require("document", "viewer", "id", ...) untuk route "/documents/{id}"
+func (m *RelationMiddleware) Require(namespace string, relation string, pathParam string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(UserContextKey).(uuid.UUID)
+		if !ok {
+			helper.ResponseUnauthorized(w, "Sesi tidak valid atau tidak ditemukan")
+			return
+		}
+
+		objectID := r.PathValue(pathParam)
+		if objectID == "" {
+			helper.ResponseBadRequest(w, "ID resource tidak ditemukan")
+			return
+		}
+
+		allowed, err := m.relationService.Check(r.Context(), domain.RelationCheckRequest{
+			Object:   namespace + ":" + objectID,
+			Relation: relation,
+			Subject:  "user:" + userID.String(),
+		})
+		if err != nil {
+			helper.ResponseInternalError(w, "Gagal memverifikasi relasi akses")
+			return
+		}
+		if !allowed {
+			helper.ResponseForbidden(w, "Anda tidak memiliki izin untuk mengakses resource ini")
+			return
+		}
+
+		next(w, r)
+	}
+}
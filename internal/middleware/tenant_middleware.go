@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/helper"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const OrganizationContextKey contextKey = "organization"
+const TokenOrganizationContextKey contextKey = "token_organization"
+
+// header yang dipakai client untuk memilih organisasi (tenant) aktif
+const OrganizationHeader = "X-Organization-ID"
+
+type TenantMiddleware struct {
+	organizationService domain.OrganizationService
+}
+
+func NewTenantMiddleware(organizationService domain.OrganizationService) *TenantMiddleware {
+	return &TenantMiddleware{
+		organizationService: organizationService,
+	}
+}
+
+// Resolve menentukan organisasi aktif dari header X-Organization-ID,
+// kalau header kosong pakai organisasi yang melekat di token.
+// harus dipasang setelah Authenticate
+func (m *TenantMiddleware) Resolve(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(UserContextKey).(uuid.UUID)
+		if !ok {
+			helper.ResponseUnauthorized(w, "Sesi tidak valid atau tidak ditemukan")
+			return
+		}
+
+		tokenOrgID, tokenScoped := r.Context().Value(TokenOrganizationContextKey).(uuid.UUID)
+
+		orgHeader := r.Header.Get(OrganizationHeader)
+		if orgHeader == "" {
+			// tidak memilih organisasi, pakai organisasi dari token (kalau ada)
+			if tokenScoped {
+				ctx := context.WithValue(r.Context(), OrganizationContextKey, tokenOrgID)
+				r = r.WithContext(ctx)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		orgID, err := uuid.Parse(orgHeader)
+		if err != nil {
+			helper.ResponseBadRequest(w, "Format ID Organisasi tidak valid")
+			return
+		}
+
+		// token yang dibatasi ke satu organisasi tidak boleh dipakai di organisasi lain
+		if tokenScoped && tokenOrgID != orgID {
+			helper.ResponseForbidden(w, "Token ini tidak berlaku untuk organisasi tersebut")
+			return
+		}
+
+		isMember, err := m.organizationService.IsMember(r.Context(), orgID, userID)
+		if err != nil {
+			helper.ResponseInternalError(w, "Gagal memverifikasi keanggotaan organisasi")
+			return
+		}
+		if !isMember {
+			helper.ResponseForbidden(w, "Anda bukan anggota organisasi ini")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), OrganizationContextKey, orgID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// OrganizationFromRequest mengambil organisasi aktif untuk request ini.
+// urutannya: path value {org_id}, lalu hasil Resolve (header / token)
+func OrganizationFromRequest(r *http.Request) (uuid.UUID, bool) {
+	if orgIDStr := r.PathValue("org_id"); orgIDStr != "" {
+		orgID, err := uuid.Parse(orgIDStr)
+		if err != nil {
+			return uuid.Nil, false
+		}
+		return orgID, true
+	}
+
+	orgID, ok := r.Context().Value(OrganizationContextKey).(uuid.UUID)
+	return orgID, ok
+}
@@ -0,0 +1,344 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+/*
+	Evaluator AST. Tipe nilai yang dikenal:
+	nil, bool, float64, string, time.Time, []any, map[string]any
+	Tidak ada perulangan dan tidak ada akses ke fungsi Go selain daftar `functions`,
+	jadi evaluasi selalu berhenti dan aman dijalankan untuk ekspresi dari admin
+*/
+
+func eval(n *node, env map[string]any) (any, error) {
+	switch n.kind {
+	case nodeLiteral:
+		return n.value, nil
+
+	case nodeIdent:
+		// variabel yang tidak ada dianggap null, sehingga `resource.owner_id == user.id` aman
+		return normalize(env[n.name]), nil
+
+	case nodeList:
+		items := make([]any, 0, len(n.children))
+		for _, child := range n.children {
+			v, err := eval(child, env)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+
+	case nodeMember:
+		target, err := eval(n.left, env)
+		if err != nil {
+			return nil, err
+		}
+		return member(target, n.name)
+
+	case nodeIndex:
+		target, err := eval(n.left, env)
+		if err != nil {
+			return nil, err
+		}
+		index, err := eval(n.right, env)
+		if err != nil {
+			return nil, err
+		}
+		return indexOf(target, index)
+
+	case nodeUnary:
+		operand, err := eval(n.left, env)
+		if err != nil {
+			return nil, err
+		}
+		switch n.name {
+		case "!":
+			b, ok := operand.(bool)
+			if !ok {
+				return nil, fmt.Errorf("operator ! membutuhkan boolean")
+			}
+			return !b, nil
+		case "-":
+			f, ok := operand.(float64)
+			if !ok {
+				return nil, fmt.Errorf("operator - membutuhkan angka")
+			}
+			return -f, nil
+		}
+
+	case nodeBinary:
+		return evalBinary(n, env)
+
+	case nodeCall:
+		args := make([]any, 0, len(n.children))
+		for _, child := range n.children {
+			v, err := eval(child, env)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, v)
+		}
+		return functions[n.name](args)
+	}
+
+	return nil, fmt.Errorf("ekspresi tidak valid")
+}
+
+func evalBinary(n *node, env map[string]any) (any, error) {
+	left, err := eval(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+
+	// && dan || dievaluasi secara short-circuit
+	if n.name == "&&" || n.name == "||" {
+		lb, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s membutuhkan boolean", n.name)
+		}
+		if n.name == "&&" && !lb {
+			return false, nil
+		}
+		if n.name == "||" && lb {
+			return true, nil
+		}
+
+		right, err := eval(n.right, env)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s membutuhkan boolean", n.name)
+		}
+		return rb, nil
+	}
+
+	right, err := eval(n.right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.name {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.name, left, right)
+	case "in":
+		return contains(right, left)
+	case "+":
+		if ls, ok := left.(string); ok {
+			if rs, ok := right.(string); ok {
+				return ls + rs, nil
+			}
+		}
+		return arithmetic(n.name, left, right)
+	case "-", "*", "/", "%":
+		return arithmetic(n.name, left, right)
+	}
+
+	return nil, fmt.Errorf("operator %s tidak dikenal", n.name)
+}
+
+func member(target any, name string) (any, error) {
+	switch t := target.(type) {
+	case map[string]any:
+		return normalize(t[name]), nil
+	case map[string]string:
+		if v, ok := t[name]; ok {
+			return v, nil
+		}
+		return nil, nil
+	case nil:
+		// akses atribut dari null menghasilkan null (seperti optional chaining)
+		return nil, nil
+	}
+	return nil, fmt.Errorf("atribut '%s' tidak bisa diakses dari %T", name, target)
+}
+
+func indexOf(target any, index any) (any, error) {
+	switch t := target.(type) {
+	case []any:
+		f, ok := index.(float64)
+		if !ok || f != math.Trunc(f) {
+			return nil, fmt.Errorf("index list harus bilangan bulat")
+		}
+		i := int(f)
+		if i < 0 || i >= len(t) {
+			return nil, nil
+		}
+		return normalize(t[i]), nil
+	case map[string]any, map[string]string, nil:
+		key, ok := index.(string)
+		if !ok {
+			return nil, fmt.Errorf("key map harus string")
+		}
+		return member(t, key)
+	}
+	return nil, fmt.Errorf("nilai %T tidak bisa diakses dengan index", target)
+}
+
+func equal(left any, right any) bool {
+	switch l := left.(type) {
+	case time.Time:
+		r, ok := right.(time.Time)
+		return ok && l.Equal(r)
+	case []any, map[string]any:
+		// list & map tidak dibandingkan isinya
+		return false
+	}
+	// tipe lain dari environment (misal map[string]string dari resolver resource) bisa tidak comparable,
+	// == pada tipe seperti itu panic
+	if left != nil && !reflect.TypeOf(left).Comparable() {
+		return false
+	}
+	return left == right
+}
+
+func compare(op string, left any, right any) (bool, error) {
+	var cmp int
+
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false, fmt.Errorf("tidak bisa membandingkan angka dengan %T", right)
+		}
+		cmp = compareOrdered(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false, fmt.Errorf("tidak bisa membandingkan string dengan %T", right)
+		}
+		cmp = strings.Compare(l, r)
+	case time.Time:
+		r, ok := right.(time.Time)
+		if !ok {
+			return false, fmt.Errorf("tidak bisa membandingkan waktu dengan %T", right)
+		}
+		cmp = l.Compare(r)
+	default:
+		return false, fmt.Errorf("operator %s tidak mendukung %T", op, left)
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func compareOrdered(l float64, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+func arithmetic(op string, left any, right any) (any, error) {
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s membutuhkan angka", op)
+	}
+
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("pembagian dengan nol")
+		}
+		return l / r, nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("pembagian dengan nol")
+		}
+		return math.Mod(l, r), nil
+	}
+}
+
+// contains dipakai operator `in`: elemen di list, key di map, atau substring di string
+func contains(container any, item any) (bool, error) {
+	switch c := container.(type) {
+	case []any:
+		for _, v := range c {
+			if equal(normalize(v), item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]any:
+		key, ok := item.(string)
+		if !ok {
+			return false, nil
+		}
+		_, exists := c[key]
+		return exists, nil
+	case string:
+		sub, ok := item.(string)
+		if !ok {
+			return false, fmt.Errorf("operator in pada string membutuhkan string")
+		}
+		return strings.Contains(c, sub), nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("operator in tidak mendukung %T", container)
+}
+
+// normalize menyeragamkan tipe Go dari environment (int, []string, dll) ke tipe evaluator
+func normalize(v any) any {
+	switch t := v.(type) {
+	case int:
+		return float64(t)
+	case int32:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case uint:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	case float32:
+		return float64(t)
+	case *time.Time:
+		if t == nil {
+			return nil
+		}
+		return *t
+	case []string:
+		items := make([]any, len(t))
+		for i, s := range t {
+			items[i] = s
+		}
+		return items
+	case fmt.Stringer:
+		// misal uuid.UUID, dibandingkan sebagai string
+		if _, isTime := v.(time.Time); !isTime {
+			return t.String()
+		}
+	}
+	return v
+}
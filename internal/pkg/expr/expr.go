@@ -0,0 +1,241 @@
+package expr
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+	Bahasa ekspresi kecil (mirip CEL) untuk kondisi policy, contoh:
+
+	  hour(request.time, "Asia/Jakarta") >= 8 && hour(request.time, "Asia/Jakarta") < 17
+	  ip_in_cidr(request.ip, "10.0.0.0/8")
+	  resource.owner_id == user.id
+	  weekday(request.time) in [1, 2, 3, 4, 5]
+*/
+
+// Program adalah ekspresi yang sudah di-parse dan siap dievaluasi berkali-kali
+type Program struct {
+	source string
+	root   *node
+}
+
+// Compile mem-parse ekspresi, dipakai juga untuk validasi saat kondisi disimpan
+func Compile(src string) (*Program, error) {
+	root, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{source: src, root: root}, nil
+}
+
+func (p *Program) String() string {
+	return p.source
+}
+
+// Eval menjalankan ekspresi terhadap environment, hasil akhirnya wajib boolean
+func (p *Program) Eval(env map[string]any) (bool, error) {
+	result, err := eval(p.root, env)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("hasil ekspresi harus boolean, didapat %T", result)
+	}
+	return b, nil
+}
+
+// Cache menyimpan Program hasil Compile, agar ekspresi yang sama tidak di-parse tiap request
+type Cache struct {
+	mu       sync.RWMutex
+	programs map[string]*Program
+}
+
+// batas jumlah ekspresi di cache, kalau penuh cache dikosongkan
+const maxCacheSize = 1000
+
+func NewCache() *Cache {
+	return &Cache{
+		programs: make(map[string]*Program),
+	}
+}
+
+func (c *Cache) Compile(src string) (*Program, error) {
+	c.mu.RLock()
+	program, ok := c.programs[src]
+	c.mu.RUnlock()
+	if ok {
+		return program, nil
+	}
+
+	program, err := Compile(src)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if len(c.programs) >= maxCacheSize {
+		c.programs = make(map[string]*Program)
+	}
+	c.programs[src] = program
+	c.mu.Unlock()
+
+	return program, nil
+}
+
+// ==========================================
+// FUNGSI BAWAAN
+// ==========================================
+
+var functions map[string]func(args []any) (any, error)
+
+func init() {
+	functions = map[string]func(args []any) (any, error){
+		"hour": func(args []any) (any, error) {
+			t, err := timeArg("hour", args)
+			if err != nil {
+				return nil, err
+			}
+			return float64(t.Hour()), nil
+		},
+		"minute": func(args []any) (any, error) {
+			t, err := timeArg("minute", args)
+			if err != nil {
+				return nil, err
+			}
+			return float64(t.Minute()), nil
+		},
+		// 0 = Minggu, 1 = Senin, ... 6 = Sabtu
+		"weekday": func(args []any) (any, error) {
+			t, err := timeArg("weekday", args)
+			if err != nil {
+				return nil, err
+			}
+			return float64(t.Weekday()), nil
+		},
+		"timestamp": func(args []any) (any, error) {
+			s, err := stringArgs("timestamp", args, 1)
+			if err != nil {
+				return nil, err
+			}
+			t, err := time.Parse(time.RFC3339, s[0])
+			if err != nil {
+				return nil, fmt.Errorf("timestamp: format harus RFC3339")
+			}
+			return t, nil
+		},
+		"ip_in_cidr": func(args []any) (any, error) {
+			s, err := stringArgs("ip_in_cidr", args, 2)
+			if err != nil {
+				return nil, err
+			}
+			ip, err := netip.ParseAddr(s[0])
+			if err != nil {
+				return false, nil
+			}
+			prefix, err := netip.ParsePrefix(s[1])
+			if err != nil {
+				return nil, fmt.Errorf("ip_in_cidr: CIDR '%s' tidak valid", s[1])
+			}
+			return prefix.Contains(ip.Unmap()), nil
+		},
+		"starts_with": func(args []any) (any, error) {
+			s, err := stringArgs("starts_with", args, 2)
+			if err != nil {
+				return nil, err
+			}
+			return strings.HasPrefix(s[0], s[1]), nil
+		},
+		"ends_with": func(args []any) (any, error) {
+			s, err := stringArgs("ends_with", args, 2)
+			if err != nil {
+				return nil, err
+			}
+			return strings.HasSuffix(s[0], s[1]), nil
+		},
+		"lower": func(args []any) (any, error) {
+			s, err := stringArgs("lower", args, 1)
+			if err != nil {
+				return nil, err
+			}
+			return strings.ToLower(s[0]), nil
+		},
+		"upper": func(args []any) (any, error) {
+			s, err := stringArgs("upper", args, 1)
+			if err != nil {
+				return nil, err
+			}
+			return strings.ToUpper(s[0]), nil
+		},
+		"size": func(args []any) (any, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("size: membutuhkan 1 argumen")
+			}
+			switch v := args[0].(type) {
+			case string:
+				return float64(len([]rune(v))), nil
+			case []any:
+				return float64(len(v)), nil
+			case map[string]any:
+				return float64(len(v)), nil
+			case nil:
+				return float64(0), nil
+			}
+			return nil, fmt.Errorf("size: tidak mendukung %T", args[0])
+		},
+		// has(x) bernilai true kalau atribut ada (tidak null)
+		"has": func(args []any) (any, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("has: membutuhkan 1 argumen")
+			}
+			return args[0] != nil, nil
+		},
+	}
+}
+
+// timeArg membaca argumen (waktu, [zona waktu]) untuk fungsi hour/minute/weekday
+func timeArg(name string, args []any) (time.Time, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return time.Time{}, fmt.Errorf("%s: membutuhkan 1 atau 2 argumen", name)
+	}
+
+	t, ok := args[0].(time.Time)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%s: argumen pertama harus waktu", name)
+	}
+
+	if len(args) == 2 {
+		zone, ok := args[1].(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("%s: zona waktu harus string", name)
+		}
+		location, err := time.LoadLocation(zone)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s: zona waktu '%s' tidak dikenal", name, zone)
+		}
+		t = t.In(location)
+	}
+
+	return t, nil
+}
+
+func stringArgs(name string, args []any, count int) ([]string, error) {
+	if len(args) != count {
+		return nil, fmt.Errorf("%s: membutuhkan %d argumen", name, count)
+	}
+
+	result := make([]string, count)
+	for i, arg := range args {
+		s, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: argumen ke-%d harus string", name, i+1)
+		}
+		result[i] = s
+	}
+	return result, nil
+}
@@ -0,0 +1,144 @@
+package expr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Senin 10:00 WIB dan Sabtu 20:00 WIB
+	workHours := time.Date(2026, 10, 19, 10, 0, 0, 0, jakarta)
+	night := time.Date(2026, 10, 24, 20, 0, 0, 0, jakarta)
+
+	businessHours := `hour(request.time, "Asia/Jakarta") >= 8 && hour(request.time, "Asia/Jakarta") < 17 && weekday(request.time, "Asia/Jakarta") in [1, 2, 3, 4, 5]`
+
+	tests := []struct {
+		name string
+		src  string
+		env  map[string]any
+		want bool
+	}{
+		{"jam kerja", businessHours, map[string]any{"request": map[string]any{"time": workHours}}, true},
+		{"di luar jam kerja", businessHours, map[string]any{"request": map[string]any{"time": night}}, false},
+		{"jam kerja dengan waktu UTC", businessHours, map[string]any{"request": map[string]any{"time": workHours.UTC()}}, true},
+
+		{"ip di dalam range", `ip_in_cidr(request.ip, "10.0.0.0/8")`, map[string]any{"request": map[string]any{"ip": "10.1.2.3"}}, true},
+		{"ip di luar range", `ip_in_cidr(request.ip, "10.0.0.0/8")`, map[string]any{"request": map[string]any{"ip": "192.168.1.1"}}, false},
+		{"ipv4-mapped ipv6", `ip_in_cidr(request.ip, "10.0.0.0/8")`, map[string]any{"request": map[string]any{"ip": "::ffff:10.0.0.1"}}, true},
+		{"ip tidak valid", `ip_in_cidr(request.ip, "10.0.0.0/8")`, map[string]any{"request": map[string]any{"ip": "bukan-ip"}}, false},
+
+		{"pemilik resource", `resource.owner_id == user.id`, map[string]any{
+			"resource": map[string]any{"owner_id": "u-1"},
+			"user":     map[string]any{"id": "u-1"},
+		}, true},
+		{"bukan pemilik resource", `resource.owner_id == user.id`, map[string]any{
+			"resource": map[string]any{"owner_id": "u-2"},
+			"user":     map[string]any{"id": "u-1"},
+		}, false},
+		{"resource tanpa owner", `resource.owner_id == user.id`, map[string]any{
+			"resource": map[string]any{},
+			"user":     map[string]any{"id": "u-1"},
+		}, false},
+		{"resource dari map[string]string", `resource.owner_id == user.id`, map[string]any{
+			"resource": map[string]string{"owner_id": "u-1"},
+			"user":     map[string]any{"id": "u-1"},
+		}, true},
+
+		{"map tidak comparable", `resource.labels == resource.labels`, map[string]any{
+			"resource": map[string]any{"labels": map[string]string{"env": "prod"}},
+		}, false},
+		{"list tidak dibandingkan isinya", `[1, 2] == [1, 2]`, nil, false},
+		{"short-circuit tidak menyentuh sisi kanan", `false && undefined_fn_result.x > 1`, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := Compile(tt.src)
+			if err != nil {
+				t.Fatalf("Compile(%q): %v", tt.src, err)
+			}
+			got, err := program.Eval(tt.env)
+			if err != nil {
+				t.Fatalf("Eval(%q): %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval(%q) = %v, ingin %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+// kondisi yang error harus ditolak pemanggil, Eval mengembalikan false bersama error-nya
+func TestEvalFailClosed(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		env  map[string]any
+	}{
+		{"hasil bukan boolean", `user.id`, map[string]any{"user": map[string]any{"id": "u-1"}}},
+		{"waktu tidak ada", `hour(request.time) >= 8`, map[string]any{}},
+		{"zona waktu tidak dikenal", `hour(request.time, "Mars/Olympus") >= 8`, map[string]any{"request": map[string]any{"time": time.Now()}}},
+		{"cidr tidak valid", `ip_in_cidr(request.ip, "10.0.0.0/99")`, map[string]any{"request": map[string]any{"ip": "10.0.0.1"}}},
+		{"banding beda tipe", `user.level > "3"`, map[string]any{"user": map[string]any{"level": 5}}},
+		{"logika pada null", `user.admin && true`, map[string]any{}},
+		{"pembagian dengan nol", `1 / 0 == 1`, nil},
+		{"akses atribut dari string", `user.id.name == "x"`, map[string]any{"user": map[string]any{"id": "u-1"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := Compile(tt.src)
+			if err != nil {
+				t.Fatalf("Compile(%q): %v", tt.src, err)
+			}
+			got, err := program.Eval(tt.env)
+			if err == nil {
+				t.Fatalf("Eval(%q) = %v, ingin error", tt.src, got)
+			}
+			if got {
+				t.Errorf("Eval(%q) = true bersama error %v", tt.src, err)
+			}
+		})
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"kosong", ``},
+		{"kurung tidak ditutup", `(user.id == "a"`},
+		{"fungsi tidak dikenal", `exec("rm -rf /")`},
+		{"string tidak ditutup", `user.id == "a`},
+		{"operator menggantung", `user.id ==`},
+		{"karakter tidak dikenal", `user.id # 1`},
+		{"terlalu panjang", `"` + string(make([]byte, maxSourceLength)) + `"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.src); err == nil {
+				t.Errorf("Compile(%q) tidak mengembalikan error", tt.src)
+			}
+		})
+	}
+}
+
+func TestCompileDepthLimit(t *testing.T) {
+	src := ""
+	for range maxDepth + 1 {
+		src += "("
+	}
+	src += "true"
+	for range maxDepth + 1 {
+		src += ")"
+	}
+	if _, err := Compile(src); err == nil {
+		t.Errorf("Compile dengan kedalaman %d tidak mengembalikan error", maxDepth+1)
+	}
+}
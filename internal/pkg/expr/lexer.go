@@ -0,0 +1,154 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+/*
+	Lexer untuk bahasa ekspresi kondisi.
+	Mengubah string seperti `request.ip == "10.0.0.1" && hour(request.time) < 17`
+	menjadi deretan token
+*/
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+	tokenDot
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value any // nilai literal untuk number & string
+	pos   int
+}
+
+// operator dua karakter dicek lebih dulu dari yang satu karakter
+var twoCharOperators = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+
+	for i < len(src) {
+		c := rune(src[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '"' || c == '\'':
+			str, next, err := readString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: src[i:next], value: str, pos: i})
+			i = next
+
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			var number float64
+			if _, err := fmt.Sscanf(src[start:i], "%g", &number); err != nil {
+				return nil, fmt.Errorf("angka tidak valid di posisi %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[start:i], value: number, pos: start})
+
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(src) && (unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i])) || src[i] == '_') {
+				i++
+			}
+			word := src[start:i]
+			// "in" adalah operator, bukan identifier
+			if word == "in" {
+				tokens = append(tokens, token{kind: tokenOperator, text: word, pos: start})
+			} else {
+				tokens = append(tokens, token{kind: tokenIdent, text: word, pos: start})
+			}
+
+		default:
+			matched := false
+			for _, op := range twoCharOperators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+					i += 2
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+
+			kind := tokenOperator
+			switch c {
+			case '(':
+				kind = tokenLParen
+			case ')':
+				kind = tokenRParen
+			case '[':
+				kind = tokenLBracket
+			case ']':
+				kind = tokenRBracket
+			case ',':
+				kind = tokenComma
+			case '.':
+				kind = tokenDot
+			case '<', '>', '!', '+', '-', '*', '/', '%':
+				kind = tokenOperator
+			default:
+				return nil, fmt.Errorf("karakter tidak dikenal '%c' di posisi %d", c, i)
+			}
+			tokens = append(tokens, token{kind: kind, text: string(c), pos: i})
+			i++
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(src)})
+	return tokens, nil
+}
+
+// readString membaca string literal yang diawali kutip tunggal / ganda, mendukung escape \" \' \\ \n \t
+func readString(src string, start int) (string, int, error) {
+	quote := src[start]
+	var sb strings.Builder
+
+	i := start + 1
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\\' && i+1 < len(src):
+			switch src[i+1] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(src[i+1])
+			}
+			i += 2
+		case c == quote:
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+
+	return "", 0, fmt.Errorf("string tidak ditutup di posisi %d", start)
+}
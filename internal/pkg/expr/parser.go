@@ -0,0 +1,267 @@
+package expr
+
+import (
+	"fmt"
+)
+
+/*
+	Parser (Pratt / precedence climbing) yang mengubah token menjadi AST.
+	Urutan prioritas operator dari yang paling lemah:
+	||  &&  == !=  < <= > >= in  + -  * / %  unary(! -)  postfix(. [] ())
+*/
+
+// batas agar ekspresi dari user tidak bisa membuat stack overflow / makan CPU
+const (
+	maxSourceLength = 2000
+	maxDepth        = 50
+)
+
+type nodeKind int
+
+const (
+	nodeLiteral nodeKind = iota
+	nodeIdent
+	nodeUnary
+	nodeBinary
+	nodeMember
+	nodeIndex
+	nodeCall
+	nodeList
+)
+
+type node struct {
+	kind     nodeKind
+	value    any    // literal
+	name     string // identifier, nama member, nama fungsi, operator
+	left     *node
+	right    *node
+	children []*node // argumen fungsi / isi list
+}
+
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4, "in": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func parse(src string) (*node, error) {
+	if len(src) > maxSourceLength {
+		return nil, fmt.Errorf("ekspresi terlalu panjang (maksimal %d karakter)", maxSourceLength)
+	}
+
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpression(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("token tidak terduga '%s' di posisi %d", p.peek().text, p.peek().pos)
+	}
+
+	return root, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	t := p.next()
+	if t.kind != kind {
+		return fmt.Errorf("diharapkan '%s' di posisi %d", text, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseExpression(minPrecedence int) (*node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, fmt.Errorf("ekspresi terlalu dalam (maksimal %d tingkat)", maxDepth)
+	}
+
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		if t.kind != tokenOperator {
+			return left, nil
+		}
+
+		precedence, ok := binaryPrecedence[t.text]
+		if !ok || precedence <= minPrecedence {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parseExpression(precedence)
+		if err != nil {
+			return nil, err
+		}
+
+		left = &node{kind: nodeBinary, name: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (*node, error) {
+	t := p.peek()
+	if t.kind == tokenOperator && (t.text == "!" || t.text == "-") {
+		p.next()
+
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxDepth {
+			return nil, fmt.Errorf("ekspresi terlalu dalam (maksimal %d tingkat)", maxDepth)
+		}
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeUnary, name: t.text, left: operand}, nil
+	}
+
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (*node, error) {
+	current, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().kind {
+		case tokenDot:
+			p.next()
+			member := p.next()
+			if member.kind != tokenIdent {
+				return nil, fmt.Errorf("nama atribut diharapkan setelah '.' di posisi %d", member.pos)
+			}
+			current = &node{kind: nodeMember, name: member.text, left: current}
+
+		case tokenLBracket:
+			p.next()
+			index, err := p.parseExpression(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokenRBracket, "]"); err != nil {
+				return nil, err
+			}
+			current = &node{kind: nodeIndex, left: current, right: index}
+
+		default:
+			return current, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (*node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenNumber, tokenString:
+		return &node{kind: nodeLiteral, value: t.value}, nil
+
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &node{kind: nodeLiteral, value: true}, nil
+		case "false":
+			return &node{kind: nodeLiteral, value: false}, nil
+		case "null":
+			return &node{kind: nodeLiteral, value: nil}, nil
+		}
+
+		// pemanggilan fungsi, misal hour(request.time)
+		if p.peek().kind == tokenLParen {
+			p.next()
+			args, err := p.parseArguments(tokenRParen, ")")
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := functions[t.text]; !ok {
+				return nil, fmt.Errorf("fungsi '%s' tidak dikenal", t.text)
+			}
+			return &node{kind: nodeCall, name: t.text, children: args}, nil
+		}
+
+		return &node{kind: nodeIdent, name: t.text}, nil
+
+	case tokenLParen:
+		inner, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenRParen, ")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+
+	case tokenLBracket:
+		items, err := p.parseArguments(tokenRBracket, "]")
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeList, children: items}, nil
+
+	case tokenEOF:
+		return nil, fmt.Errorf("ekspresi berakhir terlalu cepat")
+	}
+
+	return nil, fmt.Errorf("token tidak terduga '%s' di posisi %d", t.text, t.pos)
+}
+
+// parseArguments membaca daftar ekspresi dipisah koma sampai token penutup
+func (p *parser) parseArguments(closing tokenKind, closingText string) ([]*node, error) {
+	var items []*node
+
+	if p.peek().kind == closing {
+		p.next()
+		return items, nil
+	}
+
+	for {
+		item, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		if p.peek().kind == tokenComma {
+			p.next()
+			continue
+		}
+		if err := p.expect(closing, closingText); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+}
@@ -0,0 +1,135 @@
+package identifier
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+/*
+	Normalisasi email & username supaya "Alice@Corp.com", "alice@corp.com" dan "ａｌｉｃｅ@corp.com"
+	(huruf fullwidth) dianggap identifier yang sama:
+
+	  1. trim spasi
+	  2. NFKC, menyatukan bentuk kompatibel (fullwidth, ligatur, superscript)
+	  3. case folding Unicode, lebih lengkap dari strings.ToLower (misal "ß" -> "ss")
+	  4. NFKC lagi karena folding bisa menghasilkan urutan yang belum ter-normalisasi
+
+	Skeleton dipakai untuk mencegah username yang mirip secara visual ("paypal" vs "рaypal" dengan р Cyrillic)
+*/
+
+var folder = cases.Fold()
+
+var (
+	ErrUsernameChar   = errors.New("username hanya boleh berisi huruf, angka, titik, underscore dan strip")
+	ErrUsernameScript = errors.New("username tidak boleh mencampur huruf Latin, Cyrillic dan Yunani")
+)
+
+func fold(s string) string {
+	s = norm.NFKC.String(strings.TrimSpace(s))
+	return norm.NFKC.String(folder.String(s))
+}
+
+// NormalizeEmail dipakai untuk kolom email_normalized & pencarian saat login
+func NormalizeEmail(email string) string {
+	return fold(email)
+}
+
+// NormalizeUsername dipakai untuk kolom username_normalized & pencarian saat login
+func NormalizeUsername(username string) string {
+	return fold(username)
+}
+
+// IsEmail membedakan login pakai email atau username, username tidak boleh mengandung "@"
+func IsEmail(login string) bool {
+	return strings.Contains(login, "@")
+}
+
+// ValidateUsername menolak karakter kontrol / format (misal zero-width joiner), "@" yang bisa
+// tertukar dengan email, dan campuran script yang rawan dipakai untuk meniru username lain
+func ValidateUsername(username string) error {
+	var scripts []*unicode.RangeTable
+
+	for _, r := range NormalizeUsername(username) {
+		switch {
+		case r == '.' || r == '_' || r == '-':
+			continue
+		case unicode.IsLetter(r):
+			for _, script := range confusableScripts {
+				if unicode.Is(script, r) && !containsTable(scripts, script) {
+					scripts = append(scripts, script)
+				}
+			}
+		case unicode.IsNumber(r), unicode.Is(unicode.Mn, r), unicode.Is(unicode.Mc, r):
+			continue
+		default:
+			return ErrUsernameChar
+		}
+	}
+
+	if len(scripts) > 1 {
+		return ErrUsernameScript
+	}
+	return nil
+}
+
+// script yang hurufnya banyak kembar dengan Latin
+var confusableScripts = []*unicode.RangeTable{unicode.Latin, unicode.Cyrillic, unicode.Greek}
+
+func containsTable(tables []*unicode.RangeTable, table *unicode.RangeTable) bool {
+	for _, t := range tables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// Skeleton mengubah username ter-normalisasi menjadi bentuk "kerangka" berbasis huruf Latin,
+// dua username dengan skeleton sama dianggap kembar dan ditolak (konsep mirip UTS #39)
+func Skeleton(username string) string {
+	// "I" besar dan "l" kecil identik di banyak font, harus dipetakan sebelum di-fold jadi "i"
+	normalized := NormalizeUsername(strings.ReplaceAll(norm.NFKC.String(username), "I", "l"))
+
+	var b strings.Builder
+	b.Grow(len(normalized))
+	for _, r := range norm.NFD.String(normalized) {
+		// tanda diakritik dibuang, "é" dan "e" dianggap sama
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if prototype, ok := confusables[r]; ok {
+			b.WriteString(prototype)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	skeleton := b.String()
+	for _, seq := range confusableSequences {
+		skeleton = strings.ReplaceAll(skeleton, seq[0], seq[1])
+	}
+	return skeleton
+}
+
+// peta huruf kembar ke prototipe Latin, hanya huruf kecil karena input sudah di-fold
+var confusables = map[rune]string{
+	// Cyrillic
+	'а': "a", 'в': "b", 'е': "e", 'һ': "h", 'і': "i", 'ј': "j", 'к': "k",
+	'ӏ': "l", 'м': "m", 'н': "h", 'о': "o", 'р': "p", 'с': "c", 'т': "t", 'у': "y", 'х': "x",
+	'ѕ': "s", 'ԁ': "d", 'ԛ': "q", 'ԝ': "w", 'ь': "b", 'п': "n", 'г': "r",
+	// Yunani
+	'α': "a", 'β': "b", 'γ': "y", 'ε': "e", 'η': "n", 'ι': "i", 'κ': "k", 'ν': "v", 'ο': "o",
+	'ρ': "p", 'τ': "t", 'υ': "u", 'χ': "x", 'ω': "w", 'ς': "c",
+	// Latin & angka yang mirip huruf lain
+	'ı': "i", 'ɡ': "g", 'ɑ': "a", '0': "o", '1': "l",
+}
+
+// urutan huruf yang terlihat seperti satu huruf lain
+var confusableSequences = [][2]string{
+	{"rn", "m"},
+	{"vv", "w"},
+}
@@ -0,0 +1,88 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// Message adalah email sederhana (plain text)
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim notifikasi, implementasinya bisa SMTP atau cukup ditulis ke log
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New memilih mailer dari env, kalau SMTP_HOST kosong email hanya dicatat di log
+func New() Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return &LogMailer{}
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+
+	return &SMTPMailer{
+		Addr:     net.JoinHostPort(host, port),
+		Host:     host,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("MAIL_FROM"),
+	}
+}
+
+// LogMailer tidak benar-benar mengirim email, cocok untuk development
+type LogMailer struct{}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	slog.Info("MAIL",
+		slog.Any("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
+
+type SMTPMailer struct {
+	Addr     string
+	Host     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return nil
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	// header & subject tidak boleh mengandung baris baru (header injection)
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(msg.Subject)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", m.From)
+	fmt.Fprintf(&sb, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&sb, "Subject: %s\r\n", subject)
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	sb.WriteString(msg.Body)
+
+	return smtp.SendMail(m.Addr, auth, m.From, msg.To, []byte(sb.String()))
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-auth/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type accessRequestRepository struct {
+	db DBTX
+}
+
+func NewAccessRequestRepository(db *sql.DB) domain.AccessRequestRepository {
+	return &accessRequestRepository{
+		db: db,
+	}
+}
+
+func (repo *accessRequestRepository) WithTx(tx *sql.Tx) domain.AccessRequestRepository {
+	return &accessRequestRepository{
+		db: tx,
+	}
+}
+
+// kolom yang sama untuk semua query select, nama target diambil dari roles / permissions
+const accessRequestSelect = `SELECT ar.id, ar.user_id, u.username, ar.request_type,
+				COALESCE(ar.role_id, ar.permission_id), COALESCE(r.name, p.name, ''),
+				ar.justification, ar.duration_minutes, ar.status, ar.reviewer_id,
+				ar.review_note, ar.reviewed_at, ar.expires_at, ar.created_at, ar.updated_at
+			  FROM access_requests as ar
+			  JOIN users as u ON u.id = ar.user_id
+			  LEFT JOIN roles as r ON r.id = ar.role_id
+			  LEFT JOIN permissions as p ON p.id = ar.permission_id`
+
+func (repo *accessRequestRepository) Create(ctx context.Context, req *domain.AccessRequest) error {
+
+	query := `INSERT INTO access_requests
+			  (id, user_id, request_type, role_id, permission_id, justification, duration_minutes, status, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	idBin, _ := req.ID.MarshalBinary()
+	userBin, _ := req.UserID.MarshalBinary()
+	targetBin, _ := req.TargetID.MarshalBinary()
+
+	// target disimpan di kolom sesuai tipenya agar foreign key-nya berlaku
+	var roleBin, permissionBin any
+	if req.Type == "role" {
+		roleBin = targetBin
+	} else {
+		permissionBin = targetBin
+	}
+
+	_, err := repo.db.ExecContext(ctx, query,
+		idBin,
+		userBin,
+		req.Type,
+		roleBin,
+		permissionBin,
+		req.Justification,
+		req.DurationMinutes,
+		req.Status,
+		req.CreatedAt,
+		req.UpdatedAt,
+	)
+
+	return err
+}
+
+func (repo *accessRequestRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.AccessRequest, error) {
+
+	query := accessRequestSelect + ` WHERE ar.id = ?`
+
+	binID, _ := id.MarshalBinary()
+
+	req, err := scanAccessRequest(repo.db.QueryRowContext(ctx, query, binID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("access request not found")
+		}
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (repo *accessRequestRepository) FindAll(ctx context.Context, status string) ([]domain.AccessRequest, error) {
+
+	query := accessRequestSelect + ` ORDER BY ar.created_at DESC`
+	var args []any
+	if status != "" {
+		query = accessRequestSelect + ` WHERE ar.status = ? ORDER BY ar.created_at DESC`
+		args = append(args, status)
+	}
+
+	return repo.findMany(ctx, query, args...)
+}
+
+func (repo *accessRequestRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]domain.AccessRequest, error) {
+
+	query := accessRequestSelect + ` WHERE ar.user_id = ? ORDER BY ar.created_at DESC`
+
+	userBin, _ := userID.MarshalBinary()
+
+	return repo.findMany(ctx, query, userBin)
+}
+
+func (repo *accessRequestRepository) AnonymizeByUserID(ctx context.Context, userID uuid.UUID) error {
+
+	query := `UPDATE access_requests
+			  SET justification = '', status = IF(status = 'pending', 'cancelled', status)
+			  WHERE user_id = ?`
+
+	userBin, _ := userID.MarshalBinary()
+	_, err := repo.db.ExecContext(ctx, query, userBin)
+
+	return err
+}
+
+func (repo *accessRequestRepository) UpdateStatus(ctx context.Context, req *domain.AccessRequest) error {
+
+	query := `UPDATE access_requests
+			  SET status = ?, reviewer_id = ?, review_note = ?, reviewed_at = ?, expires_at = ?, updated_at = ?
+			  WHERE id = ? AND status = 'pending'`
+
+	idBin, _ := req.ID.MarshalBinary()
+
+	var reviewerBin any
+	if req.ReviewerID != nil {
+		reviewerBin, _ = req.ReviewerID.MarshalBinary()
+	}
+
+	res, err := repo.db.ExecContext(ctx, query,
+		req.Status,
+		reviewerBin,
+		nullString(req.ReviewNote),
+		req.ReviewedAt,
+		req.ExpiresAt,
+		req.UpdatedAt,
+		idBin,
+	)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("access request sudah diproses sebelumnya")
+		}
+	}
+
+	return err
+}
+
+func (repo *accessRequestRepository) FindTargetName(ctx context.Context, requestType string, targetID uuid.UUID) (string, error) {
+
+	// role khusus organisasi tidak bisa diminta lewat access request global
+	query := `SELECT name FROM roles WHERE id = ? AND organization_id IS NULL`
+	notFound := "role not found"
+	if requestType == "permission" {
+		query = `SELECT name FROM permissions WHERE id = ?`
+		notFound = "permission not found"
+	}
+
+	binID, _ := targetID.MarshalBinary()
+
+	var name string
+	err := repo.db.QueryRowContext(ctx, query, binID).Scan(&name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.New(notFound)
+		}
+		return "", err
+	}
+
+	return name, nil
+}
+
+func (repo *accessRequestRepository) findMany(ctx context.Context, query string, args ...any) ([]domain.AccessRequest, error) {
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []domain.AccessRequest{}
+	for rows.Next() {
+		req, err := scanAccessRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, *req)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+func scanAccessRequest(row scanner) (*domain.AccessRequest, error) {
+	req := &domain.AccessRequest{}
+	var idBin, userBin, targetBin, reviewerBin []byte
+	var note sql.NullString
+	var reviewedAt, expiresAt sql.NullTime
+
+	err := row.Scan(
+		&idBin,
+		&userBin,
+		&req.Username,
+		&req.Type,
+		&targetBin,
+		&req.TargetName,
+		&req.Justification,
+		&req.DurationMinutes,
+		&req.Status,
+		&reviewerBin,
+		&note,
+		&reviewedAt,
+		&expiresAt,
+		&req.CreatedAt,
+		&req.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	req.ID, _ = uuid.FromBytes(idBin)
+	req.UserID, _ = uuid.FromBytes(userBin)
+	req.TargetID, _ = uuid.FromBytes(targetBin)
+	if reviewerBin != nil {
+		reviewerID, _ := uuid.FromBytes(reviewerBin)
+		req.ReviewerID = &reviewerID
+	}
+	req.ReviewNote = note.String
+	req.ReviewedAt = nullTimePtr(reviewedAt)
+	req.ExpiresAt = nullTimePtr(expiresAt)
+
+	return req, nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"golang-auth/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type auditRepository struct {
+	db DBTX
+}
+
+func NewAuditRepository(db *sql.DB) domain.AuditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
+
+func (repo *auditRepository) WithTx(tx *sql.Tx) domain.AuditRepository {
+	return &auditRepository{
+		db: tx,
+	}
+}
+
+func (repo *auditRepository) Create(ctx context.Context, log *domain.AuditLog) error {
+
+	query := `INSERT INTO audit_logs (id, actor_id, action, subject_type, subject_id, metadata, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	if log.ID == uuid.Nil {
+		log.ID, _ = uuid.NewV7()
+	}
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+
+	idBin, _ := log.ID.MarshalBinary()
+
+	// actor kosong disimpan sebagai NULL (aksi sistem)
+	var actorBin any
+	if log.ActorID != nil {
+		actorBin, _ = log.ActorID.MarshalBinary()
+	}
+
+	var metadata any
+	if len(log.Metadata) > 0 {
+		encoded, err := json.Marshal(log.Metadata)
+		if err != nil {
+			return err
+		}
+		metadata = encoded
+	}
+
+	_, err := repo.db.ExecContext(ctx, query,
+		idBin,
+		actorBin,
+		log.Action,
+		log.SubjectType,
+		log.SubjectID,
+		metadata,
+		log.CreatedAt,
+	)
+
+	return err
+}
+
+func (repo *auditRepository) FindByUser(ctx context.Context, userID uuid.UUID) ([]domain.AuditLog, error) {
+
+	query := `SELECT id, actor_id, action, subject_type, subject_id, metadata, created_at FROM audit_logs
+			  WHERE actor_id = ? OR (subject_type = 'user' AND subject_id = ?)
+			  ORDER BY id`
+
+	userBin, _ := userID.MarshalBinary()
+	rows, err := repo.db.QueryContext(ctx, query, userBin, userID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []domain.AuditLog{}
+	for rows.Next() {
+		var log domain.AuditLog
+		var idBin, actorBin, metadata []byte
+
+		err := rows.Scan(&idBin, &actorBin, &log.Action, &log.SubjectType, &log.SubjectID, &metadata, &log.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		log.ID, _ = uuid.FromBytes(idBin)
+		if actorBin != nil {
+			actorID, _ := uuid.FromBytes(actorBin)
+			log.ActorID = &actorID
+		}
+		if metadata != nil {
+			err = json.Unmarshal(metadata, &log.Metadata)
+			if err != nil {
+				return nil, err
+			}
+		}
+		logs = append(logs, log)
+	}
+
+	return logs, rows.Err()
+}
+
+func (repo *auditRepository) AnonymizeUser(ctx context.Context, userID uuid.UUID, email string) error {
+
+	// key metadata yang berisi data pribadi, baris log-nya sendiri tetap disimpan
+	query := `UPDATE audit_logs SET metadata = JSON_REMOVE(metadata, '$.email', '$.username', '$.ip', '$.user_agent')
+			  WHERE metadata IS NOT NULL AND subject_type = 'user' AND subject_id = ?`
+
+	userBin, _ := userID.MarshalBinary()
+	_, err := repo.db.ExecContext(ctx, query, userID.String())
+	if err != nil {
+		return err
+	}
+
+	// log undangan menyimpan email tujuan
+	query = `UPDATE audit_logs SET metadata = JSON_REMOVE(metadata, '$.email')
+			 WHERE metadata IS NOT NULL AND subject_type = 'invitation' AND subject_id IN (
+				SELECT BIN_TO_UUID(id) FROM invitations WHERE accepted_user_id = ? OR email = ?
+			 )`
+
+	_, err = repo.db.ExecContext(ctx, query, userBin, email)
+	return err
+}
@@ -3,10 +3,121 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"golang-auth/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type DBTX interface {
 	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
 	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
 	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
-}
\ No newline at end of file
+}
+
+// scanner dipakai bersama oleh *sql.Row dan *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// conditionValue mengambil kondisi ABAC untuk id tertentu, NULL kalau assignment tanpa kondisi
+func conditionValue(conditions map[uuid.UUID]string, id uuid.UUID) any {
+	if condition, ok := conditions[id]; ok && condition != "" {
+		return condition
+	}
+	return nil
+}
+
+// scheduleValues mengambil starts_at & expires_at untuk id tertentu, NULL kalau tanpa jadwal
+func scheduleValues(schedules map[uuid.UUID]domain.AssignmentSchedule, id uuid.UUID) (any, any) {
+	schedule, ok := schedules[id]
+	if !ok {
+		return nil, nil
+	}
+
+	var startsAt, expiresAt any
+	if schedule.StartsAt != nil {
+		startsAt = schedule.StartsAt.UTC()
+	}
+	if schedule.ExpiresAt != nil {
+		expiresAt = schedule.ExpiresAt.UTC()
+	}
+	return startsAt, expiresAt
+}
+
+// activeAssignment adalah filter SQL untuk assignment yang sedang berlaku pada alias tabel tertentu.
+// waktu disimpan dalam UTC (DSN tanpa loc), jadi dibandingkan dengan UTC_TIMESTAMP()
+func activeAssignment(alias string) string {
+	return fmt.Sprintf("(%[1]s.starts_at IS NULL OR %[1]s.starts_at <= UTC_TIMESTAMP()) AND (%[1]s.expires_at IS NULL OR %[1]s.expires_at > UTC_TIMESTAMP())", alias)
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// nullString menyimpan string kosong sebagai NULL
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// bumpVersion menaikkan kolom version untuk optimistic locking. expected 0 berarti tanpa pengecekan
+// (request tanpa If-Match), selain itu update hanya berhasil kalau versi di database masih sama
+func bumpVersion(ctx context.Context, db DBTX, table string, id uuid.UUID, expected int) error {
+	binID, _ := id.MarshalBinary()
+
+	query := `UPDATE ` + table + ` SET version = version + 1 WHERE id = ?`
+	args := []any{binID}
+	if expected > 0 {
+		query += ` AND version = ?`
+		args = append(args, expected)
+	}
+
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rows, _ := res.RowsAffected()
+	if rows > 0 {
+		return nil
+	}
+
+	// bedakan data yang tidak ada dengan versi yang sudah berubah
+	var exists int
+	err = db.QueryRowContext(ctx, `SELECT 1 FROM `+table+` WHERE id = ?`, binID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%s not found", strings.TrimSuffix(table, "s"))
+	}
+	if err != nil {
+		return err
+	}
+	return domain.ErrVersionConflict
+}
+
+// deleteLinks menghapus sebagian baris tabel pivot, misal detach beberapa permission dari role
+func deleteLinks(ctx context.Context, db DBTX, table string, ownerColumn string, ownerID uuid.UUID, linkColumn string, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ownerBin, _ := ownerID.MarshalBinary()
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, ownerBin)
+	placeholders := make([]string, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		idBin, _ := id.MarshalBinary()
+		args = append(args, idBin)
+	}
+
+	query := `DELETE FROM ` + table + ` WHERE ` + ownerColumn + ` = ? AND ` + linkColumn + ` IN (` + strings.Join(placeholders, ",") + `)`
+	_, err := db.ExecContext(ctx, query, args...)
+	return err
+}
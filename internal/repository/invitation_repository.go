@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"golang-auth/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type invitationRepository struct {
+	db DBTX
+}
+
+func NewInvitationRepository(db *sql.DB) domain.InvitationRepository {
+	return &invitationRepository{
+		db: db,
+	}
+}
+
+func (repo *invitationRepository) WithTx(tx *sql.Tx) domain.InvitationRepository {
+	return &invitationRepository{
+		db: tx,
+	}
+}
+
+const invitationSelect = `SELECT id, email, token_hash, inviter_id, guard_name, role_ids, permission_ids, status,
+				accepted_user_id, accepted_at, revoked_at, expires_at, created_at, updated_at
+			  FROM invitations`
+
+func (repo *invitationRepository) Create(ctx context.Context, invitation *domain.Invitation) error {
+
+	query := `INSERT INTO invitations
+			  (id, email, token_hash, inviter_id, guard_name, role_ids, permission_ids, status, expires_at, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	idBin, _ := invitation.ID.MarshalBinary()
+
+	var inviterBin any
+	if invitation.InviterID != nil {
+		inviterBin, _ = invitation.InviterID.MarshalBinary()
+	}
+
+	// id disimpan sebagai array JSON, role / permission yang terhapus sebelum undangan diterima
+	// akan gagal saat assign di userService.Create
+	roleIDs, err := json.Marshal(invitation.RoleIDs)
+	if err != nil {
+		return err
+	}
+	permissionIDs, err := json.Marshal(invitation.PermissionIDs)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.ExecContext(ctx, query,
+		idBin,
+		invitation.Email,
+		invitation.TokenHash,
+		inviterBin,
+		invitation.Guard,
+		roleIDs,
+		permissionIDs,
+		invitation.Status,
+		invitation.ExpiresAt,
+		invitation.CreatedAt,
+		invitation.UpdatedAt,
+	)
+
+	return err
+}
+
+func (repo *invitationRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Invitation, error) {
+
+	query := invitationSelect + ` WHERE id = ?`
+
+	binID, _ := id.MarshalBinary()
+
+	invitation, err := scanInvitation(repo.db.QueryRowContext(ctx, query, binID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("invitation not found")
+		}
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (repo *invitationRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*domain.Invitation, error) {
+
+	query := invitationSelect + ` WHERE token_hash = ?`
+
+	invitation, err := scanInvitation(repo.db.QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("invitation not found")
+		}
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (repo *invitationRepository) FindAll(ctx context.Context, status string) ([]domain.Invitation, error) {
+
+	// expired bukan status yang disimpan, jadi pending & expired dibedakan lewat expires_at
+	var where string
+	var args []any
+	switch status {
+	case "":
+	case domain.InvitationPending:
+		where = ` WHERE status = ? AND expires_at > ?`
+		args = append(args, domain.InvitationPending, time.Now())
+	case domain.InvitationExpired:
+		where = ` WHERE status = ? AND expires_at <= ?`
+		args = append(args, domain.InvitationPending, time.Now())
+	default:
+		where = ` WHERE status = ?`
+		args = append(args, status)
+	}
+
+	rows, err := repo.db.QueryContext(ctx, invitationSelect+where+` ORDER BY created_at DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanInvitations(rows)
+}
+
+func (repo *invitationRepository) FindByUser(ctx context.Context, userID uuid.UUID, email string) ([]domain.Invitation, error) {
+
+	userBin, _ := userID.MarshalBinary()
+
+	rows, err := repo.db.QueryContext(ctx, invitationSelect+` WHERE accepted_user_id = ? OR email = ? ORDER BY created_at DESC`, userBin, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanInvitations(rows)
+}
+
+func scanInvitations(rows *sql.Rows) ([]domain.Invitation, error) {
+	defer rows.Close()
+
+	invitations := []domain.Invitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *invitation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (repo *invitationRepository) ExistsPending(ctx context.Context, email string) (bool, error) {
+
+	query := `SELECT EXISTS (SELECT 1 FROM invitations WHERE email = ? AND status = ? AND expires_at > ?)`
+
+	var exists bool
+	err := repo.db.QueryRowContext(ctx, query, email, domain.InvitationPending, time.Now()).Scan(&exists)
+
+	return exists, err
+}
+
+func (repo *invitationRepository) AnonymizeEmail(ctx context.Context, userID uuid.UUID, email string, replacement string) error {
+
+	// undangan yang masih pending ikut dicabut supaya token-nya tidak bisa dipakai lagi,
+	// revoked_at membaca status yang baru karena MySQL mengevaluasi SET dari kiri ke kanan
+	query := `UPDATE invitations
+			  SET email = ?, status = IF(status = 'pending', 'revoked', status),
+			  revoked_at = IF(status = 'revoked' AND revoked_at IS NULL, ?, revoked_at)
+			  WHERE accepted_user_id = ? OR email = ?`
+
+	userBin, _ := userID.MarshalBinary()
+	_, err := repo.db.ExecContext(ctx, query, replacement, time.Now(), userBin, email)
+
+	return err
+}
+
+func (repo *invitationRepository) UpdateStatus(ctx context.Context, invitation *domain.Invitation) error {
+
+	query := `UPDATE invitations
+			  SET status = ?, accepted_user_id = ?, accepted_at = ?, revoked_at = ?, updated_at = ?
+			  WHERE id = ? AND status = 'pending'`
+
+	idBin, _ := invitation.ID.MarshalBinary()
+
+	var acceptedBin any
+	if invitation.AcceptedUserID != nil {
+		acceptedBin, _ = invitation.AcceptedUserID.MarshalBinary()
+	}
+
+	res, err := repo.db.ExecContext(ctx, query,
+		invitation.Status,
+		acceptedBin,
+		invitation.AcceptedAt,
+		invitation.RevokedAt,
+		invitation.UpdatedAt,
+		idBin,
+	)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("undangan sudah dipakai atau dibatalkan")
+		}
+	}
+
+	return err
+}
+
+func scanInvitation(row scanner) (*domain.Invitation, error) {
+	invitation := &domain.Invitation{}
+	var idBin, inviterBin, acceptedBin []byte
+	var roleIDs, permissionIDs []byte
+	var acceptedAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&idBin,
+		&invitation.Email,
+		&invitation.TokenHash,
+		&inviterBin,
+		&invitation.Guard,
+		&roleIDs,
+		&permissionIDs,
+		&invitation.Status,
+		&acceptedBin,
+		&acceptedAt,
+		&revokedAt,
+		&invitation.ExpiresAt,
+		&invitation.CreatedAt,
+		&invitation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.ID, _ = uuid.FromBytes(idBin)
+	if inviterBin != nil {
+		inviterID, _ := uuid.FromBytes(inviterBin)
+		invitation.InviterID = &inviterID
+	}
+	if acceptedBin != nil {
+		acceptedID, _ := uuid.FromBytes(acceptedBin)
+		invitation.AcceptedUserID = &acceptedID
+	}
+	invitation.AcceptedAt = nullTimePtr(acceptedAt)
+	invitation.RevokedAt = nullTimePtr(revokedAt)
+
+	err = json.Unmarshal(roleIDs, &invitation.RoleIDs)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(permissionIDs, &invitation.PermissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.Status == domain.InvitationPending && !invitation.ExpiresAt.After(time.Now()) {
+		invitation.Status = domain.InvitationExpired
+	}
+
+	return invitation, nil
+}
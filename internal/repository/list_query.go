@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-auth/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// listColumns memetakan field di API ke kolom SQL. hanya field yang terdaftar bisa dipakai filter & sort,
+// jadi nilai dari query string tidak pernah masuk ke SQL sebagai identifier
+type listColumns map[string]string
+
+// listCondition adalah kondisi WHERE tambahan di luar filter query string, misal pencarian FULLTEXT
+type listCondition struct {
+	SQL  string
+	Args []any
+}
+
+// cursor keyset: nilai kolom sort + id baris terakhir halaman sebelumnya. field & arah sort ikut disimpan
+// supaya cursor tidak bisa dipakai ulang dengan urutan lain
+type listCursor struct {
+	Sort  string    `json:"s"`
+	Desc  bool      `json:"d,omitempty"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// buildListQuery menambahkan WHERE (filter & cursor), ORDER BY dan LIMIT ke base query.
+// base harus berakhir di klausa FROM / JOIN tanpa WHERE, id selalu jadi tie-breaker urutan.
+// LIMIT diambil satu lebih banyak supaya ketahuan masih ada halaman berikutnya
+func buildListQuery(base string, idColumn string, columns listColumns, spec domain.QuerySpec, extra ...listCondition) (string, []any, error) {
+	var conditions []string
+	var args []any
+
+	for _, condition := range extra {
+		conditions = append(conditions, condition.SQL)
+		args = append(args, condition.Args...)
+	}
+
+	for _, filter := range spec.Filters {
+		column, ok := columns[filter.Field]
+		if !ok {
+			return "", nil, fmt.Errorf("filter tidak didukung: %s", filter.Field)
+		}
+
+		switch filter.Op {
+		case domain.FilterEqual:
+			conditions = append(conditions, column+" = ?")
+			args = append(args, filter.Value)
+		case domain.FilterNotEqual:
+			conditions = append(conditions, column+" <> ?")
+			args = append(args, filter.Value)
+		case domain.FilterContains:
+			conditions = append(conditions, column+` LIKE ? ESCAPE '\\'`)
+			args = append(args, "%"+escapeLike(filter.Value)+"%")
+		default:
+			return "", nil, fmt.Errorf("operator filter tidak didukung: %s", filter.Op)
+		}
+	}
+
+	sortColumn := idColumn
+	if spec.Sort != "" {
+		column, ok := columns[spec.Sort]
+		if !ok {
+			return "", nil, fmt.Errorf("sort tidak didukung: %s", spec.Sort)
+		}
+		sortColumn = column
+	}
+
+	compare, direction := ">", "ASC"
+	if spec.Desc {
+		compare, direction = "<", "DESC"
+	}
+
+	if spec.Cursor != "" {
+		cursor, err := decodeCursor(spec.Cursor)
+		if err != nil || cursor.Sort != spec.Sort || cursor.Desc != spec.Desc {
+			return "", nil, errors.New("cursor tidak valid untuk urutan sort ini")
+		}
+		idBin, _ := cursor.ID.MarshalBinary()
+
+		if sortColumn == idColumn {
+			conditions = append(conditions, idColumn+" "+compare+" ?")
+			args = append(args, idBin)
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND %[3]s %[2]s ?))", sortColumn, compare, idColumn))
+			args = append(args, cursor.Value, cursor.Value, idBin)
+		}
+	}
+
+	query := base
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY " + sortColumn + " " + direction
+	if sortColumn != idColumn {
+		query += ", " + idColumn + " " + direction
+	}
+
+	query += " LIMIT ?"
+	args = append(args, pageLimit(spec)+1)
+
+	return query, args, nil
+}
+
+// newPageMeta menghitung meta dari jumlah baris hasil buildListQuery, mengembalikan jumlah baris yang
+// ditampilkan. lastKey dipanggil untuk baris terakhir kalau masih ada halaman berikutnya
+func newPageMeta(spec domain.QuerySpec, count int, lastKey func(index int) (any, uuid.UUID)) (*domain.PageMeta, int) {
+	limit := pageLimit(spec)
+	meta := &domain.PageMeta{Limit: limit}
+	if count <= limit {
+		return meta, count
+	}
+
+	meta.HasMore = true
+	value, id := lastKey(limit - 1)
+	meta.NextCursor = encodeCursor(listCursor{Sort: spec.Sort, Desc: spec.Desc, Value: cursorValue(value), ID: id})
+	return meta, limit
+}
+
+func pageLimit(spec domain.QuerySpec) int {
+	if spec.Limit < 1 || spec.Limit > domain.MaxPageLimit {
+		return domain.DefaultPageLimit
+	}
+	return spec.Limit
+}
+
+// nilai sort disimpan sebagai string, waktu dalam format yang bisa dibandingkan MySQL
+func cursorValue(value any) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04:05.999999")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func encodeCursor(cursor listCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(encoded string) (listCursor, error) {
+	var cursor listCursor
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(raw, &cursor)
+	return cursor, err
+}
+
+// escapeLike supaya % dan _ dari user dicari apa adanya
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,425 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-auth/internal/domain"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type organizationRepository struct {
+	db DBTX
+}
+
+func NewOrganizationRepository(db *sql.DB) domain.OrganizationRepository {
+	return &organizationRepository{
+		db: db,
+	}
+}
+
+func (repo *organizationRepository) WithTx(tx *sql.Tx) domain.OrganizationRepository {
+	return &organizationRepository{
+		db: tx,
+	}
+}
+
+func (repo *organizationRepository) Create(ctx context.Context, org *domain.Organization) error {
+
+	query := `INSERT INTO organizations (id, name, slug, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?)`
+
+	idBytes, err := org.ID.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.ExecContext(ctx, query,
+		idBytes,
+		org.Name,
+		org.Slug,
+		org.CreatedAt,
+		org.UpdatedAt,
+	)
+
+	return err
+}
+
+func (repo *organizationRepository) Update(ctx context.Context, org *domain.Organization) error {
+
+	query := `UPDATE organizations SET name = ?, slug = ?, updated_at = ? WHERE id = ?`
+
+	idBytes, err := org.ID.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	res, err := repo.db.ExecContext(ctx, query,
+		org.Name,
+		org.Slug,
+		org.UpdatedAt,
+		idBytes,
+	)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("no organization updated")
+		}
+	}
+
+	return err
+}
+
+func (repo *organizationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+
+	query := `DELETE FROM organizations WHERE id = ?`
+
+	idBytes, err := id.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	res, err := repo.db.ExecContext(ctx, query, idBytes)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("no organization found to delete")
+		}
+	}
+
+	return err
+}
+
+func (repo *organizationRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Organization, error) {
+
+	query := `SELECT id, name, slug, created_at, updated_at FROM organizations WHERE id = ?`
+
+	binID, _ := id.MarshalBinary()
+
+	org := &domain.Organization{}
+	var orgBinID []byte
+	err := repo.db.QueryRowContext(ctx, query, binID).Scan(
+		&orgBinID,
+		&org.Name,
+		&org.Slug,
+		&org.CreatedAt,
+		&org.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("organization not found")
+		}
+		return nil, err
+	}
+	org.ID, _ = uuid.FromBytes(orgBinID)
+
+	return org, nil
+}
+
+func (repo *organizationRepository) FindAll(ctx context.Context) ([]domain.Organization, error) {
+
+	query := `SELECT id, name, slug, created_at, updated_at FROM organizations`
+
+	rows, err := repo.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOrganizations(rows)
+}
+
+// ambil semua organisasi tempat user menjadi member
+func (repo *organizationRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Organization, error) {
+
+	query := `SELECT o.id, o.name, o.slug, o.created_at, o.updated_at FROM organizations as o
+			  JOIN organization_members as om ON o.id = om.organization_id
+			  WHERE om.user_id = ?`
+
+	userBinID, _ := userID.MarshalBinary()
+
+	rows, err := repo.db.QueryContext(ctx, query, userBinID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOrganizations(rows)
+}
+
+func scanOrganizations(rows *sql.Rows) ([]domain.Organization, error) {
+	var orgs []domain.Organization
+	for rows.Next() {
+		var org domain.Organization
+		var binID []byte
+
+		err := rows.Scan(
+			&binID,
+			&org.Name,
+			&org.Slug,
+			&org.CreatedAt,
+			&org.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		org.ID, err = uuid.FromBytes(binID)
+		if err != nil {
+			return nil, err
+		}
+
+		orgs = append(orgs, org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orgs, nil
+}
+
+func (repo *organizationRepository) AddMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, isAdmin bool) error {
+
+	// kalau user sudah jadi member, cukup perbarui status admin-nya
+	query := `INSERT INTO organization_members (organization_id, user_id, is_admin) VALUES (?, ?, ?)
+			  ON DUPLICATE KEY UPDATE is_admin = VALUES(is_admin)`
+
+	orgBinID, _ := orgID.MarshalBinary()
+	userBinID, _ := userID.MarshalBinary()
+
+	_, err := repo.db.ExecContext(ctx, query, orgBinID, userBinID, isAdmin)
+
+	return err
+}
+
+func (repo *organizationRepository) RemoveMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) error {
+
+	// role & permission organisasi ikut terhapus lewat ON DELETE CASCADE
+	query := `DELETE FROM organization_members WHERE organization_id = ? AND user_id = ?`
+
+	orgBinID, _ := orgID.MarshalBinary()
+	userBinID, _ := userID.MarshalBinary()
+
+	res, err := repo.db.ExecContext(ctx, query, orgBinID, userBinID)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("no member found to remove")
+		}
+	}
+
+	return err
+}
+
+func (repo *organizationRepository) FindMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) (*domain.OrganizationMember, error) {
+
+	query := `SELECT u.id, u.username, u.email, om.is_admin, om.created_at FROM organization_members as om
+			  JOIN users as u ON u.id = om.user_id
+			  WHERE om.organization_id = ? AND om.user_id = ?`
+
+	orgBinID, _ := orgID.MarshalBinary()
+	userBinID, _ := userID.MarshalBinary()
+
+	member := &domain.OrganizationMember{}
+	var memberBinID []byte
+	err := repo.db.QueryRowContext(ctx, query, orgBinID, userBinID).Scan(
+		&memberBinID,
+		&member.Username,
+		&member.Email,
+		&member.IsAdmin,
+		&member.JoinedAt,
+	)
+	if err != nil {
+		// kembalikan error aslinya agar service bisa membedakan "bukan member" dengan error DB
+		return nil, err
+	}
+	member.UserID, _ = uuid.FromBytes(memberBinID)
+
+	// ambil role organisasi milik member
+	queryRole := `SELECT r.id, r.name FROM roles as r
+				  JOIN organization_user_has_roles as ouhr ON r.id = ouhr.role_id
+				  WHERE ouhr.organization_id = ? AND ouhr.user_id = ?`
+	rowsR, err := repo.db.QueryContext(ctx, queryRole, orgBinID, userBinID)
+	if err != nil {
+		return nil, err
+	}
+	defer rowsR.Close()
+
+	for rowsR.Next() {
+		var role domain.Role
+		var roleBinID []byte
+		if err := rowsR.Scan(&roleBinID, &role.Name); err != nil {
+			return nil, err
+		}
+		role.ID, _ = uuid.FromBytes(roleBinID)
+		member.Roles = append(member.Roles, role)
+	}
+	if err = rowsR.Err(); err != nil {
+		return nil, err
+	}
+
+	// ambil permission organisasi milik member
+	queryPermission := `SELECT p.id, p.name FROM permissions as p
+						JOIN organization_user_has_permissions as ouhp ON p.id = ouhp.permission_id
+						WHERE ouhp.organization_id = ? AND ouhp.user_id = ?`
+	rowsP, err := repo.db.QueryContext(ctx, queryPermission, orgBinID, userBinID)
+	if err != nil {
+		return nil, err
+	}
+	defer rowsP.Close()
+
+	for rowsP.Next() {
+		var permission domain.Permission
+		var permissionBinID []byte
+		if err := rowsP.Scan(&permissionBinID, &permission.Name); err != nil {
+			return nil, err
+		}
+		permission.ID, _ = uuid.FromBytes(permissionBinID)
+		member.Permissions = append(member.Permissions, permission)
+	}
+	if err = rowsP.Err(); err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+func (repo *organizationRepository) FindMembers(ctx context.Context, orgID uuid.UUID) ([]domain.OrganizationMember, error) {
+
+	query := `SELECT u.id, u.username, u.email, om.is_admin, om.created_at FROM organization_members as om
+			  JOIN users as u ON u.id = om.user_id
+			  WHERE om.organization_id = ?`
+
+	orgBinID, _ := orgID.MarshalBinary()
+
+	rows, err := repo.db.QueryContext(ctx, query, orgBinID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []domain.OrganizationMember
+	for rows.Next() {
+		var member domain.OrganizationMember
+		var memberBinID []byte
+		err := rows.Scan(
+			&memberBinID,
+			&member.Username,
+			&member.Email,
+			&member.IsAdmin,
+			&member.JoinedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		member.UserID, _ = uuid.FromBytes(memberBinID)
+		members = append(members, member)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (repo *organizationRepository) AssignRoles(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, roleIDs []uuid.UUID) error {
+	if len(roleIDs) == 0 {
+		return nil
+	}
+
+	query := `INSERT INTO organization_user_has_roles (organization_id, user_id, role_id) VALUES `
+
+	orgBinID, _ := orgID.MarshalBinary()
+	userBinID, _ := userID.MarshalBinary()
+
+	values := make([]interface{}, 0, len(roleIDs)*3) // * 3 karna butuh organization_id, user_id dan role_id
+	placeHolders := make([]string, 0, len(roleIDs))
+
+	for _, rID := range roleIDs {
+		placeHolders = append(placeHolders, "(?, ?, ?)")
+
+		roleBinID, _ := rID.MarshalBinary()
+		values = append(values, orgBinID, userBinID, roleBinID)
+	}
+
+	query += strings.Join(placeHolders, ", ")
+
+	_, err := repo.db.ExecContext(ctx, query, values...)
+
+	return err
+}
+
+func (repo *organizationRepository) RemoveAllRoles(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) error {
+
+	query := `DELETE FROM organization_user_has_roles WHERE organization_id = ? AND user_id = ?`
+
+	orgBinID, _ := orgID.MarshalBinary()
+	userBinID, _ := userID.MarshalBinary()
+
+	_, err := repo.db.ExecContext(ctx, query, orgBinID, userBinID)
+
+	return err
+}
+
+func (repo *organizationRepository) AssignPermissions(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, permissionIDs []uuid.UUID) error {
+	if len(permissionIDs) == 0 {
+		return nil
+	}
+
+	query := `INSERT INTO organization_user_has_permissions (organization_id, user_id, permission_id) VALUES `
+
+	orgBinID, _ := orgID.MarshalBinary()
+	userBinID, _ := userID.MarshalBinary()
+
+	values := make([]interface{}, 0, len(permissionIDs)*3) // * 3 karna butuh organization_id, user_id dan permission_id
+	placeHolders := make([]string, 0, len(permissionIDs))
+
+	for _, pID := range permissionIDs {
+		placeHolders = append(placeHolders, "(?, ?, ?)")
+
+		permBinID, _ := pID.MarshalBinary()
+		values = append(values, orgBinID, userBinID, permBinID)
+	}
+
+	query += strings.Join(placeHolders, ", ")
+
+	_, err := repo.db.ExecContext(ctx, query, values...)
+
+	return err
+}
+
+// FindHeldPermissionIDs mengembalikan permission tanpa syarat yang dimiliki user di organisasi,
+// langsung maupun lewat role organisasi
+func (repo *organizationRepository) FindHeldPermissionIDs(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, guard string) ([]uuid.UUID, error) {
+
+	query := `SELECT ouhp.permission_id FROM organization_user_has_permissions as ouhp
+			  JOIN permissions as p ON p.id = ouhp.permission_id
+			  WHERE ouhp.organization_id = ? AND ouhp.user_id = ? AND p.guard_name = ?
+
+			  UNION
+
+			  SELECT rhp.permission_id FROM organization_user_has_roles as ouhr
+			  JOIN role_has_permissions as rhp ON rhp.role_id = ouhr.role_id
+			  JOIN permissions as p ON p.id = rhp.permission_id
+			  WHERE ouhr.organization_id = ? AND ouhr.user_id = ? AND p.guard_name = ?
+			  AND rhp.condition_expression IS NULL`
+
+	orgBinID, _ := orgID.MarshalBinary()
+	userBinID, _ := userID.MarshalBinary()
+
+	return findIDs(ctx, repo.db, query, orgBinID, userBinID, guard, orgBinID, userBinID, guard)
+}
+
+func (repo *organizationRepository) RemoveAllPermissions(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) error {
+
+	query := `DELETE FROM organization_user_has_permissions WHERE organization_id = ? AND user_id = ?`
+
+	orgBinID, _ := orgID.MarshalBinary()
+	userBinID, _ := userID.MarshalBinary()
+
+	_, err := repo.db.ExecContext(ctx, query, orgBinID, userBinID)
+
+	return err
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"golang-auth/internal/domain"
 	"strings"
@@ -12,7 +13,7 @@ import (
 
 // definisikan struct secara private
 type permissionRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // buat constructor
@@ -22,30 +23,221 @@ func NewPermissionRepository(db *sql.DB) domain.PermissionRepository {
 	}
 }
 
+func (p *permissionRepository) WithTx(tx *sql.Tx) domain.PermissionRepository {
+	return &permissionRepository{
+		db: tx,
+	}
+}
+
+func (p *permissionRepository) Create(ctx context.Context, permission *domain.Permission) error {
+
+	query := `INSERT INTO permissions (id, name, guard_name, description, group_name, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	idBytes, err := permission.ID.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	_, err = p.db.ExecContext(ctx, query,
+		idBytes,
+		permission.Name,
+		permission.Guard,
+		nullString(permission.Description),
+		nullString(permission.Group),
+		permission.CreatedAt,
+		permission.UpdatedAt,
+	)
+
+	return err
+}
+
+func (p *permissionRepository) Update(ctx context.Context, permission *domain.Permission) error {
+
+	query := `UPDATE permissions SET name = ?, guard_name = ?, description = ?, group_name = ?, updated_at = ? WHERE id = ?`
+
+	idBytes, err := permission.ID.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	res, err := p.db.ExecContext(ctx, query,
+		permission.Name,
+		permission.Guard,
+		nullString(permission.Description),
+		nullString(permission.Group),
+		permission.UpdatedAt,
+		idBytes,
+	)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("no permission updated")
+		}
+	}
+
+	return err
+}
+
+func (p *permissionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+
+	query := `DELETE FROM permissions WHERE id = ?`
+
+	idBytes, err := id.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	res, err := p.db.ExecContext(ctx, query, idBytes)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("no permission found to delete")
+		}
+	}
+
+	return err
+}
+
+func (p *permissionRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Permission, error) {
+
+	query := `SELECT id, name, guard_name, description, group_name, created_at, updated_at FROM permissions WHERE id = ?`
+
+	binID, _ := id.MarshalBinary()
+
+	permission := &domain.Permission{}
+	var permissionBinID []byte
+	var description, group sql.NullString
+	err := p.db.QueryRowContext(ctx, query, binID).Scan(
+		&permissionBinID,
+		&permission.Name,
+		&permission.Guard,
+		&description,
+		&group,
+		&permission.CreatedAt,
+		&permission.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("permission not found")
+		}
+		return nil, err
+	}
+	permission.ID, _ = uuid.FromBytes(permissionBinID)
+	permission.Description = description.String
+	permission.Group = group.String
+
+	return permission, nil
+}
+
+// ambil beberapa permission sekaligus, id yang tidak ada diabaikan
+func (p *permissionRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Permission, error) {
+	if len(ids) == 0 {
+		return []domain.Permission{}, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id[:]
+	}
+
+	query := fmt.Sprintf(`SELECT id, name, guard_name, description, group_name, created_at, updated_at
+			  FROM permissions WHERE id IN (%s) ORDER BY name`, strings.Join(placeholders, ","))
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPermissions(rows)
+}
+
 // get all permissions
 func(p *permissionRepository) FindAll(ctx context.Context) ([]domain.Permission, error)   {
 	
-	query := `SELECT id, name, created_at, updated_at FROM permissions`
+	query := `SELECT id, name, guard_name, description, group_name, created_at, updated_at FROM permissions ORDER BY name, guard_name`
 	rows, err := p.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanPermissions(rows)
+}
+
+var permissionListColumns = listColumns{
+	"name":       "name",
+	"guard":      "guard_name",
+	"group":      "COALESCE(group_name, '')", // NULL tidak bisa dipakai cursor
+	"created_at": "created_at",
+}
+
+func (p *permissionRepository) FindPage(ctx context.Context, spec domain.QuerySpec) ([]domain.Permission, *domain.PageMeta, error) {
+	base := `SELECT id, name, guard_name, description, group_name, created_at, updated_at FROM permissions`
+	query, args, err := buildListQuery(base, "id", permissionListColumns, spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	permissions, err := scanPermissions(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta, count := newPageMeta(spec, len(permissions), func(i int) (any, uuid.UUID) {
+		permission := permissions[i]
+		switch spec.Sort {
+		case "name":
+			return permission.Name, permission.ID
+		case "guard":
+			return permission.Guard, permission.ID
+		case "group":
+			return permission.Group, permission.ID
+		case "created_at":
+			return permission.CreatedAt, permission.ID
+		}
+		return nil, permission.ID
+	})
+
+	permissions = permissions[:count]
+	if permissions == nil {
+		permissions = []domain.Permission{}
+	}
+	return permissions, meta, nil
+}
+
+// scanPermissions membaca hasil query dengan kolom id, name, guard_name, description, group_name, created_at, updated_at
+func scanPermissions(rows *sql.Rows) ([]domain.Permission, error) {
+
 	var permissions []domain.Permission
 	for rows.Next() {
 		var permission domain.Permission
 		var binID []byte
+		var description, group sql.NullString
 
 		err := rows.Scan(
 			&binID, 
 			&permission.Name, 
+			&permission.Guard,
+			&description,
+			&group,
 			&permission.CreatedAt, 
 			&permission.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		permission.Description = description.String
+		permission.Group = group.String
 
 		// konversi dari bytes ke uuid.UUID
 		permission.ID, err = uuid.FromBytes(binID)
@@ -57,7 +249,7 @@ func(p *permissionRepository) FindAll(ctx context.Context) ([]domain.Permission,
 	}
 
 	// Cek apakah ada error selama proses looping
-    if err = rows.Err(); err != nil {
+    if err := rows.Err(); err != nil {
         return nil, err
     }
 
@@ -65,21 +257,25 @@ func(p *permissionRepository) FindAll(ctx context.Context) ([]domain.Permission,
 }
 
 // get permission by user id
-func(p *permissionRepository) GetPermissionsByUserID(ctx context.Context, userID uuid.UUID) ([]string, error) {
+func(p *permissionRepository) GetPermissionsByUserID(ctx context.Context, userID uuid.UUID, guard string) ([]string, error) {
 	// query pertama ke role dan ke user_has_roles, buat cek role si user ada akses ke permission-nya atau tidak (indirect)
 	// query kedua ke user_has_permissions, buat cek si user punya akses langsung ke permissions atau tidak (direct) 
 	// union buat ambil hasil query select pertama dan gabungin ke hasil query select kedua
+	// assignment yang punya kondisi (ABAC) tidak ikut, dievaluasi terpisah lewat GetConditionalGrantsByUserID
 	query := `SELECT p.name FROM permissions as p
 			  JOIN role_has_permissions as rhp ON p.id = rhp.permission_id
 			  JOIN user_has_roles as uhr ON rhp.role_id = uhr.role_id
-			  WHERE uhr.user_id = ?
+			  WHERE uhr.user_id = ? AND p.guard_name = ?
+			  AND uhr.condition_expression IS NULL AND rhp.condition_expression IS NULL
+			  AND ` + activeAssignment("uhr") + `
 	
 			  UNION
 
 			  SELECT p.name FROM permissions as p
 			  JOIN user_has_permissions as uhp ON p.id = uhp.permission_id
-			  WHERE uhp.user_id = ?
-			  `
+			  WHERE uhp.user_id = ? AND p.guard_name = ?
+			  AND uhp.condition_expression IS NULL
+			  AND ` + activeAssignment("uhp")
 	
 	var binID []byte
 	binID, err := userID.MarshalBinary()
@@ -88,8 +284,8 @@ func(p *permissionRepository) GetPermissionsByUserID(ctx context.Context, userID
 	}
 
 	rows, err := p.db.QueryContext(ctx, query,
-		binID,
-		binID,
+		binID, guard,
+		binID, guard,
 	)
 	if err != nil {
 		return  nil, err
@@ -117,7 +313,7 @@ func(p *permissionRepository) GetPermissionsByUserID(ctx context.Context, userID
 }
 
 // buat ambil permission berdasarkan id role
-func (p *permissionRepository) GetPermissionsByRoleIDs(ctx context.Context, roleIDs []uuid.UUID) ([]string, error) {
+func (p *permissionRepository) GetPermissionsByRoleIDs(ctx context.Context, roleIDs []uuid.UUID, guard string) ([]string, error) {
 	// 1. Guard clause: Jika tidak ada role, langsung kembalikan array kosong
 	if len(roleIDs) == 0 {
 		return []string{}, nil
@@ -141,7 +337,10 @@ func (p *permissionRepository) GetPermissionsByRoleIDs(ctx context.Context, role
 		FROM permissions as p
 		JOIN role_has_permissions as rhp ON p.id = rhp.permission_id
 		WHERE rhp.role_id IN (%s)
+		AND p.guard_name = ?
+		AND rhp.condition_expression IS NULL
 	`, placeholderStr)
+	args = append(args, guard)
 
 	// 4. Eksekusi query dengan menyebarkan args (...)
 	rows, err := p.db.QueryContext(ctx, query, args...)
@@ -168,3 +367,252 @@ func (p *permissionRepository) GetPermissionsByRoleIDs(ctx context.Context, role
 }
 
 
+// ambil permission user yang hanya berlaku di dalam sebuah organisasi (tenant)
+func (p *permissionRepository) GetPermissionsByUserIDInOrganization(ctx context.Context, userID uuid.UUID, orgID uuid.UUID, guard string) ([]string, error) {
+	// sama seperti GetPermissionsByUserID, tapi sumbernya tabel organisasi
+	// member yang sudah dikeluarkan otomatis tidak punya baris lagi karna ON DELETE CASCADE.
+	// role-permission bersyarat tidak ikut, dievaluasi lewat GetConditionalGrantsByUserIDInOrganization
+	query := `SELECT p.name FROM permissions as p
+			  JOIN role_has_permissions as rhp ON p.id = rhp.permission_id
+			  JOIN organization_user_has_roles as ouhr ON rhp.role_id = ouhr.role_id
+			  WHERE ouhr.user_id = ? AND ouhr.organization_id = ? AND p.guard_name = ?
+			  AND rhp.condition_expression IS NULL
+
+			  UNION
+
+			  SELECT p.name FROM permissions as p
+			  JOIN organization_user_has_permissions as ouhp ON p.id = ouhp.permission_id
+			  WHERE ouhp.user_id = ? AND ouhp.organization_id = ? AND p.guard_name = ?
+			  `
+
+	userBinID, _ := userID.MarshalBinary()
+	orgBinID, _ := orgID.MarshalBinary()
+
+	rows, err := p.db.QueryContext(ctx, query,
+		userBinID,
+		orgBinID,
+		guard,
+		userBinID,
+		orgBinID,
+		guard,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []string
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// ambil semua jalur grant bersyarat (ABAC) untuk satu permission milik user
+func (p *permissionRepository) GetConditionalGrantsByUserID(ctx context.Context, userID uuid.UUID, permission string, guard string) ([]domain.PermissionGrant, error) {
+	// query pertama: lewat role, kondisinya bisa ada di assignment role (uhr) maupun di role-permission (rhp)
+	// query kedua: direct permission yang punya kondisi
+	query := `SELECT 'role', r.id, r.name, uhr.condition_expression, rhp.condition_expression
+			  FROM permissions as p
+			  JOIN role_has_permissions as rhp ON p.id = rhp.permission_id
+			  JOIN user_has_roles as uhr ON rhp.role_id = uhr.role_id
+			  JOIN roles as r ON r.id = uhr.role_id
+			  WHERE uhr.user_id = ? AND p.name = ? AND p.guard_name = ?
+			  AND (uhr.condition_expression IS NOT NULL OR rhp.condition_expression IS NOT NULL)
+			  AND ` + activeAssignment("uhr") + `
+
+			  UNION ALL
+
+			  SELECT 'direct', NULL, NULL, uhp.condition_expression, NULL
+			  FROM permissions as p
+			  JOIN user_has_permissions as uhp ON p.id = uhp.permission_id
+			  WHERE uhp.user_id = ? AND p.name = ? AND p.guard_name = ?
+			  AND uhp.condition_expression IS NOT NULL
+			  AND ` + activeAssignment("uhp")
+
+	userBinID, _ := userID.MarshalBinary()
+
+	rows, err := p.db.QueryContext(ctx, query,
+		userBinID, permission, guard,
+		userBinID, permission, guard,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanConditionalGrants(rows, permission)
+}
+
+// ambil role organisasi yang link role-permission-nya bersyarat, assignment organisasi sendiri tidak punya kondisi
+func (p *permissionRepository) GetConditionalGrantsByUserIDInOrganization(ctx context.Context, userID uuid.UUID, orgID uuid.UUID, permission string, guard string) ([]domain.PermissionGrant, error) {
+	query := `SELECT 'organization-role', r.id, r.name, NULL, rhp.condition_expression
+			  FROM permissions as p
+			  JOIN role_has_permissions as rhp ON p.id = rhp.permission_id
+			  JOIN organization_user_has_roles as ouhr ON rhp.role_id = ouhr.role_id
+			  JOIN roles as r ON r.id = ouhr.role_id
+			  WHERE ouhr.user_id = ? AND ouhr.organization_id = ? AND p.name = ? AND p.guard_name = ?
+			  AND rhp.condition_expression IS NOT NULL`
+
+	userBinID, _ := userID.MarshalBinary()
+	orgBinID, _ := orgID.MarshalBinary()
+
+	rows, err := p.db.QueryContext(ctx, query, userBinID, orgBinID, permission, guard)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := scanConditionalGrants(rows, permission)
+	if err != nil {
+		return nil, err
+	}
+	for i := range grants {
+		grants[i].OrganizationID = &orgID
+	}
+
+	return grants, nil
+}
+
+// kolom: source, role id, role name, kondisi assignment, kondisi role-permission
+func scanConditionalGrants(rows *sql.Rows, permission string) ([]domain.PermissionGrant, error) {
+	defer rows.Close()
+
+	var grants []domain.PermissionGrant
+	for rows.Next() {
+		grant := domain.PermissionGrant{Permission: permission}
+		var roleBinID []byte
+		var roleName, firstCondition, secondCondition sql.NullString
+
+		err := rows.Scan(
+			&grant.Source,
+			&roleBinID,
+			&roleName,
+			&firstCondition,
+			&secondCondition,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if roleBinID != nil {
+			roleID, _ := uuid.FromBytes(roleBinID)
+			grant.RoleID = &roleID
+		}
+		grant.RoleName = roleName.String
+
+		for _, condition := range []sql.NullString{firstCondition, secondCondition} {
+			if condition.Valid {
+				grant.Conditions = append(grant.Conditions, condition.String)
+			}
+		}
+
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return grants, nil
+}
+
+// ambil semua jalur grant satu permission milik user tanpa filter guard, kondisi maupun masa berlaku
+func (p *permissionRepository) FindGrantPathsByUserID(ctx context.Context, userID uuid.UUID, permission string) ([]domain.PermissionGrant, error) {
+	query := `SELECT 'role', r.id, r.name, p.guard_name, NULL, uhr.condition_expression, rhp.condition_expression, uhr.starts_at, uhr.expires_at
+			  FROM permissions as p
+			  JOIN role_has_permissions as rhp ON p.id = rhp.permission_id
+			  JOIN user_has_roles as uhr ON rhp.role_id = uhr.role_id
+			  JOIN roles as r ON r.id = uhr.role_id
+			  WHERE uhr.user_id = ? AND p.name = ?
+
+			  UNION ALL
+
+			  SELECT 'direct', NULL, NULL, p.guard_name, NULL, uhp.condition_expression, NULL, uhp.starts_at, uhp.expires_at
+			  FROM permissions as p
+			  JOIN user_has_permissions as uhp ON p.id = uhp.permission_id
+			  WHERE uhp.user_id = ? AND p.name = ?
+
+			  UNION ALL
+
+			  SELECT 'organization-role', r.id, r.name, p.guard_name, ouhr.organization_id, NULL, rhp.condition_expression, NULL, NULL
+			  FROM permissions as p
+			  JOIN role_has_permissions as rhp ON p.id = rhp.permission_id
+			  JOIN organization_user_has_roles as ouhr ON rhp.role_id = ouhr.role_id
+			  JOIN roles as r ON r.id = ouhr.role_id
+			  WHERE ouhr.user_id = ? AND p.name = ?
+
+			  UNION ALL
+
+			  SELECT 'organization-direct', NULL, NULL, p.guard_name, ouhp.organization_id, NULL, NULL, NULL, NULL
+			  FROM permissions as p
+			  JOIN organization_user_has_permissions as ouhp ON p.id = ouhp.permission_id
+			  WHERE ouhp.user_id = ? AND p.name = ?`
+
+	userBinID, _ := userID.MarshalBinary()
+
+	rows, err := p.db.QueryContext(ctx, query,
+		userBinID, permission,
+		userBinID, permission,
+		userBinID, permission,
+		userBinID, permission,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []domain.PermissionGrant{}
+	for rows.Next() {
+		grant := domain.PermissionGrant{Permission: permission}
+		var roleBinID, orgBinID []byte
+		var roleName, firstCondition, secondCondition sql.NullString
+		var startsAt, expiresAt sql.NullTime
+
+		err := rows.Scan(
+			&grant.Source,
+			&roleBinID,
+			&roleName,
+			&grant.Guard,
+			&orgBinID,
+			&firstCondition,
+			&secondCondition,
+			&startsAt,
+			&expiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if roleBinID != nil {
+			roleID, _ := uuid.FromBytes(roleBinID)
+			grant.RoleID = &roleID
+		}
+		if orgBinID != nil {
+			orgID, _ := uuid.FromBytes(orgBinID)
+			grant.OrganizationID = &orgID
+		}
+		grant.RoleName = roleName.String
+		grant.StartsAt = nullTimePtr(startsAt)
+		grant.ExpiresAt = nullTimePtr(expiresAt)
+
+		for _, condition := range []sql.NullString{firstCondition, secondCondition} {
+			if condition.Valid {
+				grant.Conditions = append(grant.Conditions, condition.String)
+			}
+		}
+
+		grants = append(grants, grant)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return grants, nil
+}
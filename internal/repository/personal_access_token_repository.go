@@ -23,16 +23,24 @@ func NewPersonalAccessTokenRepository(db *sql.DB) domain.PersonalAccessTokenRepo
 
 func (repo *tokenRepository) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
     
-    query := `INSERT INTO personal_access_tokens (id, token_hash, user_id, token_name, last_used_at, expires_at, created_at)
-              VALUES (?, ?, ?, ?, ?, ?, ?)`
+    query := `INSERT INTO personal_access_tokens (id, token_hash, user_id, organization_id, guard_name, token_name, last_used_at, expires_at, created_at)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
     
     idUserBytes, _ := token.UserID.MarshalBinary()
     idTokenBytes, _ := token.ID.MarshalBinary()
 
+    // organization_id boleh NULL (token global)
+    var idOrgBytes []byte
+    if token.OrganizationID != nil {
+        idOrgBytes, _ = token.OrganizationID.MarshalBinary()
+    }
+
     _, err := repo.db.ExecContext(ctx, query, 
         idTokenBytes,
         token.TokenHash,
         idUserBytes,
+        idOrgBytes,
+        token.Guard,
         token.TokenName,
         token.LastUsedAt,
         token.ExpiresAt,
@@ -44,12 +52,13 @@ func (repo *tokenRepository) Create(ctx context.Context, token *domain.PersonalA
 
 func (repo *tokenRepository) FindByToken(ctx context.Context, token string) (*domain.PersonalAccessToken, error) {
     
-    query := `SELECT id, token_hash, user_id, token_name, last_used_at, expires_at, created_at
+    query := `SELECT id, token_hash, user_id, organization_id, guard_name, token_name, last_used_at, expires_at, created_at
               FROM personal_access_tokens WHERE token_hash = ?`
     
     t := &domain.PersonalAccessToken{}
     var idBin []byte 
     var userBin []byte
+    var orgBin []byte
     
     // Buat penampung sementara untuk kolom yang bisa NULL
     var lastUsedAt sql.NullTime
@@ -59,6 +68,8 @@ func (repo *tokenRepository) FindByToken(ctx context.Context, token string) (*do
         &idBin,
         &t.TokenHash,
         &userBin,
+        &orgBin,
+        &t.Guard,
         &t.TokenName,
         &lastUsedAt,
         &expiresAt, 
@@ -74,6 +85,10 @@ func (repo *tokenRepository) FindByToken(ctx context.Context, token string) (*do
     // Convert byte ke UUID
     t.ID, _ = uuid.FromBytes(idBin)
     t.UserID, _ = uuid.FromBytes(userBin)
+    if orgBin != nil {
+        orgID, _ := uuid.FromBytes(orgBin)
+        t.OrganizationID = &orgID
+    }
 
     // Pindahkan data dari sql.NullTime ke pointer struct jika datanya valid (tidak NULL)
     if lastUsedAt.Valid {
@@ -110,6 +125,15 @@ func (repo*tokenRepository) Delete(ctx context.Context, token string) error {
     return err
 }
 
+func (repo *tokenRepository) DeleteOthersByUserID(ctx context.Context, userID uuid.UUID, keepToken string) error {
+    query := `DELETE FROM personal_access_tokens WHERE user_id = ? AND token_hash <> ?`
+
+    userBin, _ := userID.MarshalBinary()
+    _, err := repo.db.ExecContext(ctx, query, userBin, keepToken)
+
+    return err
+}
+
 func (repo *tokenRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
     query := `DELETE FROM personal_access_tokens WHERE user_id = ?`
     
@@ -117,4 +141,44 @@ func (repo *tokenRepository) DeleteByUserID(ctx context.Context, userID uuid.UUI
     _, err := repo.db.ExecContext(ctx, query, userBin)
 
     return err
-}
\ No newline at end of file
+}
+func (repo *tokenRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]domain.PersonalAccessToken, error) {
+
+    query := `SELECT id, user_id, organization_id, guard_name, token_name, last_used_at, expires_at, created_at
+              FROM personal_access_tokens WHERE user_id = ? ORDER BY created_at DESC`
+
+    userBin, _ := userID.MarshalBinary()
+    rows, err := repo.db.QueryContext(ctx, query, userBin)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    tokens := []domain.PersonalAccessToken{}
+    for rows.Next() {
+        var t domain.PersonalAccessToken
+        var idBin, userIDBin, orgBin []byte
+        var lastUsedAt, expiresAt sql.NullTime
+
+        err := rows.Scan(&idBin, &userIDBin, &orgBin, &t.Guard, &t.TokenName, &lastUsedAt, &expiresAt, &t.CreatedAt)
+        if err != nil {
+            return nil, err
+        }
+
+        t.ID, _ = uuid.FromBytes(idBin)
+        t.UserID, _ = uuid.FromBytes(userIDBin)
+        if orgBin != nil {
+            orgID, _ := uuid.FromBytes(orgBin)
+            t.OrganizationID = &orgID
+        }
+        if lastUsedAt.Valid {
+            t.LastUsedAt = &lastUsedAt.Time
+        }
+        if expiresAt.Valid {
+            t.ExpiresAt = &expiresAt.Time
+        }
+        tokens = append(tokens, t)
+    }
+
+    return tokens, rows.Err()
+}
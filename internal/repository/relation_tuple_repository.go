@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-auth/internal/domain"
+	"strings"
+)
+
+type relationTupleRepository struct {
+	db DBTX
+}
+
+func NewRelationTupleRepository(db *sql.DB) domain.RelationTupleRepository {
+	return &relationTupleRepository{
+		db: db,
+	}
+}
+
+func (repo *relationTupleRepository) Write(ctx context.Context, t *domain.RelationTuple) error {
+
+	// INSERT IGNORE agar menulis tuple yang sama bersifat idempotent
+	query := `INSERT IGNORE INTO relation_tuples (namespace, object_id, relation, subject_namespace, subject_id, subject_relation)
+			  VALUES (?, ?, ?, ?, ?, ?)`
+
+	_, err := repo.db.ExecContext(ctx, query,
+		t.Namespace,
+		t.ObjectID,
+		t.Relation,
+		t.Subject.Namespace,
+		t.Subject.ID,
+		t.Subject.Relation,
+	)
+
+	return err
+}
+
+func (repo *relationTupleRepository) Delete(ctx context.Context, t *domain.RelationTuple) error {
+
+	query := `DELETE FROM relation_tuples
+			  WHERE namespace = ? AND object_id = ? AND relation = ?
+			  AND subject_namespace = ? AND subject_id = ? AND subject_relation = ?`
+
+	res, err := repo.db.ExecContext(ctx, query,
+		t.Namespace,
+		t.ObjectID,
+		t.Relation,
+		t.Subject.Namespace,
+		t.Subject.ID,
+		t.Subject.Relation,
+	)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("no relation tuple found to delete")
+		}
+	}
+
+	return err
+}
+
+func (repo *relationTupleRepository) Read(ctx context.Context, filter domain.RelationTupleFilter) ([]domain.RelationTuple, error) {
+
+	query := `SELECT namespace, object_id, relation, subject_namespace, subject_id, subject_relation, created_at
+			  FROM relation_tuples`
+
+	// bangun WHERE secara dinamis, hanya dari field filter yang diisi
+	conditions := make([]string, 0, 6)
+	args := make([]any, 0, 6)
+
+	addCondition := func(column string, value string) {
+		if value != "" {
+			conditions = append(conditions, column+" = ?")
+			args = append(args, value)
+		}
+	}
+	addCondition("namespace", filter.Namespace)
+	addCondition("object_id", filter.ObjectID)
+	addCondition("relation", filter.Relation)
+	addCondition("subject_namespace", filter.SubjectNamespace)
+	addCondition("subject_id", filter.SubjectID)
+
+	// subject_relation boleh string kosong, jadi pakai pointer untuk membedakan "tidak difilter"
+	if filter.SubjectRelation != nil {
+		conditions = append(conditions, "subject_relation = ?")
+		args = append(args, *filter.SubjectRelation)
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tuples []domain.RelationTuple
+	for rows.Next() {
+		var t domain.RelationTuple
+		err := rows.Scan(
+			&t.Namespace,
+			&t.ObjectID,
+			&t.Relation,
+			&t.Subject.Namespace,
+			&t.Subject.ID,
+			&t.Subject.Relation,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tuples, nil
+}
+
+func (repo *relationTupleRepository) FindObjectIDs(ctx context.Context, namespace string) ([]string, error) {
+
+	query := `SELECT DISTINCT object_id FROM relation_tuples WHERE namespace = ?`
+
+	rows, err := repo.db.QueryContext(ctx, query, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
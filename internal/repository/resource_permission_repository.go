@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-auth/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type resourcePermissionRepository struct {
+	db DBTX
+}
+
+func NewResourcePermissionRepository(db *sql.DB) domain.ResourcePermissionRepository {
+	return &resourcePermissionRepository{
+		db: db,
+	}
+}
+
+func (repo *resourcePermissionRepository) Grant(ctx context.Context, rp *domain.ResourcePermission) error {
+
+	// INSERT IGNORE agar grant yang sama tidak error kalau dikirim ulang
+	var query string
+	switch rp.SubjectType {
+	case domain.SubjectTypeUser:
+		query = `INSERT IGNORE INTO user_has_resource_permissions (user_id, permission_id, resource_type, resource_id)
+				 VALUES (?, ?, ?, ?)`
+	case domain.SubjectTypeRole:
+		query = `INSERT IGNORE INTO role_has_resource_permissions (role_id, permission_id, resource_type, resource_id)
+				 VALUES (?, ?, ?, ?)`
+	default:
+		return errors.New("invalid subject type")
+	}
+
+	subjectBinID, _ := rp.SubjectID.MarshalBinary()
+	permBinID, _ := rp.PermissionID.MarshalBinary()
+
+	_, err := repo.db.ExecContext(ctx, query,
+		subjectBinID,
+		permBinID,
+		rp.ResourceType,
+		rp.ResourceID,
+	)
+
+	return err
+}
+
+func (repo *resourcePermissionRepository) Revoke(ctx context.Context, rp *domain.ResourcePermission) error {
+
+	var query string
+	switch rp.SubjectType {
+	case domain.SubjectTypeUser:
+		query = `DELETE FROM user_has_resource_permissions
+				 WHERE user_id = ? AND permission_id = ? AND resource_type = ? AND resource_id = ?`
+	case domain.SubjectTypeRole:
+		query = `DELETE FROM role_has_resource_permissions
+				 WHERE role_id = ? AND permission_id = ? AND resource_type = ? AND resource_id = ?`
+	default:
+		return errors.New("invalid subject type")
+	}
+
+	subjectBinID, _ := rp.SubjectID.MarshalBinary()
+	permBinID, _ := rp.PermissionID.MarshalBinary()
+
+	res, err := repo.db.ExecContext(ctx, query,
+		subjectBinID,
+		permBinID,
+		rp.ResourceType,
+		rp.ResourceID,
+	)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("no resource permission found to revoke")
+		}
+	}
+
+	return err
+}
+
+// ambil semua grant (user & role) pada satu resource
+func (repo *resourcePermissionRepository) FindByResource(ctx context.Context, resourceType string, resourceID string) ([]domain.ResourcePermission, error) {
+
+	query := `SELECT 'user', u.id, u.username, p.id, p.name, uhrp.resource_type, uhrp.resource_id, uhrp.created_at
+			  FROM user_has_resource_permissions as uhrp
+			  JOIN users as u ON u.id = uhrp.user_id
+			  JOIN permissions as p ON p.id = uhrp.permission_id
+			  WHERE uhrp.resource_type = ? AND uhrp.resource_id = ?
+
+			  UNION ALL
+
+			  SELECT 'role', r.id, r.name, p.id, p.name, rhrp.resource_type, rhrp.resource_id, rhrp.created_at
+			  FROM role_has_resource_permissions as rhrp
+			  JOIN roles as r ON r.id = rhrp.role_id
+			  JOIN permissions as p ON p.id = rhrp.permission_id
+			  WHERE rhrp.resource_type = ? AND rhrp.resource_id = ?`
+
+	rows, err := repo.db.QueryContext(ctx, query,
+		resourceType,
+		resourceID,
+		resourceType,
+		resourceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanResourcePermissions(rows)
+}
+
+func (repo *resourcePermissionRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]domain.ResourcePermission, error) {
+
+	query := `SELECT 'user', u.id, u.username, p.id, p.name, uhrp.resource_type, uhrp.resource_id, uhrp.created_at
+			  FROM user_has_resource_permissions as uhrp
+			  JOIN users as u ON u.id = uhrp.user_id
+			  JOIN permissions as p ON p.id = uhrp.permission_id
+			  WHERE uhrp.user_id = ?
+			  ORDER BY uhrp.resource_type, uhrp.resource_id`
+
+	userBinID, _ := userID.MarshalBinary()
+
+	rows, err := repo.db.QueryContext(ctx, query, userBinID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanResourcePermissions(rows)
+}
+
+func scanResourcePermissions(rows *sql.Rows) ([]domain.ResourcePermission, error) {
+	defer rows.Close()
+
+	var grants []domain.ResourcePermission
+	for rows.Next() {
+		var grant domain.ResourcePermission
+		var subjectBinID, permBinID []byte
+
+		err := rows.Scan(
+			&grant.SubjectType,
+			&subjectBinID,
+			&grant.SubjectName,
+			&permBinID,
+			&grant.PermissionName,
+			&grant.ResourceType,
+			&grant.ResourceID,
+			&grant.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		grant.SubjectID, _ = uuid.FromBytes(subjectBinID)
+		grant.PermissionID, _ = uuid.FromBytes(permBinID)
+
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return grants, nil
+}
+
+func (repo *resourcePermissionRepository) HasResourcePermission(ctx context.Context, userID uuid.UUID, permission string, resourceType string, resourceID string, guard string) (bool, error) {
+
+	// query pertama: grant langsung ke user (direct)
+	// query kedua: grant ke role yang dimiliki user (indirect), assignment role bersyarat tidak ikut
+	// karena kondisinya dievaluasi terpisah dan tidak bisa dicek di sini
+	query := `SELECT EXISTS (
+				SELECT 1 FROM user_has_resource_permissions as uhrp
+				JOIN permissions as p ON p.id = uhrp.permission_id
+				WHERE uhrp.user_id = ? AND p.name = ? AND p.guard_name = ? AND uhrp.resource_type = ? AND uhrp.resource_id = ?
+
+				UNION ALL
+
+				SELECT 1 FROM role_has_resource_permissions as rhrp
+				JOIN permissions as p ON p.id = rhrp.permission_id
+				JOIN user_has_roles as uhr ON uhr.role_id = rhrp.role_id
+				WHERE uhr.user_id = ? AND p.name = ? AND p.guard_name = ? AND rhrp.resource_type = ? AND rhrp.resource_id = ?
+				AND uhr.condition_expression IS NULL
+				AND ` + activeAssignment("uhr") + `
+			  )`
+
+	userBinID, _ := userID.MarshalBinary()
+
+	var exists bool
+	err := repo.db.QueryRowContext(ctx, query,
+		userBinID, permission, guard, resourceType, resourceID,
+		userBinID, permission, guard, resourceType, resourceID,
+	).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (repo *resourcePermissionRepository) GetResourceIDs(ctx context.Context, userID uuid.UUID, permission string, resourceType string, guard string) ([]string, error) {
+
+	query := `SELECT uhrp.resource_id FROM user_has_resource_permissions as uhrp
+			  JOIN permissions as p ON p.id = uhrp.permission_id
+			  WHERE uhrp.user_id = ? AND p.name = ? AND p.guard_name = ? AND uhrp.resource_type = ?
+
+			  UNION
+
+			  SELECT rhrp.resource_id FROM role_has_resource_permissions as rhrp
+			  JOIN permissions as p ON p.id = rhrp.permission_id
+			  JOIN user_has_roles as uhr ON uhr.role_id = rhrp.role_id
+			  WHERE uhr.user_id = ? AND p.name = ? AND p.guard_name = ? AND rhrp.resource_type = ?
+			  AND uhr.condition_expression IS NULL
+			  AND ` + activeAssignment("uhr")
+
+	userBinID, _ := userID.MarshalBinary()
+
+	rows, err := repo.db.QueryContext(ctx, query,
+		userBinID, permission, guard, resourceType,
+		userBinID, permission, guard, resourceType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
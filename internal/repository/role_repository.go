@@ -28,8 +28,14 @@ func (repo *roleRepository) WithTx(tx *sql.Tx) domain.RoleRepository {
 
 func (repo *roleRepository) Create(ctx context.Context, role *domain.Role) error {
 	
-	query := `INSERT INTO roles (id, name, created_at, updated_at) 
-			  VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO roles (id, name, guard_name, template_key, organization_id, created_at, updated_at) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	// role global tidak punya organization_id
+	var orgBytes []byte
+	if role.OrganizationID != nil {
+		orgBytes, _ = role.OrganizationID.MarshalBinary()
+	}
 
 	// ubah uuid ke format mysql
 	idBytes, err := role.ID.MarshalBinary()
@@ -41,6 +47,9 @@ func (repo *roleRepository) Create(ctx context.Context, role *domain.Role) error
 	_, err = repo.db.ExecContext(ctx, query,
 		idBytes,
 		role.Name,
+		role.Guard,
+		nullString(role.TemplateKey),
+		orgBytes,
 		role.CreatedAt,
 		role.UpdatedAt,
 	)
@@ -57,11 +66,16 @@ func (repo *roleRepository) FindById(ctx context.Context, id uuid.UUID) (*domain
 	binID, _ := id.MarshalBinary()
 
 	// query pertama: ambil data role
-	queryRole := `SELECT id, name FROM roles WHERE id = ?`
-	var roleBinID []byte
+	queryRole := `SELECT id, name, guard_name, template_key, organization_id, version FROM roles WHERE id = ?`
+	var roleBinID, orgBinID []byte
+	var templateKey sql.NullString
 	err := repo.db.QueryRowContext(ctx, queryRole, binID).Scan(
 		&roleBinID,
 		&res.Name,
+		&res.Guard,
+		&templateKey,
+		&orgBinID,
+		&res.Version,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -70,9 +84,14 @@ func (repo *roleRepository) FindById(ctx context.Context, id uuid.UUID) (*domain
 		return nil, err
 	}
 	res.ID, _ = uuid.FromBytes(roleBinID)
+	res.TemplateKey = templateKey.String
+	if orgBinID != nil {
+		orgID, _ := uuid.FromBytes(orgBinID)
+		res.OrganizationID = &orgID
+	}
 
 	// query kedua: ambil permissions
-	queryPermission := `SELECT p.id, p.name FROM permissions as p
+	queryPermission := `SELECT p.id, p.name, p.guard_name, rhp.condition_expression FROM permissions as p
 						 JOIN role_has_permissions as rhp ON p.id = rhp.permission_id
 						 WHERE rhp.role_id = ?`
 	
@@ -85,13 +104,17 @@ func (repo *roleRepository) FindById(ctx context.Context, id uuid.UUID) (*domain
 	for rowsP.Next() {
 		var permission domain.Permission
 		var permissionBinId []byte
+		var condition sql.NullString
 		err := rowsP.Scan(
 			&permissionBinId,
 			&permission.Name,
+			&permission.Guard,
+			&condition,
 		)
 		if err != nil {
 			return nil, err
 		}
+		permission.Condition = condition.String
 		// konversi id ke uuid
 		permission.ID, _ = uuid.FromBytes(permissionBinId)
 
@@ -105,7 +128,7 @@ func (repo *roleRepository) FindById(ctx context.Context, id uuid.UUID) (*domain
 	// query ketiga: Ambil users
 	queryUser := `SELECT u.id, u.username, u.email FROM users as u
 				  JOIN user_has_roles as uhr ON u.id = uhr.user_id
-				  WHERE uhr.role_id = ?`
+				  WHERE uhr.role_id = ? AND ` + activeAssignment("uhr")
 	
 	rowsU, err := repo.db.QueryContext(ctx, queryUser, binID)
 	if err != nil {
@@ -139,21 +162,112 @@ func (repo *roleRepository) FindById(ctx context.Context, id uuid.UUID) (*domain
 
 func (repo *roleRepository) FindAll(ctx context.Context) ([]domain.Role, error) {
 	
-	query := `SELECT id, name, created_at, updated_at FROM roles`
+	query := `SELECT ` + roleColumns + ` FROM roles`
 	rows, err := repo.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	
+	return scanRoles(rows)
+}
+
+var roleListColumns = listColumns{
+	"name":       "name",
+	"guard":      "guard_name",
+	"created_at": "created_at",
+}
+
+func (repo *roleRepository) FindPage(ctx context.Context, spec domain.QuerySpec) ([]domain.Role, *domain.PageMeta, error) {
+	query, args, err := buildListQuery(`SELECT `+roleColumns+` FROM roles`, "id", roleListColumns, spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	roles, err := scanRoles(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta, count := newPageMeta(spec, len(roles), func(i int) (any, uuid.UUID) {
+		role := roles[i]
+		switch spec.Sort {
+		case "name":
+			return role.Name, role.ID
+		case "guard":
+			return role.Guard, role.ID
+		case "created_at":
+			return role.CreatedAt, role.ID
+		}
+		return nil, role.ID
+	})
+
+	roles = roles[:count]
+	if roles == nil {
+		roles = []domain.Role{}
+	}
+	return roles, meta, nil
+}
+
+// ambil beberapa role sekaligus, id yang tidak ada diabaikan
+func (repo *roleRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Role, error) {
+	if len(ids) == 0 {
+		return []domain.Role{}, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id[:]
+	}
+
+	query := `SELECT ` + roleColumns + ` FROM roles WHERE id IN (` + strings.Join(placeholders, ",") + `)`
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+// semua role yang dibuat dari template tertentu
+func (repo *roleRepository) FindByTemplateKey(ctx context.Context, templateKey string) ([]domain.Role, error) {
+
+	query := `SELECT ` + roleColumns + ` FROM roles WHERE template_key = ? ORDER BY name`
+	rows, err := repo.db.QueryContext(ctx, query, templateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+// kolom yang dibaca scanRoles, urutannya harus sama
+const roleColumns = `id, name, guard_name, template_key, organization_id, version, created_at, updated_at`
+
+func scanRoles(rows *sql.Rows) ([]domain.Role, error) {
 	var roles []domain.Role
 	for rows.Next() {
 		var role domain.Role
-		var binID []byte
+		var binID, orgBinID []byte
+		var templateKey sql.NullString
 
 		err := rows.Scan(
 			&binID,
 			&role.Name,
+			&role.Guard,
+			&templateKey,
+			&orgBinID,
+			&role.Version,
 			&role.CreatedAt,
 			&role.UpdatedAt,
 		)
@@ -165,25 +279,30 @@ func (repo *roleRepository) FindAll(ctx context.Context) ([]domain.Role, error)
 		if err != nil {
 			return nil, err
 		}
+		role.TemplateKey = templateKey.String
+		if orgBinID != nil {
+			orgID, _ := uuid.FromBytes(orgBinID)
+			role.OrganizationID = &orgID
+		}
 
 		roles = append(roles, role)
 	}
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
 	return roles, nil
 }
 
-func (repo *roleRepository) GetRoleByUserID(ctx context.Context, userID uuid.UUID) ([]string, error) {
+func (repo *roleRepository) GetRoleByUserID(ctx context.Context, userID uuid.UUID, guard string) ([]string, error) {
 	
 	userBinID, _ := userID.MarshalBinary()
 	
 	queryRole := `SELECT r.name FROM roles as r
 				  JOIN user_has_roles as uhr ON r.id = uhr.role_id
-				  WHERE uhr.user_id = ?`
+				  WHERE uhr.user_id = ? AND r.guard_name = ? AND ` + activeAssignment("uhr")
 	
-	rows, err := repo.db.QueryContext(ctx, queryRole, userBinID)
+	rows, err := repo.db.QueryContext(ctx, queryRole, userBinID, guard)
 	if err != nil {
 		return nil, err
 	}
@@ -206,7 +325,7 @@ func (repo *roleRepository) GetRoleByUserID(ctx context.Context, userID uuid.UUI
 
 func (repo *roleRepository) Update(ctx context.Context, role *domain.Role) error {
 	
-	query := `UPDATE roles SET name = ?, updated_at = ? WHERE id = ?`
+	query := `UPDATE roles SET name = ?, guard_name = ?, updated_at = ? WHERE id = ?`
 
 	// ubah uuid ke format mysql
 	idBytes, err := role.ID.MarshalBinary()
@@ -216,6 +335,7 @@ func (repo *roleRepository) Update(ctx context.Context, role *domain.Role) error
 
 	res, err := repo.db.ExecContext(ctx, query,
 		role.Name,
+		role.Guard,
 		role.UpdatedAt,
 		idBytes,
 	)
@@ -229,6 +349,18 @@ func (repo *roleRepository) Update(ctx context.Context, role *domain.Role) error
 	return err
 }
 
+func (repo *roleRepository) IsAssigned(ctx context.Context, id uuid.UUID) (bool, error) {
+
+	query := `SELECT EXISTS (SELECT 1 FROM user_has_roles WHERE role_id = ?)
+			  OR EXISTS (SELECT 1 FROM organization_user_has_roles WHERE role_id = ?)`
+
+	idBytes, _ := id.MarshalBinary()
+
+	var assigned bool
+	err := repo.db.QueryRowContext(ctx, query, idBytes, idBytes).Scan(&assigned)
+	return assigned, err
+}
+
 func (repo *roleRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	
 	query := `DELETE from roles WHERE id = ?`
@@ -250,25 +382,25 @@ func (repo *roleRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-func (repo *roleRepository) AssignPermission(ctx context.Context, roleID uuid.UUID, permIDs []uuid.UUID) error {
+func (repo *roleRepository) AssignPermission(ctx context.Context, roleID uuid.UUID, permIDs []uuid.UUID, opts domain.AssignmentOptions) error {
 	if len(permIDs) == 0 {
 		return nil
 	}
 
-	query := `INSERT INTO role_has_permissions (role_id, permission_id) VALUES `
+	query := `INSERT INTO role_has_permissions (role_id, permission_id, condition_expression) VALUES `
 
 	// pre-allocation
-	values := make([]interface{}, 0, len(permIDs) * 2) // *2 karena 1 role, 1 perm
+	values := make([]interface{}, 0, len(permIDs) * 3) // *3 karena 1 role, 1 perm, 1 kondisi
 	placeHolders := make([]string, 0, len(permIDs))
 
 	roleBin, _ := roleID.MarshalBinary()
 
-	// bangun string (?, ?) sebanyak jumlah permIDs
+	// bangun string (?, ?, ?) sebanyak jumlah permIDs
 	for _, pID := range permIDs {
-		placeHolders = append(placeHolders, "(?, ?)")
+		placeHolders = append(placeHolders, "(?, ?, ?)")
 
 		permBin, _ := pID.MarshalBinary()
-		values = append(values, roleBin, permBin)
+		values = append(values, roleBin, permBin, conditionValue(opts.Conditions, pID))
 	}
 
 	// gabungkan query dasar dengan semua placeholder
@@ -280,6 +412,39 @@ func (repo *roleRepository) AssignPermission(ctx context.Context, roleID uuid.UU
 	return err
 }
 
+// attach tanpa menghapus permission lain, kondisi permission yang sudah terpasang ditimpa
+func (repo *roleRepository) AttachPermissions(ctx context.Context, roleID uuid.UUID, permIDs []uuid.UUID, opts domain.AssignmentOptions) error {
+	if len(permIDs) == 0 {
+		return nil
+	}
+
+	values := make([]interface{}, 0, len(permIDs) * 3)
+	placeHolders := make([]string, 0, len(permIDs))
+
+	roleBin, _ := roleID.MarshalBinary()
+	for _, pID := range permIDs {
+		placeHolders = append(placeHolders, "(?, ?, ?)")
+
+		permBin, _ := pID.MarshalBinary()
+		values = append(values, roleBin, permBin, conditionValue(opts.Conditions, pID))
+	}
+
+	query := `INSERT INTO role_has_permissions (role_id, permission_id, condition_expression) VALUES ` +
+		strings.Join(placeHolders, ", ") +
+		` ON DUPLICATE KEY UPDATE condition_expression = VALUES(condition_expression)`
+
+	_, err := repo.db.ExecContext(ctx, query, values...)
+	return err
+}
+
+func (repo *roleRepository) DetachPermissions(ctx context.Context, roleID uuid.UUID, permIDs []uuid.UUID) error {
+	return deleteLinks(ctx, repo.db, "role_has_permissions", "role_id", roleID, "permission_id", permIDs)
+}
+
+func (repo *roleRepository) BumpVersion(ctx context.Context, id uuid.UUID, expected int) error {
+	return bumpVersion(ctx, repo.db, "roles", id, expected)
+}
+
 func (repo *roleRepository) RemoveAllPermissions(ctx context.Context, roleID uuid.UUID) error {
 	query := `DELETE FROM role_has_permissions WHERE role_id = ?`
 
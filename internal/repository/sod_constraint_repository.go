@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"golang-auth/internal/domain"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type sodConstraintRepository struct {
+	db DBTX
+}
+
+func NewSoDConstraintRepository(db *sql.DB) domain.SoDConstraintRepository {
+	return &sodConstraintRepository{
+		db: db,
+	}
+}
+
+func (repo *sodConstraintRepository) WithTx(tx *sql.Tx) domain.SoDConstraintRepository {
+	return &sodConstraintRepository{
+		db: tx,
+	}
+}
+
+// kolom constraint beserta nama role / permission di kedua sisinya
+const sodConstraintColumns = `c.id, c.name, c.constraint_type,
+				COALESCE(c.first_role_id, c.first_permission_id), COALESCE(fr.name, fp.name, ''),
+				COALESCE(c.second_role_id, c.second_permission_id), COALESCE(sr.name, sp.name, ''),
+				c.description, c.created_at`
+
+const sodConstraintJoins = `LEFT JOIN roles as fr ON fr.id = c.first_role_id
+			  LEFT JOIN roles as sr ON sr.id = c.second_role_id
+			  LEFT JOIN permissions as fp ON fp.id = c.first_permission_id
+			  LEFT JOIN permissions as sp ON sp.id = c.second_permission_id`
+
+func (repo *sodConstraintRepository) Create(ctx context.Context, c *domain.SoDConstraint) error {
+
+	query := `INSERT INTO sod_constraints
+			  (id, name, constraint_type, first_role_id, second_role_id, first_permission_id, second_permission_id, description, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// pasangan disimpan berurutan, supaya (A, B) dan (B, A) dianggap constraint yang sama
+	firstBin, _ := c.FirstID.MarshalBinary()
+	secondBin, _ := c.SecondID.MarshalBinary()
+	if bytes.Compare(firstBin, secondBin) > 0 {
+		firstBin, secondBin = secondBin, firstBin
+		c.FirstID, c.SecondID = c.SecondID, c.FirstID
+		c.FirstName, c.SecondName = c.SecondName, c.FirstName
+	}
+
+	var firstRole, secondRole, firstPermission, secondPermission any
+	if c.Type == domain.SoDTypeRole {
+		firstRole, secondRole = firstBin, secondBin
+	} else {
+		firstPermission, secondPermission = firstBin, secondBin
+	}
+
+	idBin, _ := c.ID.MarshalBinary()
+
+	_, err := repo.db.ExecContext(ctx, query,
+		idBin,
+		c.Name,
+		c.Type,
+		firstRole,
+		secondRole,
+		firstPermission,
+		secondPermission,
+		nullString(c.Description),
+		c.CreatedAt,
+	)
+
+	return err
+}
+
+func (repo *sodConstraintRepository) Delete(ctx context.Context, id uuid.UUID) error {
+
+	query := `DELETE FROM sod_constraints WHERE id = ?`
+
+	idBin, _ := id.MarshalBinary()
+
+	res, err := repo.db.ExecContext(ctx, query, idBin)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("no sod constraint found to delete")
+		}
+	}
+
+	return err
+}
+
+func (repo *sodConstraintRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.SoDConstraint, error) {
+
+	query := `SELECT ` + sodConstraintColumns + ` FROM sod_constraints as c
+			  ` + sodConstraintJoins + `
+			  WHERE c.id = ?`
+
+	idBin, _ := id.MarshalBinary()
+
+	c := &domain.SoDConstraint{}
+	err := scanSoDConstraint(repo.db.QueryRowContext(ctx, query, idBin), c)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("sod constraint not found")
+		}
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (repo *sodConstraintRepository) FindAll(ctx context.Context) ([]domain.SoDConstraint, error) {
+
+	query := `SELECT ` + sodConstraintColumns + ` FROM sod_constraints as c
+			  ` + sodConstraintJoins + `
+			  ORDER BY c.name`
+
+	rows, err := repo.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	constraints := []domain.SoDConstraint{}
+	for rows.Next() {
+		var c domain.SoDConstraint
+		if err := scanSoDConstraint(rows, &c); err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return constraints, nil
+}
+
+func (repo *sodConstraintRepository) FindConflicts(ctx context.Context, constraintType string, ids []uuid.UUID) ([]domain.SoDConstraint, error) {
+	if len(ids) < 2 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	idArgs := make([]any, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		idArgs[i] = id[:]
+	}
+	placeholderStr := strings.Join(placeholders, ",")
+
+	column := "role_id"
+	if constraintType == domain.SoDTypePermission {
+		column = "permission_id"
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM sod_constraints as c
+			  %s
+			  WHERE c.constraint_type = ? AND c.first_%s IN (%s) AND c.second_%s IN (%s)`,
+		sodConstraintColumns, sodConstraintJoins, column, placeholderStr, column, placeholderStr)
+
+	args := append([]any{constraintType}, idArgs...)
+	args = append(args, idArgs...)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var constraints []domain.SoDConstraint
+	for rows.Next() {
+		var c domain.SoDConstraint
+		if err := scanSoDConstraint(rows, &c); err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return constraints, nil
+}
+
+func (repo *sodConstraintRepository) FindViolations(ctx context.Context, userIDs []uuid.UUID) ([]domain.SoDViolation, error) {
+	if len(userIDs) == 0 {
+		return repo.findViolations(ctx, "", nil)
+	}
+
+	placeholders := make([]string, len(userIDs))
+	args := make([]any, len(userIDs))
+	for i, id := range userIDs {
+		placeholders[i] = "?"
+		args[i] = id[:]
+	}
+
+	return repo.findViolations(ctx, "AND a.user_id IN ("+strings.Join(placeholders, ",")+")", args)
+}
+
+func (repo *sodConstraintRepository) FindViolationsByRoleID(ctx context.Context, roleID uuid.UUID) ([]domain.SoDViolation, error) {
+	roleBin, _ := roleID.MarshalBinary()
+	return repo.findViolations(ctx, `AND a.user_id IN (
+		SELECT user_id FROM user_has_roles WHERE role_id = ?
+		UNION
+		SELECT user_id FROM organization_user_has_roles WHERE role_id = ?
+	)`, []any{roleBin, roleBin})
+}
+
+// findViolations mencari user yang memegang kedua sisi constraint.
+// semua assignment ikut dihitung (termasuk yang bersyarat / sementara), karna SoD bersifat statis.
+// assignment organisasi dihitung bersama assignment global, karena di route organisasi keduanya berlaku sekaligus.
+// dua sisi yang berasal dari organisasi berbeda tidak dianggap melanggar
+func (repo *sodConstraintRepository) findViolations(ctx context.Context, userFilter string, filterArgs []any) ([]domain.SoDViolation, error) {
+
+	// query pertama: constraint antar role
+	// query kedua: constraint antar permission, dari direct permission maupun lewat role
+	// organization_id NULL berarti assignment global
+	query := `WITH effective_roles AS (
+				SELECT user_id, role_id, CAST(NULL AS BINARY(16)) as organization_id FROM user_has_roles
+				UNION
+				SELECT user_id, role_id, organization_id FROM organization_user_has_roles
+			  ),
+			  effective_permissions AS (
+				SELECT user_id, permission_id, CAST(NULL AS BINARY(16)) as organization_id FROM user_has_permissions
+				UNION
+				SELECT uhr.user_id, rhp.permission_id, NULL FROM user_has_roles as uhr
+				JOIN role_has_permissions as rhp ON rhp.role_id = uhr.role_id
+				UNION
+				SELECT user_id, permission_id, organization_id FROM organization_user_has_permissions
+				UNION
+				SELECT ouhr.user_id, rhp.permission_id, ouhr.organization_id FROM organization_user_has_roles as ouhr
+				JOIN role_has_permissions as rhp ON rhp.role_id = ouhr.role_id
+			  )
+
+			  SELECT u.id, u.username, ` + sodConstraintColumns + ` FROM sod_constraints as c
+			  ` + sodConstraintJoins + `
+			  JOIN effective_roles as a ON a.role_id = c.first_role_id
+			  JOIN effective_roles as b ON b.role_id = c.second_role_id AND b.user_id = a.user_id
+			  AND ` + sameSoDScope("a", "b") + `
+			  JOIN users as u ON u.id = a.user_id
+			  WHERE c.constraint_type = 'role' ` + userFilter + `
+
+			  UNION
+
+			  SELECT u.id, u.username, ` + sodConstraintColumns + ` FROM sod_constraints as c
+			  ` + sodConstraintJoins + `
+			  JOIN effective_permissions as a ON a.permission_id = c.first_permission_id
+			  JOIN effective_permissions as b ON b.permission_id = c.second_permission_id AND b.user_id = a.user_id
+			  AND ` + sameSoDScope("a", "b") + `
+			  JOIN users as u ON u.id = a.user_id
+			  WHERE c.constraint_type = 'permission' ` + userFilter + `
+
+			  ORDER BY username`
+
+	args := append(append([]any{}, filterArgs...), filterArgs...)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	violations := []domain.SoDViolation{}
+	for rows.Next() {
+		var violation domain.SoDViolation
+		var userBin []byte
+
+		err := scanSoDConstraint(rows, &violation.Constraint, &userBin, &violation.Username)
+		if err != nil {
+			return nil, err
+		}
+		violation.UserID, _ = uuid.FromBytes(userBin)
+
+		violations = append(violations, violation)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return violations, nil
+}
+
+// scanSoDConstraint membaca kolom sodConstraintColumns, prefix adalah kolom tambahan sebelumnya
+func scanSoDConstraint(row scanner, c *domain.SoDConstraint, prefix ...any) error {
+	var idBin, firstBin, secondBin []byte
+	var description sql.NullString
+
+	dest := append(prefix,
+		&idBin,
+		&c.Name,
+		&c.Type,
+		&firstBin,
+		&c.FirstName,
+		&secondBin,
+		&c.SecondName,
+		&description,
+		&c.CreatedAt,
+	)
+
+	err := row.Scan(dest...)
+	if err != nil {
+		return err
+	}
+
+	c.ID, _ = uuid.FromBytes(idBin)
+	c.FirstID, _ = uuid.FromBytes(firstBin)
+	c.SecondID, _ = uuid.FromBytes(secondBin)
+	c.Description = description.String
+
+	return nil
+}
+
+// dua assignment berlaku bersamaan kalau salah satunya global atau keduanya di organisasi yang sama
+func sameSoDScope(a string, b string) string {
+	return fmt.Sprintf("(%[1]s.organization_id IS NULL OR %[2]s.organization_id IS NULL OR %[1]s.organization_id = %[2]s.organization_id)", a, b)
+}
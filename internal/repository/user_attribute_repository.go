@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-auth/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type userAttributeRepository struct {
+	db DBTX
+}
+
+func NewUserAttributeRepository(db *sql.DB) domain.UserAttributeRepository {
+	return &userAttributeRepository{
+		db: db,
+	}
+}
+
+func (repo *userAttributeRepository) WithTx(tx *sql.Tx) domain.UserAttributeRepository {
+	return &userAttributeRepository{
+		db: tx,
+	}
+}
+
+const attributeDefinitionSelect = `SELECT id, attribute_key, label, type, is_required, is_unique, user_editable, rule, created_at, updated_at
+			  FROM user_attribute_definitions`
+
+func (repo *userAttributeRepository) CreateDefinition(ctx context.Context, definition *domain.UserAttributeDefinition) error {
+
+	query := `INSERT INTO user_attribute_definitions
+			  (id, attribute_key, label, type, is_required, is_unique, user_editable, rule, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	idBin, _ := definition.ID.MarshalBinary()
+
+	_, err := repo.db.ExecContext(ctx, query,
+		idBin,
+		definition.Key,
+		definition.Label,
+		definition.Type,
+		definition.Required,
+		definition.Unique,
+		definition.UserEditable,
+		nullString(definition.Rule),
+		definition.CreatedAt,
+		definition.UpdatedAt,
+	)
+
+	return err
+}
+
+func (repo *userAttributeRepository) UpdateDefinition(ctx context.Context, definition *domain.UserAttributeDefinition) error {
+
+	query := `UPDATE user_attribute_definitions
+			  SET label = ?, is_required = ?, is_unique = ?, user_editable = ?, rule = ?, updated_at = ?
+			  WHERE id = ?`
+
+	idBin, _ := definition.ID.MarshalBinary()
+
+	res, err := repo.db.ExecContext(ctx, query,
+		definition.Label,
+		definition.Required,
+		definition.Unique,
+		definition.UserEditable,
+		nullString(definition.Rule),
+		definition.UpdatedAt,
+		idBin,
+	)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("attribute definition not found")
+		}
+	}
+
+	return err
+}
+
+func (repo *userAttributeRepository) DeleteDefinition(ctx context.Context, id uuid.UUID) error {
+
+	query := `DELETE FROM user_attribute_definitions WHERE id = ?`
+
+	idBin, _ := id.MarshalBinary()
+
+	res, err := repo.db.ExecContext(ctx, query, idBin)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("attribute definition not found")
+		}
+	}
+
+	return err
+}
+
+func (repo *userAttributeRepository) FindDefinitionByID(ctx context.Context, id uuid.UUID) (*domain.UserAttributeDefinition, error) {
+
+	query := attributeDefinitionSelect + ` WHERE id = ?`
+
+	idBin, _ := id.MarshalBinary()
+
+	definition, err := scanAttributeDefinition(repo.db.QueryRowContext(ctx, query, idBin))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("attribute definition not found")
+		}
+		return nil, err
+	}
+
+	return definition, nil
+}
+
+func (repo *userAttributeRepository) FindDefinitions(ctx context.Context) ([]domain.UserAttributeDefinition, error) {
+
+	rows, err := repo.db.QueryContext(ctx, attributeDefinitionSelect+` ORDER BY attribute_key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	definitions := []domain.UserAttributeDefinition{}
+	for rows.Next() {
+		definition, err := scanAttributeDefinition(rows)
+		if err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, *definition)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return definitions, nil
+}
+
+func (repo *userAttributeRepository) SyncUnique(ctx context.Context, definitionID uuid.UUID, unique bool) error {
+
+	query := `UPDATE user_attribute_values SET unique_value = NULL WHERE definition_id = ?`
+	if unique {
+		query = `UPDATE user_attribute_values SET unique_value = LEFT(value, 255) WHERE definition_id = ?`
+	}
+
+	idBin, _ := definitionID.MarshalBinary()
+
+	_, err := repo.db.ExecContext(ctx, query, idBin)
+	return err
+}
+
+func (repo *userAttributeRepository) FindValues(ctx context.Context, userID uuid.UUID) ([]domain.UserAttributeValue, error) {
+
+	query := `SELECT uav.definition_id, uad.attribute_key, uad.type, uav.value, uad.user_editable
+			  FROM user_attribute_values AS uav
+			  JOIN user_attribute_definitions AS uad ON uad.id = uav.definition_id
+			  WHERE uav.user_id = ?
+			  ORDER BY uad.attribute_key`
+
+	userBin, _ := userID.MarshalBinary()
+
+	rows, err := repo.db.QueryContext(ctx, query, userBin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []domain.UserAttributeValue
+	for rows.Next() {
+		value := domain.UserAttributeValue{UserID: userID}
+		var definitionBin []byte
+		err := rows.Scan(&definitionBin, &value.Key, &value.Type, &value.Value, &value.UserEditable)
+		if err != nil {
+			return nil, err
+		}
+		value.DefinitionID, _ = uuid.FromBytes(definitionBin)
+		values = append(values, value)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func (repo *userAttributeRepository) SetValue(ctx context.Context, value domain.UserAttributeValue, unique bool) error {
+
+	query := `INSERT INTO user_attribute_values (user_id, definition_id, value, unique_value)
+			  VALUES (?, ?, ?, ?)
+			  ON DUPLICATE KEY UPDATE value = VALUES(value), unique_value = VALUES(unique_value)`
+
+	userBin, _ := value.UserID.MarshalBinary()
+	definitionBin, _ := value.DefinitionID.MarshalBinary()
+
+	var uniqueValue any
+	if unique {
+		uniqueValue = value.Value
+	}
+
+	_, err := repo.db.ExecContext(ctx, query, userBin, definitionBin, value.Value, uniqueValue)
+	return err
+}
+
+func (repo *userAttributeRepository) DeleteValue(ctx context.Context, userID uuid.UUID, definitionID uuid.UUID) error {
+
+	query := `DELETE FROM user_attribute_values WHERE user_id = ? AND definition_id = ?`
+
+	userBin, _ := userID.MarshalBinary()
+	definitionBin, _ := definitionID.MarshalBinary()
+
+	_, err := repo.db.ExecContext(ctx, query, userBin, definitionBin)
+	return err
+}
+
+func scanAttributeDefinition(row scanner) (*domain.UserAttributeDefinition, error) {
+	definition := &domain.UserAttributeDefinition{}
+	var idBin []byte
+	var rule sql.NullString
+
+	err := row.Scan(
+		&idBin,
+		&definition.Key,
+		&definition.Label,
+		&definition.Type,
+		&definition.Required,
+		&definition.Unique,
+		&definition.UserEditable,
+		&rule,
+		&definition.CreatedAt,
+		&definition.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	definition.ID, _ = uuid.FromBytes(idBin)
+	definition.Rule = rule.String
+
+	return definition, nil
+}
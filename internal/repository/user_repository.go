@@ -5,8 +5,10 @@ import (
 	"database/sql"
 	"errors"
 	"golang-auth/internal/domain"
+	"golang-auth/internal/pkg/identifier"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
@@ -33,8 +35,8 @@ func (u *userRepository) WithTx(tx *sql.Tx) domain.UserRepository {
 // buat
 func (u *userRepository) Create(ctx context.Context, user *domain.User) error {
 	
-	query := `INSERT INTO users (id, username, email, password, created_at, updated_at) 
-			  VALUES (?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO users (id, username, username_normalized, username_skeleton, email, email_normalized, password, created_at, updated_at) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	// ubah uuid ke format mysql
 	idBytes, err := user.ID.MarshalBinary()
@@ -46,7 +48,10 @@ func (u *userRepository) Create(ctx context.Context, user *domain.User) error {
 	_, err = u.db.ExecContext(ctx, query,
 		idBytes,
 		user.Username,
+		identifier.NormalizeUsername(user.Username),
+		identifier.Skeleton(user.Username),
 		user.Email,
+		identifier.NormalizeEmail(user.Email),
 		user.Password,
 		user.CreatedAt,
 		user.UpdatedAt,
@@ -65,12 +70,21 @@ func (u *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Us
 	binID, _ := id.MarshalBinary()
 	
 	// query pertama: ambil data user
-	queryUser := `SELECT id, username, email, created_at, updated_at FROM users WHERE id = ?`
+	queryUser := `SELECT id, username, email, pending_email, password, ` + userStatusSelect + `, version, created_at, updated_at FROM users WHERE id = ?`
 	var userBinId []byte
+	var pendingEmail sql.NullString
+	var status userStatusColumns
 	err := u.db.QueryRowContext(ctx, queryUser, binID).Scan(
 		&userBinId,
 		&res.Username,
 		&res.Email,
+		&pendingEmail,
+		&res.Password,
+		&res.Status,
+		&status.reason,
+		&status.until,
+		&status.deletedAt,
+		&res.Version,
 		&res.CreatedAt,
 		&res.UpdatedAt,
 	)
@@ -81,11 +95,14 @@ func (u *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Us
 		return  nil, err
 	}
 	res.ID, _ = uuid.FromBytes(userBinId)
+	res.PendingEmail = pendingEmail.String
+	status.apply(res)
 
 	// query kedua: ambil role user
-	queryRole := `SELECT r.id, r.name FROM roles as r
+	// hanya assignment yang sedang berlaku (belum kedaluwarsa & sudah dimulai)
+	queryRole := `SELECT r.id, r.name, uhr.condition_expression, uhr.starts_at, uhr.expires_at FROM roles as r
 				  JOIN user_has_roles as uhr ON r.id = uhr.role_id
-				  WHERE uhr.user_id = ?`
+				  WHERE uhr.user_id = ? AND ` + activeAssignment("uhr")
 	rowsR, err := u.db.QueryContext(ctx, queryRole, binID)
 	if err != nil {
 		return nil, err
@@ -95,13 +112,21 @@ func (u *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Us
 	for rowsR.Next() {
 		var role domain.Role
 		var roleBinId []byte
+		var condition sql.NullString
+		var startsAt, expiresAt sql.NullTime
 		err := rowsR.Scan(
 			&roleBinId,
 			&role.Name,
+			&condition,
+			&startsAt,
+			&expiresAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		role.Condition = condition.String
+		role.StartsAt = nullTimePtr(startsAt)
+		role.ExpiresAt = nullTimePtr(expiresAt)
 		// konversi id ke uuid
 		role.ID, _ = uuid.FromBytes(roleBinId)
 
@@ -113,9 +138,9 @@ func (u *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Us
 	}
 
 	// query ketiga: ambil permission user
-	queryPermission := `SELECT p.id, p.name FROM permissions as p
+	queryPermission := `SELECT p.id, p.name, uhp.condition_expression, uhp.starts_at, uhp.expires_at FROM permissions as p
 						JOIN user_has_permissions as uhp ON p.id = uhp.permission_id
-						WHERE uhp.user_id = ?`
+						WHERE uhp.user_id = ? AND ` + activeAssignment("uhp")
 	rowsP, err := u.db.QueryContext(ctx, queryPermission, binID)
 	if err != nil {
 		return nil, err
@@ -125,13 +150,21 @@ func (u *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Us
 	for rowsP.Next() {
 		var permission domain.Permission
 		var permissionBinId []byte
+		var condition sql.NullString
+		var startsAt, expiresAt sql.NullTime
 		err := rowsP.Scan(
 			&permissionBinId,
 			&permission.Name,
+			&condition,
+			&startsAt,
+			&expiresAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		permission.Condition = condition.String
+		permission.StartsAt = nullTimePtr(startsAt)
+		permission.ExpiresAt = nullTimePtr(expiresAt)
 		permission.ID, _ = uuid.FromBytes(permissionBinId)
 
 		res.Permissions = append(res.Permissions, permission)
@@ -139,24 +172,50 @@ func (u *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Us
 	if err = rowsP.Err(); err != nil {
 		return nil, err
 	}
+
+	// query keempat: atribut profil tambahan, memakai koneksi / transaksi yang sama
+	attributes, err := (&userAttributeRepository{db: u.db}).FindValues(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(attributes) > 0 {
+		res.Attributes = make(map[string]any, len(attributes))
+		for _, attribute := range attributes {
+			res.Attributes[attribute.Key] = attribute.Typed()
+		}
+	}
 	
 	return res, nil
 }
 
-// cari berdasarkan email
+// cari berdasarkan email, tidak peka huruf besar / kecil maupun bentuk Unicode
 func (u *userRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return u.findByIdentifier(ctx, "email_normalized", identifier.NormalizeEmail(email))
+}
+
+func (u *userRepository) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
+	return u.findByIdentifier(ctx, "username_normalized", identifier.NormalizeUsername(username))
+}
+
+// column hanya diisi konstanta dari FindByEmail / FindByUsername
+func (u *userRepository) findByIdentifier(ctx context.Context, column string, value string) (*domain.User, error) {
 	
-	query := `SELECT id, username, email, password FROM users WHERE email = ?`
+	query := `SELECT id, username, email, password, ` + userStatusSelect + ` FROM users WHERE ` + column + ` = ?`
 
 	var user domain.User
 	var binID []byte
+	var status userStatusColumns
 
 	// gunakan queryrowcontext
-	err := u.db.QueryRowContext(ctx, query, email).Scan(
+	err := u.db.QueryRowContext(ctx, query, value).Scan(
 		&binID,
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Status,
+		&status.reason,
+		&status.until,
+		&status.deletedAt,
 	)
 	// cek apakah user ditemukan
 	if err != nil {
@@ -173,15 +232,299 @@ func (u *userRepository) FindByEmail(ctx context.Context, email string) (*domain
 	if err != nil {
 		return nil, err
 	}
-	user.Email = email
+	status.apply(&user)
 
 	return  &user, nil
 }
 
+// semua user, terbaru di atas (uuid v7 urut waktu)
+func (u *userRepository) FindAll(ctx context.Context) ([]domain.User, error) {
+
+	query := `SELECT ` + userListSelect + ` FROM users ORDER BY id DESC`
+	rows, err := u.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUsers(rows)
+}
+
+var userListColumns = listColumns{
+	"username":   "username",
+	"email":      "email",
+	"status":     "status",
+	"created_at": "created_at",
+}
+
+// user yang sudah di-soft delete / dianonimkan disembunyikan dari list & pencarian, kecuali diminta lewat filter status
+func excludeDeletedUsers(spec domain.QuerySpec, column string) []listCondition {
+	for _, filter := range spec.Filters {
+		if filter.Field == "status" {
+			return nil
+		}
+	}
+	return []listCondition{{SQL: column + ` NOT IN (?, ?)`, Args: []any{domain.UserStatusDeleted, domain.UserStatusErased}}}
+}
+
+func (u *userRepository) FindPage(ctx context.Context, spec domain.QuerySpec) ([]domain.User, *domain.PageMeta, error) {
+	query, args, err := buildListQuery(`SELECT `+userListSelect+` FROM users`, "id", userListColumns, spec, excludeDeletedUsers(spec, "status")...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := u.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta, count := newPageMeta(spec, len(users), func(i int) (any, uuid.UUID) {
+		user := users[i]
+		switch spec.Sort {
+		case "username":
+			return user.Username, user.ID
+		case "email":
+			return user.Email, user.ID
+		case "status":
+			return user.Status, user.ID
+		case "created_at":
+			return user.CreatedAt, user.ID
+		}
+		return nil, user.ID
+	})
+
+	return users[:count], meta, nil
+}
+
+// pencarian admin: FULLTEXT (ngram) untuk q minimal 2 karakter, selain itu prefix pada index unik
+// username / email. role & permission dicek lewat grant global, ditambah grant organisasi saat ada tenant
+func (u *userRepository) Search(ctx context.Context, search domain.UserSearchQuery, spec domain.QuerySpec) ([]domain.User, *domain.PageMeta, error) {
+	conditions := excludeDeletedUsers(spec, "u.status")
+
+	var orgBin []byte
+	if search.OrganizationID != nil {
+		orgBin, _ = search.OrganizationID.MarshalBinary()
+		conditions = append(conditions, listCondition{
+			SQL:  `EXISTS (SELECT 1 FROM organization_members AS om WHERE om.user_id = u.id AND om.organization_id = ?)`,
+			Args: []any{orgBin},
+		})
+	}
+
+	if term := strings.TrimSpace(strings.ReplaceAll(search.Query, `"`, "")); term != "" {
+		if utf8.RuneCountInString(term) < 2 {
+			prefix := escapeLike(term) + "%"
+			conditions = append(conditions, listCondition{
+				SQL:  `(u.username LIKE ? ESCAPE '\\' OR u.email LIKE ? ESCAPE '\\')`,
+				Args: []any{prefix, prefix},
+			})
+		} else {
+			// frasa supaya potongan ngram harus berurutan, bukan sekadar salah satu token
+			conditions = append(conditions, listCondition{
+				SQL:  `MATCH(u.username, u.email) AGAINST (? IN BOOLEAN MODE)`,
+				Args: []any{`"` + term + `"`},
+			})
+		}
+	}
+
+	if search.Role != "" {
+		condition := listCondition{
+			SQL: `EXISTS (SELECT 1 FROM user_has_roles AS uhr JOIN roles AS r ON r.id = uhr.role_id
+				  WHERE uhr.user_id = u.id AND r.name = ? AND ` + activeAssignment("uhr") + `)`,
+			Args: []any{search.Role},
+		}
+		if orgBin != nil {
+			condition.SQL = `(` + condition.SQL + ` OR EXISTS (SELECT 1 FROM organization_user_has_roles AS ouhr
+				JOIN roles AS r ON r.id = ouhr.role_id
+				WHERE ouhr.user_id = u.id AND ouhr.organization_id = ? AND r.name = ?))`
+			condition.Args = append(condition.Args, orgBin, search.Role)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if search.Permission != "" {
+		condition := listCondition{
+			SQL: `(EXISTS (SELECT 1 FROM user_has_permissions AS uhp JOIN permissions AS p ON p.id = uhp.permission_id
+				  WHERE uhp.user_id = u.id AND p.name = ? AND ` + activeAssignment("uhp") + `)
+				  OR EXISTS (SELECT 1 FROM user_has_roles AS uhr
+				  JOIN role_has_permissions AS rhp ON rhp.role_id = uhr.role_id
+				  JOIN permissions AS p ON p.id = rhp.permission_id
+				  WHERE uhr.user_id = u.id AND p.name = ? AND ` + activeAssignment("uhr") + `)`,
+			Args: []any{search.Permission, search.Permission},
+		}
+		if orgBin != nil {
+			condition.SQL += ` OR EXISTS (SELECT 1 FROM organization_user_has_permissions AS ouhp
+				  JOIN permissions AS p ON p.id = ouhp.permission_id
+				  WHERE ouhp.user_id = u.id AND ouhp.organization_id = ? AND p.name = ?)
+				  OR EXISTS (SELECT 1 FROM organization_user_has_roles AS ouhr
+				  JOIN role_has_permissions AS rhp ON rhp.role_id = ouhr.role_id
+				  JOIN permissions AS p ON p.id = rhp.permission_id
+				  WHERE ouhr.user_id = u.id AND ouhr.organization_id = ? AND p.name = ?)`
+			condition.Args = append(condition.Args, orgBin, search.Permission, orgBin, search.Permission)
+		}
+		condition.SQL += `)`
+		conditions = append(conditions, condition)
+	}
+
+	if search.CreatedAfter != nil {
+		conditions = append(conditions, listCondition{
+			SQL:  `u.created_at >= ?`,
+			Args: []any{search.CreatedAfter.UTC()},
+		})
+	}
+
+	// hanya urut id (terbaru dulu), sort lain tidak didukung untuk pencarian
+	spec.Sort, spec.Desc = "", true
+	base := `SELECT u.id, u.username, u.email, u.status, u.suspended_reason, u.suspended_until, u.deleted_at,
+			 u.version, u.created_at, u.updated_at FROM users AS u`
+	query, args, err := buildListQuery(base, "u.id", listColumns{}, spec, conditions...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := u.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta, count := newPageMeta(spec, len(users), func(i int) (any, uuid.UUID) {
+		return nil, users[i].ID
+	})
+	users = users[:count]
+
+	err = u.loadRoles(ctx, users, orgBin)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return users, meta, nil
+}
+
+// loadRoles mengisi role aktif untuk banyak user sekaligus (satu query), ditambah role organisasi kalau orgBin diisi
+func (u *userRepository) loadRoles(ctx context.Context, users []domain.User, orgBin []byte) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	index := make(map[uuid.UUID]int, len(users))
+	placeholders := make([]string, len(users))
+	args := make([]any, 0, len(users)*2+1)
+	for i, user := range users {
+		index[user.ID] = i
+		placeholders[i] = "?"
+		binID, _ := user.ID.MarshalBinary()
+		args = append(args, binID)
+	}
+	in := strings.Join(placeholders, ",")
+
+	query := `SELECT uhr.user_id, r.id, r.name, r.guard_name FROM user_has_roles AS uhr
+			  JOIN roles AS r ON r.id = uhr.role_id
+			  WHERE uhr.user_id IN (` + in + `) AND ` + activeAssignment("uhr")
+	if orgBin != nil {
+		query += ` UNION SELECT ouhr.user_id, r.id, r.name, r.guard_name FROM organization_user_has_roles AS ouhr
+				   JOIN roles AS r ON r.id = ouhr.role_id
+				   WHERE ouhr.organization_id = ? AND ouhr.user_id IN (` + in + `)`
+		args = append(args, orgBin)
+		args = append(args, args[:len(users)]...)
+	}
+
+	rows, err := u.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userBinID, roleBinID []byte
+		var role domain.Role
+		err := rows.Scan(&userBinID, &roleBinID, &role.Name, &role.Guard)
+		if err != nil {
+			return err
+		}
+		userID, _ := uuid.FromBytes(userBinID)
+		role.ID, _ = uuid.FromBytes(roleBinID)
+
+		i := index[userID]
+		users[i].Roles = append(users[i].Roles, role)
+	}
+
+	return rows.Err()
+}
+
+// kolom list user tanpa role & permission, urutannya harus sama dengan scanUsers
+const userListSelect = `id, username, email, ` + userStatusSelect + `, version, created_at, updated_at`
+
+// kolom status akun, urutannya harus sama dengan field userStatusColumns
+const userStatusSelect = `status, suspended_reason, suspended_until, deleted_at`
+
+// userStatusColumns menampung kolom status yang nullable sebelum disalin ke domain.User
+type userStatusColumns struct {
+	reason    sql.NullString
+	until     sql.NullTime
+	deletedAt sql.NullTime
+}
+
+func (c userStatusColumns) apply(user *domain.User) {
+	user.SuspendedReason = c.reason.String
+	if c.until.Valid {
+		until := c.until.Time
+		user.SuspendedUntil = &until
+	}
+	if c.deletedAt.Valid {
+		deletedAt := c.deletedAt.Time
+		user.DeletedAt = &deletedAt
+	}
+}
+
+func scanUsers(rows *sql.Rows) ([]domain.User, error) {
+	users := []domain.User{}
+	for rows.Next() {
+		var user domain.User
+		var binID []byte
+		var status userStatusColumns
+		err := rows.Scan(
+			&binID,
+			&user.Username,
+			&user.Email,
+			&user.Status,
+			&status.reason,
+			&status.until,
+			&status.deletedAt,
+			&user.Version,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		user.ID, _ = uuid.FromBytes(binID)
+		status.apply(&user)
+
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
 // update
 func (u *userRepository) Update(ctx context.Context, user *domain.User) error {
 	
-	query := `UPDATE users SET username = ?, email = ?, updated_at = ? WHERE id = ?`
+	query := `UPDATE users SET username = ?, username_normalized = ?, username_skeleton = ?,
+			  email = ?, email_normalized = ?, updated_at = ? WHERE id = ?`
 
 
 	// ubah uuid ke format mysql
@@ -192,7 +535,10 @@ func (u *userRepository) Update(ctx context.Context, user *domain.User) error {
 
 	res, err := u.db.ExecContext(ctx, query, 
 		user.Username,
+		identifier.NormalizeUsername(user.Username),
+		identifier.Skeleton(user.Username),
 		user.Email,
+		identifier.NormalizeEmail(user.Email),
 		user.UpdatedAt,
 		idBytes,
 	)
@@ -231,27 +577,84 @@ func (u *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return  err
 }
 
+// simpan status akun, deleted_at diisi / dikosongkan sesuai user.DeletedAt
+func (u *userRepository) UpdateStatus(ctx context.Context, user *domain.User) error {
+
+	query := `UPDATE users SET status = ?, suspended_reason = ?, suspended_until = ?, deleted_at = ?, updated_at = ? WHERE id = ?`
+
+	idBytes, err := user.ID.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	res, err := u.db.ExecContext(ctx, query,
+		user.Status,
+		nullString(user.SuspendedReason),
+		user.SuspendedUntil,
+		user.DeletedAt,
+		user.UpdatedAt,
+		idBytes,
+	)
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New("No user updated")
+		}
+	}
+
+	return err
+}
+
+func (u *userRepository) FindStatus(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+
+	query := `SELECT ` + userStatusSelect + ` FROM users WHERE id = ?`
+
+	idBytes, err := id.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &domain.User{ID: id}
+	var status userStatusColumns
+	err = u.db.QueryRowContext(ctx, query, idBytes).Scan(
+		&user.Status,
+		&status.reason,
+		&status.until,
+		&status.deletedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	status.apply(user)
+
+	return user, nil
+}
+
 // tambah role
-func (u *userRepository) AssignRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID) error {
+func (u *userRepository) AssignRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID, opts domain.AssignmentOptions) error {
 	
 	if len(roleIDs) == 0 {
 		return nil
 	}
 
-	query := `INSERT INTO user_has_roles (user_id, role_id) VALUES `
+	query := `INSERT INTO user_has_roles (user_id, role_id, condition_expression, starts_at, expires_at) VALUES `
 
 	// konversi kedua ID
 	userBinId, _ := userID.MarshalBinary()
 
 	// pre-allocation slice
-	values := make([]interface{}, 0, len(roleIDs)*2) // * 2 karna butuh user_id dan role_id
+	values := make([]interface{}, 0, len(roleIDs)*5) // * 5 karna butuh user_id, role_id, kondisi dan masa berlaku
 	placeHolders := make([]string, 0, len(roleIDs))
 
 	for _, rID := range roleIDs {
-		placeHolders = append(placeHolders, "(?, ?)")
+		placeHolders = append(placeHolders, "(?, ?, ?, ?, ?)")
 
 		roleBinID, _ := rID.MarshalBinary()
-		values = append(values, userBinId, roleBinID)
+		startsAt, expiresAt := scheduleValues(opts.Schedules, rID)
+		values = append(values, userBinId, roleBinID, conditionValue(opts.Conditions, rID), startsAt, expiresAt)
 	}
 
 	query += strings.Join(placeHolders, ", ")
@@ -274,27 +677,76 @@ func (u *userRepository) RemoveAllRoles(ctx context.Context, userID uuid.UUID) e
 	return  err
 }
 
+// attach role tanpa menghapus role lain, kondisi & masa berlaku role yang sudah terpasang ditimpa
+func (u *userRepository) AttachRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID, opts domain.AssignmentOptions) error {
+	return u.attach(ctx, "user_has_roles", "role_id", userID, roleIDs, opts)
+}
+
+func (u *userRepository) DetachRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID) error {
+	return deleteLinks(ctx, u.db, "user_has_roles", "user_id", userID, "role_id", roleIDs)
+}
+
+func (u *userRepository) AttachPermissions(ctx context.Context, userID uuid.UUID, permissionIDs []uuid.UUID, opts domain.AssignmentOptions) error {
+	return u.attach(ctx, "user_has_permissions", "permission_id", userID, permissionIDs, opts)
+}
+
+func (u *userRepository) DetachPermissions(ctx context.Context, userID uuid.UUID, permissionIDs []uuid.UUID) error {
+	return deleteLinks(ctx, u.db, "user_has_permissions", "user_id", userID, "permission_id", permissionIDs)
+}
+
+// upsert assignment user ke tabel pivot (user_has_roles / user_has_permissions)
+func (u *userRepository) attach(ctx context.Context, table string, linkColumn string, userID uuid.UUID, ids []uuid.UUID, opts domain.AssignmentOptions) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	userBinId, _ := userID.MarshalBinary()
+
+	values := make([]interface{}, 0, len(ids)*5)
+	placeHolders := make([]string, 0, len(ids))
+	for _, id := range ids {
+		placeHolders = append(placeHolders, "(?, ?, ?, ?, ?)")
+
+		binID, _ := id.MarshalBinary()
+		startsAt, expiresAt := scheduleValues(opts.Schedules, id)
+		values = append(values, userBinId, binID, conditionValue(opts.Conditions, id), startsAt, expiresAt)
+	}
+
+	query := `INSERT INTO ` + table + ` (user_id, ` + linkColumn + `, condition_expression, starts_at, expires_at) VALUES ` +
+		strings.Join(placeHolders, ", ") +
+		` ON DUPLICATE KEY UPDATE condition_expression = VALUES(condition_expression),
+		  starts_at = VALUES(starts_at), expires_at = VALUES(expires_at)`
+
+	_, err := u.db.ExecContext(ctx, query, values...)
+	return err
+}
+
+func (u *userRepository) BumpVersion(ctx context.Context, id uuid.UUID, expected int) error {
+	return bumpVersion(ctx, u.db, "users", id, expected)
+}
+
 // tambah permissions
-func (u *userRepository) AssignPermissions(ctx context.Context, userID uuid.UUID, permissionIDs []uuid.UUID) error {
+func (u *userRepository) AssignPermissions(ctx context.Context, userID uuid.UUID, permissionIDs []uuid.UUID, opts domain.AssignmentOptions) error {
 	
 	if len(permissionIDs) == 0 {
 		return nil
 	}
 
-	query := `INSERT INTO user_has_permissions (user_id, permission_id) VALUES `
+	query := `INSERT INTO user_has_permissions (user_id, permission_id, condition_expression, starts_at, expires_at) VALUES `
 
 	// konversi kedua ID
 	userBinId, _ := userID.MarshalBinary()
 
 	// pre-allocation slice
-	values := make([]interface{}, 0, len(permissionIDs)*2) // * 2 karna butuh user_id dan permission_id
+	values := make([]interface{}, 0, len(permissionIDs)*5) // * 5 karna butuh user_id, permission_id, kondisi dan masa berlaku
 	placeHolders := make([]string, 0, len(permissionIDs))
 
 	for _, pID := range permissionIDs {
-		placeHolders = append(placeHolders, "(?, ?)")
+		placeHolders = append(placeHolders, "(?, ?, ?, ?, ?)")
 
 		permBinID, _ := pID.MarshalBinary()
-		values = append(values, userBinId, permBinID)
+		startsAt, expiresAt := scheduleValues(opts.Schedules, pID)
+		values = append(values, userBinId, permBinID, conditionValue(opts.Conditions, pID), startsAt, expiresAt)
 	}
 
 	query += strings.Join(placeHolders, ", ")
@@ -317,6 +769,303 @@ func (u *userRepository) RemoveAllPermissions(ctx context.Context, userID uuid.U
 	return  err
 }
 
+// cari assignment role & permission yang sudah kedaluwarsa
+func (u *userRepository) FindExpiredAssignments(ctx context.Context, before time.Time) ([]domain.ExpiredAssignment, error) {
+
+	query := `SELECT uhr.user_id, 'role', r.id, r.name, uhr.expires_at FROM user_has_roles as uhr
+			  JOIN roles as r ON r.id = uhr.role_id
+			  WHERE uhr.expires_at <= ?
+
+			  UNION ALL
+
+			  SELECT uhp.user_id, 'permission', p.id, p.name, uhp.expires_at FROM user_has_permissions as uhp
+			  JOIN permissions as p ON p.id = uhp.permission_id
+			  WHERE uhp.expires_at <= ?`
+
+	rows, err := u.db.QueryContext(ctx, query, before.UTC(), before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []domain.ExpiredAssignment
+	for rows.Next() {
+		var assignment domain.ExpiredAssignment
+		var userBinID, binID []byte
+
+		err := rows.Scan(
+			&userBinID,
+			&assignment.Type,
+			&binID,
+			&assignment.Name,
+			&assignment.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		assignment.UserID, _ = uuid.FromBytes(userBinID)
+		assignment.ID, _ = uuid.FromBytes(binID)
+
+		assignments = append(assignments, assignment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+// hapus satu assignment kedaluwarsa, expires_at ikut dicek supaya assignment yang
+// baru saja diperpanjang tidak ikut terhapus
+func (u *userRepository) DeleteExpiredAssignment(ctx context.Context, assignment domain.ExpiredAssignment) error {
+
+	query := `DELETE FROM user_has_roles WHERE user_id = ? AND role_id = ? AND expires_at <= ?`
+	if assignment.Type == "permission" {
+		query = `DELETE FROM user_has_permissions WHERE user_id = ? AND permission_id = ? AND expires_at <= ?`
+	}
+
+	userBinID, _ := assignment.UserID.MarshalBinary()
+	binID, _ := assignment.ID.MarshalBinary()
+
+	_, err := u.db.ExecContext(ctx, query, userBinID, binID, assignment.ExpiresAt.UTC())
+
+	return err
+}
+
+// assignment sementara, dipakai saat access request disetujui
+func (u *userRepository) GrantTemporaryAssignment(ctx context.Context, userID uuid.UUID, assignmentType string, id uuid.UUID, expiresAt time.Time) error {
+
+	// kalau assignment sudah ada: permanen (expires_at NULL) tetap permanen,
+	// yang sementara diperpanjang ke waktu yang paling lama
+	query := `INSERT INTO user_has_roles (user_id, role_id, expires_at) VALUES (?, ?, ?)
+			  ON DUPLICATE KEY UPDATE expires_at = IF(expires_at IS NULL, NULL, GREATEST(expires_at, VALUES(expires_at)))`
+	if assignmentType == "permission" {
+		query = `INSERT INTO user_has_permissions (user_id, permission_id, expires_at) VALUES (?, ?, ?)
+				 ON DUPLICATE KEY UPDATE expires_at = IF(expires_at IS NULL, NULL, GREATEST(expires_at, VALUES(expires_at)))`
+	}
+
+	userBinID, _ := userID.MarshalBinary()
+	binID, _ := id.MarshalBinary()
+
+	_, err := u.db.ExecContext(ctx, query, userBinID, binID, expiresAt.UTC())
+
+	return err
+}
+
+// cari user yang punya permission tertentu, baik direct maupun lewat role
+func (u *userRepository) FindByPermission(ctx context.Context, permission string) ([]domain.User, error) {
+
+	query := `SELECT u.id, u.username, u.email FROM users as u
+			  JOIN user_has_roles as uhr ON uhr.user_id = u.id
+			  JOIN role_has_permissions as rhp ON rhp.role_id = uhr.role_id
+			  JOIN permissions as p ON p.id = rhp.permission_id
+			  WHERE p.name = ? AND u.status = 'active'
+			  AND uhr.condition_expression IS NULL AND rhp.condition_expression IS NULL
+			  AND ` + activeAssignment("uhr") + `
+
+			  UNION
+
+			  SELECT u.id, u.username, u.email FROM users as u
+			  JOIN user_has_permissions as uhp ON uhp.user_id = u.id
+			  JOIN permissions as p ON p.id = uhp.permission_id
+			  WHERE p.name = ? AND u.status = 'active'
+			  AND uhp.condition_expression IS NULL
+			  AND ` + activeAssignment("uhp")
+
+	rows, err := u.db.QueryContext(ctx, query, permission, permission)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var user domain.User
+		var binID []byte
+		err := rows.Scan(
+			&binID,
+			&user.Username,
+			&user.Email,
+		)
+		if err != nil {
+			return nil, err
+		}
+		user.ID, _ = uuid.FromBytes(binID)
+
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (u *userRepository) FindHeldRoleIDs(ctx context.Context, userID uuid.UUID, guard string) ([]uuid.UUID, error) {
+
+	query := `SELECT uhr.role_id FROM user_has_roles as uhr
+			  JOIN roles as r ON r.id = uhr.role_id
+			  WHERE uhr.user_id = ? AND r.organization_id IS NULL AND r.guard_name = ?
+			  AND uhr.condition_expression IS NULL
+			  AND ` + activeAssignment("uhr")
+
+	userBin, _ := userID.MarshalBinary()
+
+	return findIDs(ctx, u.db, query, userBin, guard)
+}
+
+func (u *userRepository) FindHeldPermissionIDs(ctx context.Context, userID uuid.UUID, guard string) ([]uuid.UUID, error) {
+
+	query := `SELECT uhp.permission_id FROM user_has_permissions as uhp
+			  JOIN permissions as p ON p.id = uhp.permission_id
+			  WHERE uhp.user_id = ? AND p.guard_name = ? AND uhp.condition_expression IS NULL
+			  AND ` + activeAssignment("uhp") + `
+
+			  UNION
+
+			  SELECT rhp.permission_id FROM user_has_roles as uhr
+			  JOIN role_has_permissions as rhp ON rhp.role_id = uhr.role_id
+			  JOIN permissions as p ON p.id = rhp.permission_id
+			  WHERE uhr.user_id = ? AND p.guard_name = ?
+			  AND uhr.condition_expression IS NULL AND rhp.condition_expression IS NULL
+			  AND ` + activeAssignment("uhr")
+
+	userBin, _ := userID.MarshalBinary()
+
+	return findIDs(ctx, u.db, query, userBin, guard, userBin, guard)
+}
+
+// findIDs menjalankan query yang hanya mengembalikan satu kolom id binary
+func findIDs(ctx context.Context, db DBTX, query string, args ...any) ([]uuid.UUID, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var binID []byte
+		err := rows.Scan(&binID)
+		if err != nil {
+			return nil, err
+		}
+		id, _ := uuid.FromBytes(binID)
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (u *userRepository) FindTakenIdentifiers(ctx context.Context, emails []string, usernames []string) (map[string]bool, map[string]bool, error) {
+	takenEmails, err := u.findTaken(ctx, "email_normalized", emails)
+	if err != nil {
+		return nil, nil, err
+	}
+	takenUsernames, err := u.findTaken(ctx, "username_skeleton", usernames)
+	if err != nil {
+		return nil, nil, err
+	}
+	return takenEmails, takenUsernames, nil
+}
+
+// column hanya diisi konstanta dari FindTakenIdentifiers, bukan dari input
+func (u *userRepository) findTaken(ctx context.Context, column string, values []string) (map[string]bool, error) {
+	taken := map[string]bool{}
+	if len(values) == 0 {
+		return taken, nil
+	}
+
+	placeholders := make([]string, len(values))
+	args := make([]any, len(values))
+	for i, value := range values {
+		placeholders[i] = "?"
+		args[i] = value
+	}
+
+	query := `SELECT ` + column + ` FROM users WHERE ` + column + ` IN (` + strings.Join(placeholders, ",") + `)`
+	rows, err := u.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+		taken[value] = true
+	}
+
+	return taken, rows.Err()
+}
+
+func (u *userRepository) Export(ctx context.Context, guard string, fn func(row domain.UserExportRow) error) error {
+
+	// satu baris per role (bukan GROUP_CONCAT yang terpotong diam-diam di group_concat_max_len),
+	// baris milik user yang sama berurutan karena ORDER BY u.id lalu digabung di sini
+	query := `SELECT u.id, u.username, u.email, u.status, u.created_at, r.name
+			  FROM users AS u
+			  LEFT JOIN (user_has_roles AS uhr
+			  	JOIN roles AS r ON r.id = uhr.role_id AND r.guard_name = ? AND r.organization_id IS NULL)
+			  ON uhr.user_id = u.id AND ` + activeAssignment("uhr") + `
+			  WHERE u.status NOT IN (?, ?)
+			  ORDER BY u.id, r.name`
+
+	rows, err := u.db.QueryContext(ctx, query, guard, domain.UserStatusDeleted, domain.UserStatusErased)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var current *domain.UserExportRow
+	for rows.Next() {
+		var row domain.UserExportRow
+		var binID []byte
+		var role sql.NullString
+		err := rows.Scan(
+			&binID,
+			&row.Username,
+			&row.Email,
+			&row.Status,
+			&row.CreatedAt,
+			&role,
+		)
+		if err != nil {
+			return err
+		}
+		row.ID, _ = uuid.FromBytes(binID)
+
+		if current == nil || current.ID != row.ID {
+			if current != nil {
+				err = fn(*current)
+				if err != nil {
+					return err
+				}
+			}
+			row.Roles = []string{}
+			current = &row
+		}
+		if role.Valid {
+			current.Roles = append(current.Roles, role.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if current != nil {
+		return fn(*current)
+	}
+	return nil
+}
+
 // ubah password
 func (u *userRepository) ChangePassword(ctx context.Context, id uuid.UUID ,newPassword string) error {
 
@@ -342,4 +1091,167 @@ func (u *userRepository) ChangePassword(ctx context.Context, id uuid.UUID ,newPa
 	}
 
 	return  err
-}
\ No newline at end of file
+}
+// simpan email baru beserta hash token verifikasinya, email lama tetap dipakai sampai diverifikasi
+func (u *userRepository) SetPendingEmail(ctx context.Context, id uuid.UUID, email string, tokenHash string, expiresAt time.Time) error {
+
+	query := `UPDATE users SET pending_email = ?, email_verification_hash = ?, email_verification_expires_at = ? WHERE id = ?`
+
+	binID, _ := id.MarshalBinary()
+	_, err := u.db.ExecContext(ctx, query, email, tokenHash, expiresAt.UTC(), binID)
+
+	return err
+}
+
+func (u *userRepository) FindByEmailVerification(ctx context.Context, tokenHash string) (*domain.User, error) {
+
+	query := `SELECT id, username, email, pending_email FROM users
+			  WHERE email_verification_hash = ? AND email_verification_expires_at > UTC_TIMESTAMP()`
+
+	var user domain.User
+	var binID []byte
+	err := u.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&binID,
+		&user.Username,
+		&user.Email,
+		&user.PendingEmail,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("verification token not found or expired")
+		}
+		return nil, err
+	}
+	user.ID, _ = uuid.FromBytes(binID)
+
+	return &user, nil
+}
+
+// pindahkan pending_email ke email dan hapus token verifikasi
+func (u *userRepository) ConfirmPendingEmail(ctx context.Context, id uuid.UUID) error {
+
+	binID, _ := id.MarshalBinary()
+
+	// bentuk ter-normalisasi dihitung di aplikasi, jadi pending_email dibaca dulu
+	var pendingEmail sql.NullString
+	err := u.db.QueryRowContext(ctx, `SELECT pending_email FROM users WHERE id = ? FOR UPDATE`, binID).Scan(&pendingEmail)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if !pendingEmail.Valid {
+		return errors.New("No pending email to confirm")
+	}
+
+	query := `UPDATE users SET email = pending_email, email_normalized = ?, pending_email = NULL,
+			  email_verification_hash = NULL, email_verification_expires_at = NULL
+			  WHERE id = ? AND pending_email IS NOT NULL`
+
+	res, err := u.db.ExecContext(ctx, query, identifier.NormalizeEmail(pendingEmail.String), binID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return errors.New("No pending email to confirm")
+	}
+	return nil
+}
+
+func (u *userRepository) Erase(ctx context.Context, user *domain.User) error {
+
+	query := `UPDATE users SET username = ?, username_normalized = ?, username_skeleton = ?,
+			  email = ?, email_normalized = ?, password = '', status = ?, deleted_at = ?,
+			  suspended_reason = NULL, suspended_until = NULL, pending_email = NULL,
+			  email_verification_hash = NULL, email_verification_expires_at = NULL, updated_at = ?
+			  WHERE id = ?`
+
+	binID, _ := user.ID.MarshalBinary()
+	res, err := u.db.ExecContext(ctx, query,
+		user.Username,
+		identifier.NormalizeUsername(user.Username),
+		identifier.Skeleton(user.Username),
+		user.Email,
+		identifier.NormalizeEmail(user.Email),
+		user.Status,
+		user.DeletedAt,
+		user.UpdatedAt,
+		binID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return errors.New("No user updated")
+	}
+
+	// data yang menempel ke user, riwayatnya tetap ada di audit log
+	tables := []string{
+		"user_has_roles",
+		"user_has_permissions",
+		"user_has_resource_permissions",
+		"organization_user_has_roles",
+		"organization_user_has_permissions",
+		"organization_members",
+		"user_attribute_values",
+	}
+	for _, table := range tables {
+		_, err := u.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE user_id = ?`, binID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// relation tuple memakai id string, user bisa jadi subject (user:<id>) maupun object
+	_, err = u.db.ExecContext(ctx, `DELETE FROM relation_tuples
+		WHERE (subject_namespace = ? AND subject_id = ?) OR (namespace = ? AND object_id = ?)`,
+		domain.UserRelationNamespace, user.ID.String(), domain.UserRelationNamespace, user.ID.String())
+
+	return err
+}
+
+func (u *userRepository) FindUnnormalized(ctx context.Context, after uuid.UUID, limit int) ([]domain.User, error) {
+
+	query := `SELECT id, username, email FROM users
+			  WHERE id > ? AND (email_normalized IS NULL OR username_normalized IS NULL)
+			  ORDER BY id LIMIT ?`
+
+	afterID, _ := after.MarshalBinary()
+	rows, err := u.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var user domain.User
+		var binID []byte
+		err := rows.Scan(&binID, &user.Username, &user.Email)
+		if err != nil {
+			return nil, err
+		}
+		user.ID, _ = uuid.FromBytes(binID)
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (u *userRepository) SetNormalizedIdentifiers(ctx context.Context, user *domain.User, skipSkeleton bool) error {
+
+	query := `UPDATE users SET email_normalized = ?, username_normalized = ?, username_skeleton = ? WHERE id = ?`
+
+	skeleton := sql.NullString{String: identifier.Skeleton(user.Username), Valid: !skipSkeleton}
+
+	binID, _ := user.ID.MarshalBinary()
+	_, err := u.db.ExecContext(ctx, query,
+		identifier.NormalizeEmail(user.Email),
+		identifier.NormalizeUsername(user.Username),
+		skeleton,
+		binID,
+	)
+
+	return err
+}
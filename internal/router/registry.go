@@ -0,0 +1,135 @@
+package router
+
+import (
+	"golang-auth/internal/domain"
+	"golang-auth/internal/middleware"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry mencatat setiap route (method, path, permission) yang didaftarkan lewat Group,
+// sehingga permission di route tidak perlu diduplikasi di seeder
+type Registry struct {
+	mu     sync.RWMutex
+	routes []domain.Route
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Group mendaftarkan route ke mux dengan prefix tertentu (prefix hanya untuk pencatatan,
+// misal mux yang sudah di-StripPrefix "/api/v1"). perm boleh nil untuk route tanpa permission
+func (reg *Registry) Group(mux *http.ServeMux, prefix string, access string, perm *middleware.PermissionMiddleware) *RouteGroup {
+	return &RouteGroup{
+		registry: reg,
+		mux:      mux,
+		prefix:   strings.TrimSuffix(prefix, "/"),
+		access:   access,
+		perm:     perm,
+	}
+}
+
+func (reg *Registry) Routes() []domain.Route {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	routes := make([]domain.Route, len(reg.routes))
+	copy(routes, reg.routes)
+	sort.Slice(routes, func(a, b int) bool {
+		if routes[a].Path == routes[b].Path {
+			return routes[a].Method < routes[b].Method
+		}
+		return routes[a].Path < routes[b].Path
+	})
+	return routes
+}
+
+func (reg *Registry) HasPermission(name string) bool {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	for _, route := range reg.routes {
+		if route.Permission == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions mengembalikan permission unik yang dipakai route, urut abjad
+func (reg *Registry) Permissions() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, route := range reg.routes {
+		if route.Permission != "" && !seen[route.Permission] {
+			seen[route.Permission] = true
+			names = append(names, route.Permission)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (reg *Registry) add(route domain.Route) {
+	reg.mu.Lock()
+	reg.routes = append(reg.routes, route)
+	reg.mu.Unlock()
+}
+
+type RouteGroup struct {
+	registry *Registry
+	mux      *http.ServeMux
+	prefix   string
+	access   string
+	perm     *middleware.PermissionMiddleware
+}
+
+// Handle mendaftarkan route tanpa permission (publik atau cukup login, tergantung group)
+func (g *RouteGroup) Handle(pattern string, handler http.HandlerFunc) {
+	g.register(pattern, domain.Route{Access: g.access}, handler)
+}
+
+// Require mendaftarkan route yang diproteksi PermissionMiddleware.Require
+func (g *RouteGroup) Require(pattern string, permission string, handler http.HandlerFunc) {
+	g.register(pattern, domain.Route{
+		Access:     domain.RouteAccessPermission,
+		Permission: permission,
+	}, g.perm.Require(permission, handler))
+}
+
+// RequireInOrganization mendaftarkan route yang diproteksi PermissionMiddleware.RequireInOrganization
+func (g *RouteGroup) RequireInOrganization(pattern string, permission string, handler http.HandlerFunc) {
+	g.register(pattern, domain.Route{
+		Access:     domain.RouteAccessOrganization,
+		Permission: permission,
+	}, g.perm.RequireInOrganization(permission, handler))
+}
+
+// RequireResource mendaftarkan route yang diproteksi PermissionMiddleware.RequireResource
+func (g *RouteGroup) RequireResource(pattern string, permission string, resourceType string, pathParam string, handler http.HandlerFunc) {
+	g.register(pattern, domain.Route{
+		Access:       domain.RouteAccessResource,
+		Permission:   permission,
+		ResourceType: resourceType,
+	}, g.perm.RequireResource(permission, resourceType, pathParam, handler))
+}
+
+func (g *RouteGroup) register(pattern string, route domain.Route, handler http.HandlerFunc) {
+	g.mux.HandleFunc(pattern, handler)
+
+	// pattern net/http: "[METHOD ]/path"
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "*", pattern
+	}
+	route.Method = method
+	route.Path = g.prefix + strings.TrimSpace(path)
+
+	g.registry.add(route)
+}
@@ -8,31 +8,12 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-// SeedPermissionsAndSuperadmin memastikan permission wajib dan superadmin tersedia
-func SeedPermissionsAndSuperadmin(db *sql.DB) {
+// SeedSuperadmin memastikan superadmin tersedia dan memiliki semua permission.
+// daftar permission sendiri dibuat registry route saat API start dan file policy RBAC (rbac.yaml), bukan di sini
+func SeedSuperadmin(db *sql.DB) {
 	// ==========================================
-	// TAHAP 1: SEED PERMISSIONS
+	// TAHAP 1: AMBIL SEMUA PERMISSION DARI DB
 	// ==========================================
-	permissions := []string{
-		"roles:view",
-		"roles:manage",
-		"permissions:view",
-		// Tambahkan yang lain jika ada
-	}
-
-	for _, name := range permissions {
-		id, _ := uuid.NewV7()
-		query := `INSERT IGNORE INTO permissions (id, name) VALUES (?, ?)`
-		_, err := db.Exec(query, id[:], name)
-		if err != nil {
-			slog.Error("Gagal insert permission", "permission", name, "error", err)
-		}
-	}
-
-	// ==========================================
-	// TAHAP 2: AMBIL ID PERMISSION ASLI DARI DB
-	// ==========================================
-	// Kita ambil id asli dari DB karena id hasil generate tadi mungkin diabaikan oleh INSERT IGNORE
 	rows, err := db.Query(`SELECT id, name FROM permissions`)
 	if err != nil {
 		slog.Error("Gagal mengambil daftar permissions", "error", err)
@@ -56,7 +37,7 @@ func SeedPermissionsAndSuperadmin(db *sql.DB) {
 	}
 
 	// ==========================================
-	// TAHAP 3: SEED USER SUPERADMIN
+	// TAHAP 2: SEED USER SUPERADMIN
 	// ==========================================
 	emailAdmin := "superadmin@example.com"
 	usernameAdmin := "superadmin"
@@ -86,7 +67,7 @@ func SeedPermissionsAndSuperadmin(db *sql.DB) {
 	}
 
 	// ==========================================
-	// TAHAP 4: HUBUNGKAN SUPERADMIN DENGAN SEMUA PERMISSIONS
+	// TAHAP 3: HUBUNGKAN SUPERADMIN DENGAN SEMUA PERMISSIONS
 	// ==========================================
 	for _, p := range allPermissions {
 		assignQuery := `INSERT IGNORE INTO user_has_permissions (user_id, permission_id) VALUES (?, ?)`
@@ -102,5 +83,5 @@ func SeedPermissionsAndSuperadmin(db *sql.DB) {
 		}
 	}
 
-	slog.Info("Proses Seeder Permission & Superadmin Selesai dengan Sukses!")
+	slog.Info("Proses Seeder Superadmin Selesai dengan Sukses!")
 }
\ No newline at end of file
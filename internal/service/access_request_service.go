@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/pkg/mailer"
+	"log/slog"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type accessRequestService struct {
+	accessRequestRepository domain.AccessRequestRepository
+	userRepository          domain.UserRepository
+	auditRepository         domain.AuditRepository
+	sodConstraintRepository domain.SoDConstraintRepository
+	mailer                  mailer.Mailer
+	db                      *sql.DB
+	validate                *validator.Validate
+}
+
+func NewAccessRequestService(accessRequestRepository domain.AccessRequestRepository, userRepository domain.UserRepository, auditRepository domain.AuditRepository, sodConstraintRepository domain.SoDConstraintRepository, mailer mailer.Mailer, db *sql.DB, validate *validator.Validate) domain.AccessRequestService {
+	return &accessRequestService{
+		accessRequestRepository: accessRequestRepository,
+		userRepository:          userRepository,
+		auditRepository:         auditRepository,
+		sodConstraintRepository: sodConstraintRepository,
+		mailer:                  mailer,
+		db:                      db,
+		validate:                validate,
+	}
+}
+
+func (service *accessRequestService) Create(ctx context.Context, userID uuid.UUID, req domain.AccessRequestCreateRequest) (*domain.AccessRequest, error) {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.accessRequestRepository.WithTx(tx)
+
+	// pastikan role / permission yang diminta memang ada
+	targetName, err := repoTx.FindTargetName(ctx, req.Type, req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	requester, err := service.userRepository.WithTx(tx).FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	uuid7, _ := uuid.NewV7()
+	now := time.Now()
+
+	accessRequest := &domain.AccessRequest{
+		ID:              uuid7,
+		UserID:          userID,
+		Username:        requester.Username,
+		Type:            req.Type,
+		TargetID:        req.TargetID,
+		TargetName:      targetName,
+		Justification:   req.Justification,
+		DurationMinutes: req.DurationMinutes,
+		Status:          domain.AccessRequestPending,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	err = repoTx.Create(ctx, accessRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.auditRepository.WithTx(tx).Create(ctx, &domain.AuditLog{
+		ActorID:     &userID,
+		Action:      "access_request.created",
+		SubjectType: "access_request",
+		SubjectID:   accessRequest.ID.String(),
+		Metadata:    accessRequestMetadata(accessRequest),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	service.notifyApprovers(ctx, accessRequest)
+
+	return accessRequest, nil
+}
+
+func (service *accessRequestService) Approve(ctx context.Context, id uuid.UUID, reviewerID uuid.UUID, req domain.AccessRequestReviewRequest) error {
+	return service.review(ctx, id, reviewerID, req, domain.AccessRequestApproved)
+}
+
+func (service *accessRequestService) Reject(ctx context.Context, id uuid.UUID, reviewerID uuid.UUID, req domain.AccessRequestReviewRequest) error {
+	return service.review(ctx, id, reviewerID, req, domain.AccessRequestRejected)
+}
+
+func (service *accessRequestService) review(ctx context.Context, id uuid.UUID, reviewerID uuid.UUID, req domain.AccessRequestReviewRequest, status string) error {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.accessRequestRepository.WithTx(tx)
+
+	accessRequest, err := repoTx.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// approver tidak boleh menyetujui permintaannya sendiri
+	if accessRequest.UserID == reviewerID {
+		return errors.New("tidak dapat meninjau access request milik sendiri")
+	}
+
+	now := time.Now()
+	accessRequest.Status = status
+	accessRequest.ReviewerID = &reviewerID
+	accessRequest.ReviewNote = req.Note
+	accessRequest.ReviewedAt = &now
+	accessRequest.UpdatedAt = now
+
+	// durasi dihitung sejak disetujui, bukan sejak diajukan
+	if status == domain.AccessRequestApproved {
+		expiresAt := now.Add(time.Duration(accessRequest.DurationMinutes) * time.Minute)
+		accessRequest.ExpiresAt = &expiresAt
+	}
+
+	err = repoTx.UpdateStatus(ctx, accessRequest)
+	if err != nil {
+		return err
+	}
+
+	if status == domain.AccessRequestApproved {
+		// sama seperti assign langsung, reviewer tidak bisa memberikan akses yang tidak dia miliki
+		var roleIDs, permissionIDs []uuid.UUID
+		if accessRequest.Type == "permission" {
+			permissionIDs = []uuid.UUID{accessRequest.TargetID}
+		} else {
+			roleIDs = []uuid.UUID{accessRequest.TargetID}
+		}
+		err = checkHeldGrants(ctx, service.userRepository, reviewerID, roleIDs, permissionIDs)
+		if err != nil {
+			return err
+		}
+
+		userTx := service.userRepository.WithTx(tx)
+		err = userTx.GrantTemporaryAssignment(ctx, accessRequest.UserID, accessRequest.Type, accessRequest.TargetID, *accessRequest.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		err = userTx.BumpVersion(ctx, accessRequest.UserID, 0)
+		if err != nil {
+			return err
+		}
+
+		// elevasi sementara juga tidak boleh melanggar separation of duties
+		err = checkSoDViolations(ctx, service.sodConstraintRepository.WithTx(tx), accessRequest.UserID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = service.auditRepository.WithTx(tx).Create(ctx, &domain.AuditLog{
+		ActorID:     &reviewerID,
+		Action:      "access_request." + status,
+		SubjectType: "access_request",
+		SubjectID:   accessRequest.ID.String(),
+		Metadata:    accessRequestMetadata(accessRequest),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	service.notifyRequester(ctx, accessRequest)
+
+	return nil
+}
+
+// Cancel hanya bisa dilakukan oleh pemilik request selama masih pending
+func (service *accessRequestService) Cancel(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.accessRequestRepository.WithTx(tx)
+
+	accessRequest, err := repoTx.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if accessRequest.UserID != userID {
+		return errors.New("access request not found")
+	}
+
+	accessRequest.Status = domain.AccessRequestCancelled
+	accessRequest.UpdatedAt = time.Now()
+
+	err = repoTx.UpdateStatus(ctx, accessRequest)
+	if err != nil {
+		return err
+	}
+
+	err = service.auditRepository.WithTx(tx).Create(ctx, &domain.AuditLog{
+		ActorID:     &userID,
+		Action:      "access_request.cancelled",
+		SubjectType: "access_request",
+		SubjectID:   accessRequest.ID.String(),
+		Metadata:    accessRequestMetadata(accessRequest),
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (service *accessRequestService) FindByID(ctx context.Context, id uuid.UUID) (*domain.AccessRequest, error) {
+	return service.accessRequestRepository.FindByID(ctx, id)
+}
+
+func (service *accessRequestService) FindAll(ctx context.Context, status string) ([]domain.AccessRequest, error) {
+	switch status {
+	case "", domain.AccessRequestPending, domain.AccessRequestApproved, domain.AccessRequestRejected, domain.AccessRequestCancelled:
+	default:
+		return nil, errors.New("status tidak valid")
+	}
+	return service.accessRequestRepository.FindAll(ctx, status)
+}
+
+func (service *accessRequestService) FindByUserID(ctx context.Context, userID uuid.UUID) ([]domain.AccessRequest, error) {
+	return service.accessRequestRepository.FindByUserID(ctx, userID)
+}
+
+// notifikasi dikirim setelah commit, kegagalan kirim hanya dicatat di log
+func (service *accessRequestService) notifyApprovers(ctx context.Context, accessRequest *domain.AccessRequest) {
+	approvers, err := service.userRepository.FindByPermission(ctx, domain.AccessRequestApprovePermission)
+	if err != nil {
+		slog.Error("Gagal mengambil daftar approver", "error", err)
+		return
+	}
+
+	var to []string
+	for _, approver := range approvers {
+		if approver.ID != accessRequest.UserID {
+			to = append(to, approver.Email)
+		}
+	}
+	if len(to) == 0 {
+		slog.Warn("Tidak ada approver untuk access request", "id", accessRequest.ID.String())
+		return
+	}
+
+	err = service.mailer.Send(ctx, mailer.Message{
+		To:      to,
+		Subject: fmt.Sprintf("Access request baru: %s %s", accessRequest.Type, accessRequest.TargetName),
+		Body: fmt.Sprintf("User %s meminta akses %s \"%s\" selama %d menit.\n\nAlasan: %s\n\nID request: %s",
+			accessRequest.Username, accessRequest.Type, accessRequest.TargetName, accessRequest.DurationMinutes,
+			accessRequest.Justification, accessRequest.ID),
+	})
+	if err != nil {
+		slog.Error("Gagal mengirim notifikasi access request", "error", err)
+	}
+}
+
+func (service *accessRequestService) notifyRequester(ctx context.Context, accessRequest *domain.AccessRequest) {
+	requester, err := service.userRepository.FindByID(ctx, accessRequest.UserID)
+	if err != nil {
+		slog.Error("Gagal mengambil data pemohon access request", "error", err)
+		return
+	}
+
+	body := fmt.Sprintf("Access request %s \"%s\" Anda telah %s.", accessRequest.Type, accessRequest.TargetName, accessRequest.Status)
+	if accessRequest.ExpiresAt != nil {
+		body += fmt.Sprintf("\nAkses berlaku sampai %s.", accessRequest.ExpiresAt.Format(time.RFC1123))
+	}
+	if accessRequest.ReviewNote != "" {
+		body += "\n\nCatatan: " + accessRequest.ReviewNote
+	}
+
+	err = service.mailer.Send(ctx, mailer.Message{
+		To:      []string{requester.Email},
+		Subject: fmt.Sprintf("Access request %s", accessRequest.Status),
+		Body:    body,
+	})
+	if err != nil {
+		slog.Error("Gagal mengirim notifikasi access request", "error", err)
+	}
+}
+
+func accessRequestMetadata(accessRequest *domain.AccessRequest) map[string]any {
+	metadata := map[string]any{
+		"user_id":          accessRequest.UserID.String(),
+		"type":             accessRequest.Type,
+		"target_id":        accessRequest.TargetID.String(),
+		"target_name":      accessRequest.TargetName,
+		"duration_minutes": accessRequest.DurationMinutes,
+		"status":           accessRequest.Status,
+	}
+	if accessRequest.ReviewNote != "" {
+		metadata["note"] = accessRequest.ReviewNote
+	}
+	if accessRequest.ExpiresAt != nil {
+		metadata["expires_at"] = accessRequest.ExpiresAt
+	}
+	return metadata
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/pkg/expr"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type authzService struct {
+	permissionRepository domain.PermissionRepository
+	userRepository domain.UserRepository
+	organizationRepository domain.OrganizationRepository
+	validate *validator.Validate
+}
+
+func NewAuthzService(permissionRepository domain.PermissionRepository, userRepository domain.UserRepository, organizationRepository domain.OrganizationRepository, validate *validator.Validate) domain.AuthzService {
+	return &authzService{
+		permissionRepository: permissionRepository,
+		userRepository: userRepository,
+		organizationRepository: organizationRepository,
+		validate: validate,
+	}
+}
+
+func (service *authzService) Explain(ctx context.Context, req domain.AuthzExplainRequest) (*domain.AuthzExplanation, error) {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// pastikan user ada, supaya "denied" tidak tertukar dengan salah ketik user_id
+	_, err = service.userRepository.FindByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := service.permissionRepository.FindGrantPathsByUserID(ctx, req.UserID, req.Permission)
+	if err != nil {
+		return nil, err
+	}
+
+	// admin organisasi tidak punya baris grant, jalurnya ditambahkan di sini supaya sama dengan middleware
+	if req.OrganizationID != nil && slices.Contains(domain.OrganizationAdminPermissions, req.Permission) {
+		isAdmin, err := service.isOrganizationAdmin(ctx, *req.OrganizationID, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if isAdmin {
+			grants = append(grants, domain.PermissionGrant{
+				Permission: req.Permission,
+				Source: "organization-admin",
+				Guard: domain.GuardOrDefault(req.Guard),
+				OrganizationID: req.OrganizationID,
+			})
+		}
+	}
+
+	now := time.Now()
+	explanation := &domain.AuthzExplanation{
+		UserID: req.UserID,
+		Permission: req.Permission,
+		Guard: domain.GuardOrDefault(req.Guard),
+		OrganizationID: req.OrganizationID,
+		Decision: domain.AuthzDecisionDenied,
+		Steps: make([]domain.AuthzStep, 0, len(grants)),
+		EvaluatedAt: now,
+	}
+
+	for _, grant := range grants {
+		step := explainGrant(grant, explanation.Guard, req.OrganizationID, now)
+		explanation.Steps = append(explanation.Steps, step)
+
+		switch step.Status {
+		case domain.AuthzStepGranted:
+			explanation.Decision = domain.AuthzDecisionGranted
+		case domain.AuthzStepConditional:
+			if explanation.Decision == domain.AuthzDecisionDenied {
+				explanation.Decision = domain.AuthzDecisionConditional
+			}
+		}
+	}
+
+	switch explanation.Decision {
+	case domain.AuthzDecisionGranted:
+		explanation.Reason = "User memiliki permission ini lewat minimal satu jalur yang aktif"
+	case domain.AuthzDecisionConditional:
+		explanation.Reason = "Permission hanya diberikan lewat grant bersyarat, hasilnya tergantung atribut request saat diakses"
+	default:
+		if len(grants) == 0 {
+			explanation.Reason = "User tidak pernah diberi permission ini, baik langsung, lewat role, maupun lewat organisasi"
+		} else {
+			explanation.Reason = "Semua jalur pemberian permission ini sedang tidak berlaku, lihat status tiap langkah"
+		}
+	}
+
+	return explanation, nil
+}
+
+// explainGrant menentukan status satu jalur grant dengan urutan yang sama seperti PermissionMiddleware:
+// guard dulu, lalu masa berlaku, organisasi aktif, dan terakhir kondisi
+func explainGrant(grant domain.PermissionGrant, guard string, orgID *uuid.UUID, now time.Time) domain.AuthzStep {
+	step := domain.AuthzStep{PermissionGrant: grant}
+	via := describeGrantSource(grant)
+
+	switch {
+	case grant.Guard != guard:
+		step.Status = domain.AuthzStepOtherGuard
+		step.Reason = fmt.Sprintf("%s milik guard %s, sedangkan pengecekan memakai guard %s", via, grant.Guard, guard)
+
+	case grant.StartsAt != nil && grant.StartsAt.After(now):
+		step.Status = domain.AuthzStepPending
+		step.Reason = fmt.Sprintf("%s belum berlaku sampai %s", via, grant.StartsAt.Format(time.RFC3339))
+
+	case grant.ExpiresAt != nil && !grant.ExpiresAt.After(now):
+		step.Status = domain.AuthzStepExpired
+		step.Reason = fmt.Sprintf("%s sudah kedaluwarsa sejak %s", via, grant.ExpiresAt.Format(time.RFC3339))
+
+	case grant.OrganizationID != nil && (orgID == nil || *orgID != *grant.OrganizationID):
+		step.Status = domain.AuthzStepOrgInactive
+		step.Reason = fmt.Sprintf("%s hanya berlaku di route organisasi saat organisasi %s aktif (header X-Organization-ID atau token organisasi)", via, grant.OrganizationID)
+
+	case grant.OrganizationID != nil && len(grant.Conditions) == 0:
+		step.Status = domain.AuthzStepGranted
+		step.Reason = fmt.Sprintf("%s aktif, hanya untuk route organisasi (tidak berlaku di route platform)", via)
+
+	case len(grant.Conditions) > 0:
+		step.Status = domain.AuthzStepConditional
+		step.Reason = fmt.Sprintf("%s hanya berlaku jika kondisi terpenuhi: %s", via, strings.Join(grant.Conditions, " && "))
+		for _, condition := range grant.Conditions {
+			// kondisi yang gagal di-compile selalu ditolak middleware (fail closed)
+			if _, err := expr.Compile(condition); err != nil {
+				step.Status = domain.AuthzStepInvalidCondition
+				step.Reason = fmt.Sprintf("%s punya kondisi yang tidak valid (%s), grant ini tidak pernah berlaku", via, err)
+				break
+			}
+		}
+
+	default:
+		step.Status = domain.AuthzStepGranted
+		step.Reason = fmt.Sprintf("%s aktif", via)
+	}
+
+	return step
+}
+
+func describeGrantSource(grant domain.PermissionGrant) string {
+	switch grant.Source {
+	case "role":
+		return fmt.Sprintf("Grant lewat role %s", grant.RoleName)
+	case "organization-role":
+		return fmt.Sprintf("Grant lewat role %s di organisasi", grant.RoleName)
+	case "organization-direct":
+		return "Grant langsung di organisasi"
+	case "organization-admin":
+		return "Status admin organisasi"
+	default:
+		return "Grant langsung"
+	}
+}
+
+func (service *authzService) Check(ctx context.Context, req domain.AuthzCheckRequest, checker domain.AuthzChecker) ([]domain.AuthzCheckResult, error) {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// query yang sama cukup dicek sekali
+	cache := make(map[domain.AuthzCheckQuery]bool, len(req.Checks))
+	results := make([]domain.AuthzCheckResult, 0, len(req.Checks))
+	for _, query := range req.Checks {
+		allowed, ok := cache[query]
+		if !ok {
+			allowed, err = checker(query)
+			if err != nil {
+				return nil, err
+			}
+			cache[query] = allowed
+		}
+
+		results = append(results, domain.AuthzCheckResult{
+			AuthzCheckQuery: query,
+			Allowed: allowed,
+		})
+	}
+
+	return results, nil
+}
+
+func (service *authzService) EffectivePermissions(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID) (*domain.EffectivePermissions, error) {
+
+	guard := domain.GuardFromContext(ctx)
+
+	permissions, err := service.permissionRepository.GetPermissionsByUserID(ctx, userID, guard)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := &domain.EffectivePermissions{
+		Guard: guard,
+		OrganizationID: orgID,
+		Permissions: uniqueSorted(permissions),
+	}
+
+	// grant organisasi dipisah karena hanya berlaku di route organisasi
+	if orgID != nil {
+		orgPermissions, err := service.permissionRepository.GetPermissionsByUserIDInOrganization(ctx, userID, *orgID, guard)
+		if err != nil {
+			return nil, err
+		}
+
+		isAdmin, err := service.isOrganizationAdmin(ctx, *orgID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if isAdmin {
+			orgPermissions = append(orgPermissions, domain.OrganizationAdminPermissions...)
+		}
+		effective.OrganizationPermissions = uniqueSorted(orgPermissions)
+	}
+
+	return effective, nil
+}
+
+func (service *authzService) isOrganizationAdmin(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) (bool, error) {
+	member, err := service.organizationRepository.FindMember(ctx, orgID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return member.IsAdmin, nil
+}
+
+func uniqueSorted(values []string) []string {
+	sort.Strings(values)
+	unique := make([]string, 0, len(values))
+	for i, value := range values {
+		if i == 0 || value != values[i-1] {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"golang-auth/internal/domain"
+	"golang-auth/internal/pkg/mailer"
+	"log/slog"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// masa berlaku undangan kalau expires_in_hours tidak diisi
+const defaultInvitationTTL = 72 * time.Hour
+
+type invitationService struct {
+	invitationRepository domain.InvitationRepository
+	userRepository       domain.UserRepository
+	userService          domain.UserService
+	auditRepository      domain.AuditRepository
+	mailer               mailer.Mailer
+	db                   *sql.DB
+	validate             *validator.Validate
+}
+
+func NewInvitationService(invitationRepository domain.InvitationRepository, userRepository domain.UserRepository, userService domain.UserService, auditRepository domain.AuditRepository, mailer mailer.Mailer, db *sql.DB, validate *validator.Validate) domain.InvitationService {
+	return &invitationService{
+		invitationRepository: invitationRepository,
+		userRepository:       userRepository,
+		userService:          userService,
+		auditRepository:      auditRepository,
+		mailer:               mailer,
+		db:                   db,
+		validate:             validate,
+	}
+}
+
+func (service *invitationService) Create(ctx context.Context, inviterID uuid.UUID, req domain.InvitationCreateRequest) (*domain.Invitation, error) {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// pengundang hanya boleh memberikan role & permission yang dia punya sendiri
+	err = checkHeldGrants(ctx, service.userRepository, inviterID, req.RoleIDs, req.PermissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = service.userRepository.FindByEmail(ctx, req.Email)
+	if err == nil {
+		return nil, errors.New("Email sudah digunakan")
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.invitationRepository.WithTx(tx)
+
+	pending, err := repoTx.ExistsPending(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, errors.New("email ini masih punya undangan yang belum diterima, batalkan dulu undangan sebelumnya")
+	}
+
+	token, err := generateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := defaultInvitationTTL
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	uuid7, _ := uuid.NewV7()
+	now := time.Now()
+
+	invitation := &domain.Invitation{
+		ID:            uuid7,
+		Email:         req.Email,
+		TokenHash:     hashToken(token),
+		InviterID:     &inviterID,
+		Guard:         domain.GuardFromContext(ctx),
+		RoleIDs:       nonNilUUIDs(req.RoleIDs),
+		PermissionIDs: nonNilUUIDs(req.PermissionIDs),
+		Status:        domain.InvitationPending,
+		ExpiresAt:     now.Add(ttl),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	err = repoTx.Create(ctx, invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.auditRepository.WithTx(tx).Create(ctx, &domain.AuditLog{
+		ActorID:     &inviterID,
+		Action:      "invitation.created",
+		SubjectType: "invitation",
+		SubjectID:   invitation.ID.String(),
+		Metadata:    invitationMetadata(invitation),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	// token hanya ada di email, response API tidak pernah memuatnya
+	err = service.mailer.Send(ctx, mailer.Message{
+		To:      []string{invitation.Email},
+		Subject: "Undangan bergabung",
+		Body: fmt.Sprintf("Halo,\n\nAnda diundang untuk membuat akun. Gunakan token berikut (berlaku sampai %s):\n\n%s\n\nKirim token ini beserta username & password ke POST /api/v1/invitations/accept.",
+			invitation.ExpiresAt.Format(time.RFC1123), token),
+	})
+	if err != nil {
+		slog.Error("Gagal mengirim email undangan", "invitation_id", invitation.ID.String(), "error", err)
+	}
+
+	return invitation, nil
+}
+
+// Accept membuat user lewat userService.CreateWith, jadi validasi, cek email, scope role & SoD sama persis
+// dengan pembuatan user oleh admin. role & permission dicek ulang terhadap grant pengundang saat ini, dan
+// undangan ditandai diterima di transaksi yang sama dengan insert user, jadi undangan yang dibatalkan
+// atau dipakai request lain di tengah jalan tidak menghasilkan akun
+func (service *invitationService) Accept(ctx context.Context, req domain.InvitationAcceptRequest) (*domain.User, error) {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := service.invitationRepository.FindByTokenHash(ctx, hashToken(req.Token))
+	if err != nil {
+		return nil, errors.New("token undangan tidak valid")
+	}
+	if invitation.Status != domain.InvitationPending {
+		return nil, fmt.Errorf("undangan sudah %s", invitation.Status)
+	}
+
+	// tanpa pengundang tidak ada yang bisa menjamin role & permission di undangan
+	if invitation.InviterID == nil && (len(invitation.RoleIDs) > 0 || len(invitation.PermissionIDs) > 0) {
+		return nil, errors.New("pengundang sudah tidak ada, minta undangan baru")
+	}
+
+	var userID uuid.UUID
+	err = service.userService.CreateWith(domain.WithGuard(ctx, invitation.Guard), domain.UserCreateRequest{
+		Username:      req.Username,
+		Email:         invitation.Email,
+		Password:      req.Password,
+		RoleIDs:       invitation.RoleIDs,
+		PermissionIDs: invitation.PermissionIDs,
+		Attributes:    req.Attributes,
+		ActorID:       invitation.InviterID,
+		SelfService:   true,
+	}, func(tx *sql.Tx, user *domain.User) error {
+		now := time.Now()
+		invitation.Status = domain.InvitationAccepted
+		invitation.AcceptedUserID = &user.ID
+		invitation.AcceptedAt = &now
+		invitation.UpdatedAt = now
+
+		// hanya mengubah undangan yang masih pending
+		err := service.invitationRepository.WithTx(tx).UpdateStatus(ctx, invitation)
+		if err != nil {
+			return err
+		}
+
+		userID = user.ID
+		return service.auditRepository.WithTx(tx).Create(ctx, &domain.AuditLog{
+			ActorID:     &user.ID,
+			Action:      "invitation.accepted",
+			SubjectType: "invitation",
+			SubjectID:   invitation.ID.String(),
+			Metadata:    invitationMetadata(invitation),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return service.userRepository.FindByID(ctx, userID)
+}
+
+func (service *invitationService) Revoke(ctx context.Context, id uuid.UUID, actorID uuid.UUID) error {
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.invitationRepository.WithTx(tx)
+
+	invitation, err := repoTx.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if invitation.Status != domain.InvitationPending {
+		return fmt.Errorf("undangan sudah %s", invitation.Status)
+	}
+
+	now := time.Now()
+	invitation.Status = domain.InvitationRevoked
+	invitation.RevokedAt = &now
+	invitation.UpdatedAt = now
+
+	err = repoTx.UpdateStatus(ctx, invitation)
+	if err != nil {
+		return err
+	}
+
+	err = service.auditRepository.WithTx(tx).Create(ctx, &domain.AuditLog{
+		ActorID:     &actorID,
+		Action:      "invitation.revoked",
+		SubjectType: "invitation",
+		SubjectID:   invitation.ID.String(),
+		Metadata:    invitationMetadata(invitation),
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (service *invitationService) FindAll(ctx context.Context, status string) ([]domain.Invitation, error) {
+	switch status {
+	case "", domain.InvitationPending, domain.InvitationAccepted, domain.InvitationRevoked, domain.InvitationExpired:
+	default:
+		return nil, errors.New("status tidak valid")
+	}
+	return service.invitationRepository.FindAll(ctx, status)
+}
+
+// checkHeldGrants menolak role / permission global yang tidak dimiliki actor (tanpa syarat, sedang berlaku & pada guard aktif),
+// dipakai saat undangan, pembuatan user dan import supaya tidak ada yang bisa memberi lebih dari yang dia punya
+func checkHeldGrants(ctx context.Context, userRepository domain.UserRepository, actorID uuid.UUID, roleIDs []uuid.UUID, permissionIDs []uuid.UUID) error {
+	if len(roleIDs) > 0 {
+		held, err := userRepository.FindHeldRoleIDs(ctx, actorID, domain.GuardFromContext(ctx))
+		if err != nil {
+			return err
+		}
+		if missing := missingIDs(roleIDs, held); len(missing) > 0 {
+			return fmt.Errorf("tidak bisa memberikan role yang tidak Anda miliki: %v", missing)
+		}
+	}
+
+	if len(permissionIDs) > 0 {
+		held, err := userRepository.FindHeldPermissionIDs(ctx, actorID, domain.GuardFromContext(ctx))
+		if err != nil {
+			return err
+		}
+		if missing := missingIDs(permissionIDs, held); len(missing) > 0 {
+			return fmt.Errorf("tidak bisa memberikan permission yang tidak Anda miliki: %v", missing)
+		}
+	}
+
+	return nil
+}
+
+// id di requested yang tidak ada di held
+func missingIDs(requested []uuid.UUID, held []uuid.UUID) []uuid.UUID {
+	heldSet := make(map[uuid.UUID]bool, len(held))
+	for _, id := range held {
+		heldSet[id] = true
+	}
+
+	var missing []uuid.UUID
+	for _, id := range requested {
+		if !heldSet[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+func nonNilUUIDs(ids []uuid.UUID) []uuid.UUID {
+	if ids == nil {
+		return []uuid.UUID{}
+	}
+	return ids
+}
+
+func invitationMetadata(invitation *domain.Invitation) map[string]any {
+	metadata := map[string]any{
+		"email":          invitation.Email,
+		"role_ids":       invitation.RoleIDs,
+		"permission_ids": invitation.PermissionIDs,
+		"status":         invitation.Status,
+		"expires_at":     invitation.ExpiresAt,
+	}
+	if invitation.AcceptedUserID != nil {
+		metadata["accepted_user_id"] = invitation.AcceptedUserID.String()
+	}
+	return metadata
+}
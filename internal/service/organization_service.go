@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"golang-auth/internal/domain"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type organizationService struct {
+	organizationRepository  domain.OrganizationRepository
+	roleRepository          domain.RoleRepository
+	userRepository          domain.UserRepository
+	sodConstraintRepository domain.SoDConstraintRepository
+	db                      *sql.DB
+	validate                *validator.Validate
+}
+
+func NewOrganizationService(organizationRepository domain.OrganizationRepository, roleRepository domain.RoleRepository, userRepository domain.UserRepository, sodConstraintRepository domain.SoDConstraintRepository, db *sql.DB, validate *validator.Validate) domain.OrganizationService {
+	return &organizationService{
+		organizationRepository:  organizationRepository,
+		roleRepository:          roleRepository,
+		userRepository:          userRepository,
+		sodConstraintRepository: sodConstraintRepository,
+		db:                      db,
+		validate:                validate,
+	}
+}
+
+func (service *organizationService) Create(ctx context.Context, req domain.OrganizationCreateRequest) (*domain.Organization, error) {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	uuid7, _ := uuid.NewV7()
+	now := time.Now()
+
+	org := &domain.Organization{
+		ID:        uuid7,
+		Name:      req.Name,
+		Slug:      req.Slug,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = service.organizationRepository.Create(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func (service *organizationService) Update(ctx context.Context, req domain.OrganizationUpdateRequest) error {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	return service.organizationRepository.Update(ctx, &domain.Organization{
+		ID:        req.ID,
+		Name:      req.Name,
+		Slug:      req.Slug,
+		UpdatedAt: time.Now(),
+	})
+}
+
+func (service *organizationService) Delete(ctx context.Context, id uuid.UUID) error {
+	return service.organizationRepository.Delete(ctx, id)
+}
+
+func (service *organizationService) FindByID(ctx context.Context, id uuid.UUID) (*domain.Organization, error) {
+	return service.organizationRepository.FindByID(ctx, id)
+}
+
+func (service *organizationService) FindAll(ctx context.Context) ([]domain.Organization, error) {
+	return service.organizationRepository.FindAll(ctx)
+}
+
+func (service *organizationService) FindByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Organization, error) {
+	return service.organizationRepository.FindByUserID(ctx, userID)
+}
+
+func (service *organizationService) AddMember(ctx context.Context, orgID uuid.UUID, req domain.OrganizationMemberRequest) error {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	// pastikan organisasinya ada, biar pesan error-nya jelas (bukan error foreign key)
+	_, err = service.organizationRepository.FindByID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	return service.organizationRepository.AddMember(ctx, orgID, req.UserID, req.IsAdmin)
+}
+
+func (service *organizationService) RemoveMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) error {
+	return service.organizationRepository.RemoveMember(ctx, orgID, userID)
+}
+
+func (service *organizationService) FindMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) (*domain.OrganizationMember, error) {
+	member, err := service.organizationRepository.FindMember(ctx, orgID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("user bukan anggota organisasi ini")
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+func (service *organizationService) FindMembers(ctx context.Context, orgID uuid.UUID) ([]domain.OrganizationMember, error) {
+	return service.organizationRepository.FindMembers(ctx, orgID)
+}
+
+func (service *organizationService) IsMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) (bool, error) {
+	_, err := service.organizationRepository.FindMember(ctx, orgID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (service *organizationService) IsAdmin(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) (bool, error) {
+	member, err := service.organizationRepository.FindMember(ctx, orgID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return member.IsAdmin, nil
+}
+
+func (service *organizationService) AssignRoles(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, actorID uuid.UUID, req domain.AssignRoleRequest) error {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	// kondisi ABAC & masa berlaku hanya didukung untuk assignment global
+	if len(req.Conditions) > 0 {
+		return errors.New("kondisi belum didukung untuk assignment organisasi")
+	}
+	if len(req.Schedules) > 0 {
+		return errors.New("masa berlaku belum didukung untuk assignment organisasi")
+	}
+
+	// role global tidak boleh diberikan lewat organisasi, dan isi role-nya harus dimiliki pemberi
+	var permissionIDs []uuid.UUID
+	for _, roleID := range req.RoleIDs {
+		role, err := service.roleRepository.FindById(ctx, roleID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("role %s tidak ditemukan", roleID)
+			}
+			return err
+		}
+		if role.OrganizationID == nil || *role.OrganizationID != orgID {
+			return fmt.Errorf("role %s bukan milik organisasi ini", role.Name)
+		}
+		for _, permission := range role.Permissions {
+			permissionIDs = append(permissionIDs, permission.ID)
+		}
+	}
+
+	err = service.checkGrantable(ctx, orgID, actorID, permissionIDs)
+	if err != nil {
+		return err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.organizationRepository.WithTx(tx)
+
+	// role organisasi hanya bisa diberikan ke member organisasi tersebut
+	_, err = repoTx.FindMember(ctx, orgID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user bukan anggota organisasi ini")
+		}
+		return err
+	}
+
+	// remove all roles
+	err = repoTx.RemoveAllRoles(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	// add all roles
+	err = repoTx.AssignRoles(ctx, orgID, userID, req.RoleIDs)
+	if err != nil {
+		return err
+	}
+
+	err = checkSoDViolations(ctx, service.sodConstraintRepository.WithTx(tx), userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (service *organizationService) AssignPermissions(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, actorID uuid.UUID, req domain.AssignPermissionRequest) error {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	// kondisi ABAC & masa berlaku hanya didukung untuk assignment global
+	if len(req.Conditions) > 0 {
+		return errors.New("kondisi belum didukung untuk assignment organisasi")
+	}
+	if len(req.Schedules) > 0 {
+		return errors.New("masa berlaku belum didukung untuk assignment organisasi")
+	}
+
+	err = service.checkGrantable(ctx, orgID, actorID, req.PermissionIDs)
+	if err != nil {
+		return err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.organizationRepository.WithTx(tx)
+
+	_, err = repoTx.FindMember(ctx, orgID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user bukan anggota organisasi ini")
+		}
+		return err
+	}
+
+	// remove all permission
+	err = repoTx.RemoveAllPermissions(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	// add all permission
+	err = repoTx.AssignPermissions(ctx, orgID, userID, req.PermissionIDs)
+	if err != nil {
+		return err
+	}
+
+	err = checkSoDViolations(ctx, service.sodConstraintRepository.WithTx(tx), userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkGrantable menolak permission yang tidak dimiliki pemberi, baik secara global maupun di organisasi ini,
+// supaya admin organisasi tidak bisa memberikan lebih dari yang dia punya
+func (service *organizationService) checkGrantable(ctx context.Context, orgID uuid.UUID, actorID uuid.UUID, permissionIDs []uuid.UUID) error {
+	if len(permissionIDs) == 0 {
+		return nil
+	}
+
+	guard := domain.GuardFromContext(ctx)
+	held, err := service.userRepository.FindHeldPermissionIDs(ctx, actorID, guard)
+	if err != nil {
+		return err
+	}
+	orgHeld, err := service.organizationRepository.FindHeldPermissionIDs(ctx, orgID, actorID, guard)
+	if err != nil {
+		return err
+	}
+
+	if missing := missingIDs(permissionIDs, append(held, orgHeld...)); len(missing) > 0 {
+		return fmt.Errorf("tidak bisa memberikan permission yang tidak Anda miliki: %v", missing)
+	}
+	return nil
+}
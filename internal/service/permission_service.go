@@ -3,31 +3,376 @@ package service
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"golang-auth/internal/domain"
+	"golang-auth/internal/pkg/expr"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
 
 type permissionService struct {
 	permissionRepository domain.PermissionRepository
+	userRepository domain.UserRepository
+	userAttributeRepository domain.UserAttributeRepository
+	routeCatalog domain.RouteCatalog
 	db *sql.DB
+	validate *validator.Validate
+	conditions *expr.Cache
 }
 
-func NewPermissionService(permissionRepository domain.PermissionRepository, db *sql.DB) domain.PermissionService {
+func NewPermissionService(permissionRepository domain.PermissionRepository, userRepository domain.UserRepository, userAttributeRepository domain.UserAttributeRepository, routeCatalog domain.RouteCatalog, db *sql.DB, validate *validator.Validate) domain.PermissionService {
 	return &permissionService{
 		permissionRepository: permissionRepository,
+		userRepository: userRepository,
+		userAttributeRepository: userAttributeRepository,
+		routeCatalog: routeCatalog,
 		db: db,
+		validate: validate,
+		conditions: expr.NewCache(),
 	}
 }
 
+func (service permissionService) Create(ctx context.Context, req domain.PermissionCreateRequest) (*domain.Permission, error) {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	uuid7, _ := uuid.NewV7()
+	now := time.Now()
+
+	permission := &domain.Permission{
+		ID: uuid7,
+		Name: req.Name,
+		Guard: domain.GuardOrDefault(req.Guard),
+		Description: req.Description,
+		Group: req.Group,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = service.permissionRepository.Create(ctx, permission)
+	if err != nil {
+		return nil, err
+	}
+
+	return permission, nil
+}
+
+func (service permissionService) Update(ctx context.Context, req domain.PermissionUpdateRequest) error {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	existing, err := service.permissionRepository.FindByID(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	// rename permission yang dipakai route akan membuat route tersebut tidak bisa diakses siapapun
+	if existing.Name != req.Name && service.routeCatalog.HasPermission(existing.Name) {
+		return fmt.Errorf("permission %s dipakai untuk memproteksi route dan tidak bisa diganti namanya", existing.Name)
+	}
+
+	// pindah guard akan membuat role yang memakainya berisi permission dari guard lain
+	guard := domain.GuardOrDefault(req.Guard)
+	if existing.Guard != guard {
+		return fmt.Errorf("guard permission %s tidak bisa diubah, buat permission baru untuk guard %s", existing.Name, guard)
+	}
+
+	return service.permissionRepository.Update(ctx, &domain.Permission{
+		ID: req.ID,
+		Name: req.Name,
+		Guard: guard,
+		Description: req.Description,
+		Group: req.Group,
+		UpdatedAt: time.Now(),
+	})
+}
+
+func (service permissionService) Delete(ctx context.Context, id uuid.UUID) error {
+
+	existing, err := service.permissionRepository.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if service.routeCatalog.HasPermission(existing.Name) {
+		return fmt.Errorf("permission %s dipakai untuk memproteksi route dan tidak bisa dihapus", existing.Name)
+	}
+
+	return service.permissionRepository.Delete(ctx, id)
+}
+
+func (service permissionService) FindByID(ctx context.Context, id uuid.UUID) (*domain.Permission, error) {
+	return service.permissionRepository.FindByID(ctx, id)
+}
+
 func (service permissionService) FindAll(ctx context.Context) ([]domain.Permission, error) {
 	return service.permissionRepository.FindAll(ctx)
 }
 
+func (service permissionService) FindPage(ctx context.Context, spec domain.QuerySpec) ([]domain.Permission, *domain.PageMeta, error) {
+	return service.permissionRepository.FindPage(ctx, spec)
+}
+
+// FindAllGrouped mengelompokkan permission berdasarkan group, atau prefix resource-nya
+// kalau group kosong (roles:view & roles:manage -> roles)
+func (service permissionService) FindAllGrouped(ctx context.Context) ([]domain.PermissionGroup, error) {
+	permissions, err := service.permissionRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []domain.PermissionGroup{}
+	index := make(map[string]int)
+	for _, permission := range permissions {
+		group := permission.Group
+		if group == "" {
+			group, _, _ = strings.Cut(permission.Name, ":")
+		}
+
+		i, ok := index[group]
+		if !ok {
+			i = len(groups)
+			index[group] = i
+			groups = append(groups, domain.PermissionGroup{Group: group})
+		}
+		groups[i].Permissions = append(groups[i].Permissions, permission)
+	}
+
+	sort.Slice(groups, func(a, b int) bool {
+		return groups[a].Group < groups[b].Group
+	})
+
+	return groups, nil
+}
+
+func (service permissionService) SyncRoutePermissions(ctx context.Context) (*domain.PermissionSyncResult, error) {
+
+	permissions, err := service.permissionRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// guard -> nama permission yang sudah ada
+	existing := make(map[string]map[string]bool, len(domain.Guards))
+	for _, guard := range domain.Guards {
+		existing[guard] = make(map[string]bool)
+	}
+	for _, permission := range permissions {
+		if existing[permission.Guard] != nil {
+			existing[permission.Guard][permission.Name] = true
+		}
+	}
+
+	result := &domain.PermissionSyncResult{
+		Created: []string{},
+		Orphans: []string{},
+	}
+
+	// permission yang dipakai route tapi belum ada di database dibuat otomatis, di setiap guard
+	// karena route yang sama bisa diakses lewat guard manapun
+	for _, guard := range domain.Guards {
+		for _, name := range service.routeCatalog.Permissions() {
+			if existing[guard][name] {
+				continue
+			}
+
+			uuid7, _ := uuid.NewV7()
+			now := time.Now()
+			err = service.permissionRepository.Create(ctx, &domain.Permission{
+				ID: uuid7,
+				Name: name,
+				Guard: guard,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+			if err != nil {
+				return nil, err
+			}
+			result.Created = append(result.Created, fmt.Sprintf("%s (%s)", name, guard))
+		}
+	}
+
+	for _, permission := range permissions {
+		if !service.routeCatalog.HasPermission(permission.Name) {
+			result.Orphans = append(result.Orphans, fmt.Sprintf("%s (%s)", permission.Name, permission.Guard))
+		}
+	}
+
+	return result, nil
+}
+
+// FindOrphanPermissions mengembalikan permission di database yang tidak memproteksi route manapun.
+// orphan belum tentu salah (misal dipakai lewat RequireResource di service lain), hanya ditandai
+func (service permissionService) FindOrphanPermissions(ctx context.Context) ([]domain.Permission, error) {
+	permissions, err := service.permissionRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := []domain.Permission{}
+	for _, permission := range permissions {
+		if !service.routeCatalog.HasPermission(permission.Name) {
+			orphans = append(orphans, permission)
+		}
+	}
+
+	return orphans, nil
+}
+
 func (service permissionService) GetPermissionsByUserID(ctx context.Context, userID uuid.UUID) ([]string, error) {
-	return service.permissionRepository.GetPermissionsByUserID(ctx, userID)
+	return service.permissionRepository.GetPermissionsByUserID(ctx, userID, domain.GuardFromContext(ctx))
 }
 
 func (service permissionService) GetPermissionsByRoleIDs(ctx context.Context, roleID []uuid.UUID) ([]string, error) {
-	return service.permissionRepository.GetPermissionsByRoleIDs(ctx, roleID)
+	return service.permissionRepository.GetPermissionsByRoleIDs(ctx, roleID, domain.GuardFromContext(ctx))
+}
+
+func (service permissionService) GetPermissionsByUserIDInOrganization(ctx context.Context, userID uuid.UUID, orgID uuid.UUID) ([]string, error) {
+	return service.permissionRepository.GetPermissionsByUserIDInOrganization(ctx, userID, orgID, domain.GuardFromContext(ctx))
+}
+
+func (service permissionService) EvaluateConditionalGrants(ctx context.Context, userID uuid.UUID, permission string, attributes map[string]any) (bool, error) {
+
+	grants, err := service.permissionRepository.GetConditionalGrantsByUserID(ctx, userID, permission, domain.GuardFromContext(ctx))
+	if err != nil {
+		return false, err
+	}
+
+	return service.evaluateConditionalGrants(ctx, userID, permission, grants, attributes)
+}
+
+func (service permissionService) EvaluateConditionalGrantsInOrganization(ctx context.Context, userID uuid.UUID, orgID uuid.UUID, permission string, attributes map[string]any) (bool, error) {
+
+	grants, err := service.permissionRepository.GetConditionalGrantsByUserIDInOrganization(ctx, userID, orgID, permission, domain.GuardFromContext(ctx))
+	if err != nil {
+		return false, err
+	}
+
+	return service.evaluateConditionalGrants(ctx, userID, permission, grants, attributes)
+}
+
+// evaluateConditionalGrants: cukup satu grant yang semua kondisinya terpenuhi
+func (service permissionService) evaluateConditionalGrants(ctx context.Context, userID uuid.UUID, permission string, grants []domain.PermissionGrant, attributes map[string]any) (bool, error) {
+	if len(grants) == 0 {
+		return false, nil
+	}
+
+	// atribut user baru diambil kalau memang ada grant bersyarat
+	userAttributes, err := service.userAttributes(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	env := make(map[string]any, len(attributes)+1)
+	for key, value := range attributes {
+		env[key] = value
+	}
+	env["user"] = userAttributes
+
+	for _, grant := range grants {
+		allowed, failedCondition, err := service.evaluateGrant(grant, env)
+
+		slog.Info("AUTHZ_CONDITION",
+			slog.String("user_id", userID.String()),
+			slog.String("permission", permission),
+			slog.String("source", grant.Source),
+			slog.String("role", grant.RoleName),
+			slog.Any("conditions", grant.Conditions),
+			slog.Bool("allowed", allowed),
+			slog.String("failed_condition", failedCondition),
+			slog.Any("error", err),
+		)
+
+		// kondisi yang error dianggap tidak terpenuhi (fail closed), lanjut ke grant berikutnya
+		if err == nil && allowed {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// evaluateGrant: semua kondisi pada satu grant harus true
+func (service permissionService) evaluateGrant(grant domain.PermissionGrant, env map[string]any) (bool, string, error) {
+	for _, condition := range grant.Conditions {
+		program, err := service.conditions.Compile(condition)
+		if err != nil {
+			return false, condition, err
+		}
+
+		ok, err := program.Eval(env)
+		if err != nil {
+			return false, condition, err
+		}
+		if !ok {
+			return false, condition, nil
+		}
+	}
+	return true, "", nil
+}
+
+func (service permissionService) userAttributes(ctx context.Context, userID uuid.UUID) (map[string]any, error) {
+	user, err := service.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]any, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		roles = append(roles, role.Name)
+	}
+
+	attributes := map[string]any{
+		"id":         user.ID.String(),
+		"username":   user.Username,
+		"email":      user.Email,
+		"roles":      roles,
+		"status":     user.Status,
+		"created_at": user.CreatedAt,
+	}
+
+	// atribut profil tambahan, misal user.department. key bawaan di atas tidak bisa dipakai definisi.
+	// atribut yang bisa diisi user sendiri tidak ikut, supaya user tidak bisa memenuhi kondisi dengan mengubah profilnya
+	values, err := service.userAttributeRepository.FindValues(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		if value.UserEditable {
+			continue
+		}
+		attributes[value.Key] = value.Typed()
+	}
+
+	return attributes, nil
+}
+
+// validateConditions memastikan kondisi hanya untuk id yang ikut di-assign dan ekspresinya valid
+func validateConditions(conditions map[uuid.UUID]string, ids []uuid.UUID) error {
+	assigned := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		assigned[id] = true
+	}
+
+	for id, condition := range conditions {
+		if !assigned[id] {
+			return fmt.Errorf("kondisi untuk %s tidak ada di daftar yang di-assign", id)
+		}
+		if _, err := expr.Compile(condition); err != nil {
+			return fmt.Errorf("kondisi untuk %s tidak valid: %s", id, err.Error())
+		}
+	}
+
+	return nil
 }
@@ -16,13 +16,17 @@ import (
 
 type personalAccessTokenService struct {
 	personalAccessTokenRepository domain.PersonalAccessTokenRepository
+	userRepository domain.UserRepository
+	auditRepository domain.AuditRepository
 	db *sql.DB
 	validate *validator.Validate
 }
 
-func NewPersonalAccessTokenService(personalAccessTokenRepository domain.PersonalAccessTokenRepository, db *sql.DB, validate *validator.Validate) domain.PersonalAccessTokenService {
+func NewPersonalAccessTokenService(personalAccessTokenRepository domain.PersonalAccessTokenRepository, userRepository domain.UserRepository, auditRepository domain.AuditRepository, db *sql.DB, validate *validator.Validate) domain.PersonalAccessTokenService {
 	return &personalAccessTokenService{
 		personalAccessTokenRepository: personalAccessTokenRepository,
+		userRepository: userRepository,
+		auditRepository: auditRepository,
 		db: db,
 		validate: validate,
 	}
@@ -36,6 +40,12 @@ func generateSecureToken(length int) (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
+// hashToken: token mentah tidak pernah disimpan, hanya SHA-256 hex-nya
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func (service *personalAccessTokenService) Create(ctx context.Context, req domain.PersonalAccessTokenRequest) (string, time.Time, error) {
 	// validasi struct
 	if err := service.validate.Struct(req); err != nil {
@@ -68,6 +78,8 @@ func (service *personalAccessTokenService) Create(ctx context.Context, req domai
 		ID: tokenID,
 		TokenHash: hashedToken,
 		UserID: req.UserID,
+		OrganizationID: req.OrganizationID,
+		Guard: domain.GuardOrDefault(req.Guard),
 		TokenName: req.TokenName,
 		CreatedAt: now,
 		ExpiresAt: &expiresAt,
@@ -82,6 +94,47 @@ func (service *personalAccessTokenService) Create(ctx context.Context, req domai
 	return rawToken, expiresAt, nil
 }
 
+func (service *personalAccessTokenService) CreateServiceToken(ctx context.Context, req domain.ServiceTokenRequest) (string, time.Time, error) {
+
+	// token internal bertindak sebagai pemiliknya, jadi admin tidak boleh membuat token
+	// untuk user yang punya role / permission internal yang tidak dia miliki sendiri
+	roleIDs, err := service.userRepository.FindHeldRoleIDs(ctx, req.UserID, domain.GuardInternal)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	permissionIDs, err := service.userRepository.FindHeldPermissionIDs(ctx, req.UserID, domain.GuardInternal)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	err = checkHeldGrants(domain.WithGuard(ctx, domain.GuardInternal), service.userRepository, req.ActorID, roleIDs, permissionIDs)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	token, expiresAt, err := service.Create(ctx, domain.PersonalAccessTokenRequest{
+		UserID: req.UserID,
+		TokenName: req.Name,
+		OrganizationID: req.OrganizationID,
+		Guard: domain.GuardInternal,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	err = service.auditRepository.Create(ctx, &domain.AuditLog{
+		ActorID: &req.ActorID,
+		Action: "user.service_token_created",
+		SubjectType: "user",
+		SubjectID: req.UserID.String(),
+		Metadata: map[string]any{"name": req.Name, "organization_id": req.OrganizationID, "expires_at": expiresAt},
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
 func (service *personalAccessTokenService) FindByToken(ctx context.Context, token string) (*domain.PersonalAccessToken, error) {
 	
 	// hash ulang token asli yg dikirim oleh user
@@ -121,6 +174,10 @@ func (service *personalAccessTokenService) DeleteByUserID(ctx context.Context, u
 	return err
 }
 
+func (service *personalAccessTokenService) DeleteOthersByUserID(ctx context.Context, userID uuid.UUID, keepToken string) error {
+	return service.personalAccessTokenRepository.DeleteOthersByUserID(ctx, userID, hashToken(keepToken))
+}
+
 func (service *personalAccessTokenService) UpdateLastUsed(ctx context.Context, token string) error {
 	
 	hasher := sha256.New()
@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"golang-auth/internal/domain"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type rbacPolicyService struct {
+	permissionRepository domain.PermissionRepository
+	roleRepository domain.RoleRepository
+	sodConstraintRepository domain.SoDConstraintRepository
+	db *sql.DB
+}
+
+func NewRBACPolicyService(permissionRepository domain.PermissionRepository, roleRepository domain.RoleRepository, sodConstraintRepository domain.SoDConstraintRepository, db *sql.DB) domain.RBACPolicyService {
+	return &rbacPolicyService{
+		permissionRepository: permissionRepository,
+		roleRepository: roleRepository,
+		sodConstraintRepository: sodConstraintRepository,
+		db: db,
+	}
+}
+
+// rbacState adalah isi database saat plan dihitung, key-nya guard/name
+type rbacState struct {
+	permissions map[string]domain.Permission
+	roles map[string]domain.Role
+	// id role -> nama permission -> kondisi (dipertahankan saat link role-permission ditulis ulang)
+	rolePermissions map[uuid.UUID]map[string]string
+}
+
+func policyKey(guard string, name string) string {
+	return guard + "/" + name
+}
+
+func (service *rbacPolicyService) Export(ctx context.Context) (*domain.RBACPolicy, error) {
+
+	state, err := loadRBACState(ctx, service.permissionRepository, service.roleRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &domain.RBACPolicy{Guards: map[string]domain.RBACGuardPolicy{}}
+
+	permissions := make([]domain.Permission, 0, len(state.permissions))
+	for _, permission := range state.permissions {
+		permissions = append(permissions, permission)
+	}
+	sort.Slice(permissions, func(a, b int) bool {
+		return permissions[a].Name < permissions[b].Name
+	})
+	for _, permission := range permissions {
+		guard := policy.Guards[permission.Guard]
+		guard.Permissions = append(guard.Permissions, domain.RBACPermissionPolicy{
+			Name: permission.Name,
+			Description: permission.Description,
+			Group: permission.Group,
+		})
+		policy.Guards[permission.Guard] = guard
+	}
+
+	roles := make([]domain.Role, 0, len(state.roles))
+	for _, role := range state.roles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(a, b int) bool {
+		return roles[a].Name < roles[b].Name
+	})
+	for _, role := range roles {
+		names := make([]string, 0, len(state.rolePermissions[role.ID]))
+		for name := range state.rolePermissions[role.ID] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		guard := policy.Guards[role.Guard]
+		guard.Roles = append(guard.Roles, domain.RBACRolePolicy{
+			Name: role.Name,
+			Permissions: names,
+		})
+		policy.Guards[role.Guard] = guard
+	}
+
+	return policy, nil
+}
+
+func (service *rbacPolicyService) Plan(ctx context.Context, policy domain.RBACPolicy, prune bool) (*domain.RBACPlan, error) {
+
+	err := validateRBACPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := loadRBACState(ctx, service.permissionRepository, service.roleRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffRBACPolicy(state, policy, prune)
+}
+
+func (service *rbacPolicyService) Apply(ctx context.Context, policy domain.RBACPolicy, prune bool) (*domain.RBACPlan, error) {
+
+	err := validateRBACPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	permissionTx := service.permissionRepository.WithTx(tx)
+	roleTx := service.roleRepository.WithTx(tx)
+	sodTx := service.sodConstraintRepository.WithTx(tx)
+
+	// state dibaca di dalam transaksi supaya plan & eksekusinya konsisten
+	state, err := loadRBACState(ctx, permissionTx, roleTx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := diffRBACPolicy(state, policy, prune)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	// id role -> guard, role yang link permission-nya berubah
+	touchedRoles := make(map[uuid.UUID]string)
+
+	for _, change := range plan.Changes {
+		key := policyKey(change.Guard, change.Name)
+
+		switch change.Kind + ":" + change.Action {
+		case domain.RBACKindPermission + ":" + domain.RBACActionCreate:
+			definition := findPermissionPolicy(policy, change.Guard, change.Name)
+			uuid7, _ := uuid.NewV7()
+			permission := domain.Permission{
+				ID: uuid7,
+				Name: change.Name,
+				Guard: change.Guard,
+				Description: definition.Description,
+				Group: definition.Group,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			err = permissionTx.Create(ctx, &permission)
+			state.permissions[key] = permission
+
+		case domain.RBACKindPermission + ":" + domain.RBACActionUpdate:
+			definition := findPermissionPolicy(policy, change.Guard, change.Name)
+			permission := state.permissions[key]
+			permission.Description = definition.Description
+			permission.Group = definition.Group
+			permission.UpdatedAt = now
+			err = permissionTx.Update(ctx, &permission)
+
+		case domain.RBACKindRole + ":" + domain.RBACActionCreate:
+			uuid7, _ := uuid.NewV7()
+			role := domain.Role{
+				ID: uuid7,
+				Name: change.Name,
+				Guard: change.Guard,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			err = roleTx.Create(ctx, &role)
+			state.roles[key] = role
+			state.rolePermissions[role.ID] = map[string]string{}
+
+		case domain.RBACKindRole + ":" + domain.RBACActionDelete:
+			err = roleTx.Delete(ctx, state.roles[key].ID)
+
+		case domain.RBACKindRolePermission + ":" + domain.RBACActionAttach:
+			roleID := state.roles[key].ID
+			state.rolePermissions[roleID][change.Detail] = ""
+			touchedRoles[roleID] = change.Guard
+
+		case domain.RBACKindRolePermission + ":" + domain.RBACActionDetach:
+			roleID := state.roles[key].ID
+			delete(state.rolePermissions[roleID], change.Detail)
+			touchedRoles[roleID] = change.Guard
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s %s/%s: %w", change.Action, change.Kind, change.Guard, change.Name, err)
+		}
+	}
+
+	// link role-permission ditulis ulang sekali per role, kondisi link lama dipertahankan.
+	// aturan SoD sama seperti lewat API: role tidak boleh berisi permission yang saling konflik
+	// dan pemilik role tidak boleh jadi melanggar constraint
+	for roleID, guard := range touchedRoles {
+		permissionIDs := make([]uuid.UUID, 0, len(state.rolePermissions[roleID]))
+		conditions := make(map[uuid.UUID]string)
+		for name, condition := range state.rolePermissions[roleID] {
+			permission := state.permissions[policyKey(guard, name)]
+			permissionIDs = append(permissionIDs, permission.ID)
+			if condition != "" {
+				conditions[permission.ID] = condition
+			}
+		}
+
+		conflicts, err := sodTx.FindConflicts(ctx, domain.SoDTypePermission, permissionIDs)
+		if err != nil {
+			return nil, err
+		}
+		if len(conflicts) > 0 {
+			return nil, sodConflictError(conflicts[0])
+		}
+
+		err = roleTx.BumpVersion(ctx, roleID, 0)
+		if err != nil {
+			return nil, err
+		}
+		err = roleTx.RemoveAllPermissions(ctx, roleID)
+		if err != nil {
+			return nil, err
+		}
+		err = roleTx.AssignPermission(ctx, roleID, permissionIDs, domain.AssignmentOptions{
+			Conditions: conditions,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		violations, err := sodTx.FindViolationsByRoleID(ctx, roleID)
+		if err != nil {
+			return nil, err
+		}
+		if len(violations) > 0 {
+			return nil, sodViolationError(violations[0])
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+func loadRBACState(ctx context.Context, permissionRepository domain.PermissionRepository, roleRepository domain.RoleRepository) (*rbacState, error) {
+	state := &rbacState{
+		permissions: make(map[string]domain.Permission),
+		roles: make(map[string]domain.Role),
+		rolePermissions: make(map[uuid.UUID]map[string]string),
+	}
+
+	permissions, err := permissionRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, permission := range permissions {
+		state.permissions[policyKey(permission.Guard, permission.Name)] = permission
+	}
+
+	roles, err := roleRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		// role khusus organisasi dikelola per tenant, bukan lewat file policy
+		if role.OrganizationID != nil {
+			continue
+		}
+
+		detail, err := roleRepository.FindById(ctx, role.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		links := make(map[string]string, len(detail.Permissions))
+		for _, permission := range detail.Permissions {
+			links[permission.Name] = permission.Condition
+		}
+
+		state.roles[policyKey(role.Guard, role.Name)] = role
+		state.rolePermissions[role.ID] = links
+	}
+
+	return state, nil
+}
+
+func validateRBACPolicy(policy domain.RBACPolicy) error {
+	for guard, definition := range policy.Guards {
+		if guard == "" || !domain.IsValidGuard(guard) {
+			return fmt.Errorf("guard %q tidak dikenal", guard)
+		}
+
+		permissions := make(map[string]bool)
+		for _, permission := range definition.Permissions {
+			if permission.Name == "" {
+				return fmt.Errorf("guard %s: nama permission wajib diisi", guard)
+			}
+			if permissions[permission.Name] {
+				return fmt.Errorf("guard %s: permission %s ditulis lebih dari sekali", guard, permission.Name)
+			}
+			permissions[permission.Name] = true
+		}
+
+		roles := make(map[string]bool)
+		for _, role := range definition.Roles {
+			if role.Name == "" {
+				return fmt.Errorf("guard %s: nama role wajib diisi", guard)
+			}
+			if roles[role.Name] {
+				return fmt.Errorf("guard %s: role %s ditulis lebih dari sekali", guard, role.Name)
+			}
+			roles[role.Name] = true
+		}
+	}
+	return nil
+}
+
+// diffRBACPolicy membandingkan file dengan database, urutannya: permission, role, link role-permission, prune
+func diffRBACPolicy(state *rbacState, policy domain.RBACPolicy, prune bool) (*domain.RBACPlan, error) {
+	plan := &domain.RBACPlan{
+		Changes: []domain.RBACChange{},
+		Unmanaged: []domain.RBACChange{},
+	}
+
+	guards := make([]string, 0, len(policy.Guards))
+	for guard := range policy.Guards {
+		guards = append(guards, guard)
+	}
+	sort.Strings(guards)
+
+	managedPermissions := make(map[string]bool)
+	managedRoles := make(map[string]bool)
+
+	for _, guard := range guards {
+		definition := policy.Guards[guard]
+
+		for _, permission := range definition.Permissions {
+			key := policyKey(guard, permission.Name)
+			managedPermissions[key] = true
+
+			existing, ok := state.permissions[key]
+			switch {
+			case !ok:
+				plan.Changes = append(plan.Changes, domain.RBACChange{Action: domain.RBACActionCreate, Kind: domain.RBACKindPermission, Guard: guard, Name: permission.Name})
+			case existing.Description != permission.Description || existing.Group != permission.Group:
+				plan.Changes = append(plan.Changes, domain.RBACChange{Action: domain.RBACActionUpdate, Kind: domain.RBACKindPermission, Guard: guard, Name: permission.Name})
+			}
+		}
+
+		for _, role := range definition.Roles {
+			key := policyKey(guard, role.Name)
+			managedRoles[key] = true
+
+			current := map[string]string{}
+			existing, ok := state.roles[key]
+			if ok {
+				current = state.rolePermissions[existing.ID]
+			} else {
+				plan.Changes = append(plan.Changes, domain.RBACChange{Action: domain.RBACActionCreate, Kind: domain.RBACKindRole, Guard: guard, Name: role.Name})
+			}
+
+			wanted := make(map[string]bool, len(role.Permissions))
+			for _, name := range role.Permissions {
+				// permission harus ada di file atau sudah ada di database pada guard yang sama
+				if !managedPermissions[policyKey(guard, name)] {
+					if _, exists := state.permissions[policyKey(guard, name)]; !exists {
+						return nil, fmt.Errorf("role %s/%s memakai permission %s yang tidak ada di guard tersebut", guard, role.Name, name)
+					}
+				}
+
+				wanted[name] = true
+				if _, linked := current[name]; !linked {
+					plan.Changes = append(plan.Changes, domain.RBACChange{Action: domain.RBACActionAttach, Kind: domain.RBACKindRolePermission, Guard: guard, Name: role.Name, Detail: name})
+				}
+			}
+
+			detached := make([]string, 0)
+			for name := range current {
+				if !wanted[name] {
+					detached = append(detached, name)
+				}
+			}
+			sort.Strings(detached)
+			for _, name := range detached {
+				plan.Changes = append(plan.Changes, domain.RBACChange{Action: domain.RBACActionDetach, Kind: domain.RBACKindRolePermission, Guard: guard, Name: role.Name, Detail: name})
+			}
+		}
+	}
+
+	// role & permission di database yang tidak dikelola file
+	unmanagedRoles := make([]domain.Role, 0)
+	for key, role := range state.roles {
+		if !managedRoles[key] {
+			unmanagedRoles = append(unmanagedRoles, role)
+		}
+	}
+	sort.Slice(unmanagedRoles, func(a, b int) bool {
+		return policyKey(unmanagedRoles[a].Guard, unmanagedRoles[a].Name) < policyKey(unmanagedRoles[b].Guard, unmanagedRoles[b].Name)
+	})
+	for _, role := range unmanagedRoles {
+		change := domain.RBACChange{Action: domain.RBACActionDelete, Kind: domain.RBACKindRole, Guard: role.Guard, Name: role.Name}
+		// guard yang tidak tercantum di file tidak disentuh
+		if _, listed := policy.Guards[role.Guard]; prune && listed {
+			plan.Changes = append(plan.Changes, change)
+		} else {
+			change.Action = ""
+			plan.Unmanaged = append(plan.Unmanaged, change)
+		}
+	}
+
+	unmanagedPermissions := make([]string, 0)
+	for key := range state.permissions {
+		if !managedPermissions[key] {
+			unmanagedPermissions = append(unmanagedPermissions, key)
+		}
+	}
+	sort.Strings(unmanagedPermissions)
+	for _, key := range unmanagedPermissions {
+		permission := state.permissions[key]
+		plan.Unmanaged = append(plan.Unmanaged, domain.RBACChange{Kind: domain.RBACKindPermission, Guard: permission.Guard, Name: permission.Name})
+	}
+
+	return plan, nil
+}
+
+func findPermissionPolicy(policy domain.RBACPolicy, guard string, name string) domain.RBACPermissionPolicy {
+	for _, permission := range policy.Guards[guard].Permissions {
+		if permission.Name == name {
+			return permission
+		}
+	}
+	return domain.RBACPermissionPolicy{Name: name}
+}
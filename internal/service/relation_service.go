@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-auth/internal/domain"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// batas kedalaman rekursi, mencegah schema / tuple yang berputar membuat request menggantung
+const maxRelationDepth = 25
+
+type relationService struct {
+	relationTupleRepository domain.RelationTupleRepository
+	schema                  domain.RelationSchema
+	validate                *validator.Validate
+}
+
+func NewRelationService(relationTupleRepository domain.RelationTupleRepository, schema domain.RelationSchema, validate *validator.Validate) domain.RelationService {
+	return &relationService{
+		relationTupleRepository: relationTupleRepository,
+		schema:                  schema,
+		validate:                validate,
+	}
+}
+
+func (service *relationService) Schema() domain.RelationSchema {
+	return service.schema
+}
+
+func (service *relationService) WriteTuple(ctx context.Context, req domain.RelationTupleRequest) error {
+	tuple, err := service.parseTuple(req)
+	if err != nil {
+		return err
+	}
+
+	return service.relationTupleRepository.Write(ctx, &tuple)
+}
+
+func (service *relationService) DeleteTuple(ctx context.Context, req domain.RelationTupleRequest) error {
+	tuple, err := service.parseTuple(req)
+	if err != nil {
+		return err
+	}
+
+	return service.relationTupleRepository.Delete(ctx, &tuple)
+}
+
+func (service *relationService) ReadTuples(ctx context.Context, filter domain.RelationTupleFilter) ([]domain.RelationTuple, error) {
+	return service.relationTupleRepository.Read(ctx, filter)
+}
+
+func (service *relationService) Check(ctx context.Context, req domain.RelationCheckRequest) (bool, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return false, err
+	}
+
+	namespace, objectID, err := domain.ParseObjectRef(req.Object)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := service.rules(namespace, req.Relation); !ok {
+		return false, fmt.Errorf("relasi %s#%s tidak terdaftar di schema", namespace, req.Relation)
+	}
+
+	subject, err := domain.ParseSubjectSet(req.Subject)
+	if err != nil {
+		return false, err
+	}
+
+	return service.check(ctx, namespace, objectID, req.Relation, subject, map[string]bool{}, 0)
+}
+
+func (service *relationService) Expand(ctx context.Context, req domain.RelationExpandRequest) (*domain.ExpandNode, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, objectID, err := domain.ParseObjectRef(req.Object)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := service.rules(namespace, req.Relation); !ok {
+		return nil, fmt.Errorf("relasi %s#%s tidak terdaftar di schema", namespace, req.Relation)
+	}
+
+	return service.expand(ctx, namespace, objectID, req.Relation, 0)
+}
+
+func (service *relationService) ListObjects(ctx context.Context, req domain.RelationListObjectsRequest) ([]string, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := service.rules(req.Namespace, req.Relation); !ok {
+		return nil, fmt.Errorf("relasi %s#%s tidak terdaftar di schema", req.Namespace, req.Relation)
+	}
+
+	subject, err := domain.ParseSubjectSet(req.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	// kandidat: semua object di namespace yang punya tuple, lalu cek satu per satu
+	candidates, err := service.relationTupleRepository.FindObjectIDs(ctx, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []string{}
+	for _, objectID := range candidates {
+		allowed, err := service.check(ctx, req.Namespace, objectID, req.Relation, subject, map[string]bool{}, 0)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			objects = append(objects, req.Namespace+":"+objectID)
+		}
+	}
+
+	return objects, nil
+}
+
+// check menelusuri aturan schema secara rekursif sampai subject ditemukan.
+// visited dipakai sebagai memo, node yang sudah dicek tidak akan dicek ulang
+func (service *relationService) check(ctx context.Context, namespace string, objectID string, relation string, subject domain.SubjectSet, visited map[string]bool, depth int) (bool, error) {
+	if depth > maxRelationDepth {
+		return false, errors.New("penelusuran relasi melebihi batas kedalaman")
+	}
+
+	key := namespace + ":" + objectID + "#" + relation
+	if visited[key] {
+		return false, nil
+	}
+	visited[key] = true
+
+	// subject berupa userset yang sama persis dengan node ini (misal cek group:eng#member)
+	if subject.Namespace == namespace && subject.ID == objectID && subject.Relation == relation {
+		return true, nil
+	}
+
+	rules, ok := service.rules(namespace, relation)
+	if !ok {
+		// relasi tidak terdaftar di namespace tujuan, anggap tidak ada akses
+		return false, nil
+	}
+
+	for _, rule := range rules {
+		switch {
+		case rule.This:
+			tuples, err := service.relationTupleRepository.Read(ctx, domain.RelationTupleFilter{
+				Namespace: namespace,
+				ObjectID:  objectID,
+				Relation:  relation,
+			})
+			if err != nil {
+				return false, err
+			}
+
+			for _, t := range tuples {
+				if t.Subject == subject {
+					return true, nil
+				}
+				// subject berupa userset, telusuri relasi tersebut
+				if t.Subject.Relation != "" {
+					allowed, err := service.check(ctx, t.Subject.Namespace, t.Subject.ID, t.Subject.Relation, subject, visited, depth+1)
+					if err != nil || allowed {
+						return allowed, err
+					}
+				}
+			}
+
+		case rule.ComputedRelation != "":
+			allowed, err := service.check(ctx, namespace, objectID, rule.ComputedRelation, subject, visited, depth+1)
+			if err != nil || allowed {
+				return allowed, err
+			}
+
+		case rule.TupleToUserset != nil:
+			tuples, err := service.relationTupleRepository.Read(ctx, domain.RelationTupleFilter{
+				Namespace: namespace,
+				ObjectID:  objectID,
+				Relation:  rule.TupleToUserset.Tupleset,
+			})
+			if err != nil {
+				return false, err
+			}
+
+			for _, t := range tuples {
+				allowed, err := service.check(ctx, t.Subject.Namespace, t.Subject.ID, rule.TupleToUserset.ComputedRelation, subject, visited, depth+1)
+				if err != nil || allowed {
+					return allowed, err
+				}
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func (service *relationService) expand(ctx context.Context, namespace string, objectID string, relation string, depth int) (*domain.ExpandNode, error) {
+	if depth > maxRelationDepth {
+		return nil, errors.New("penelusuran relasi melebihi batas kedalaman")
+	}
+
+	key := namespace + ":" + objectID + "#" + relation
+	node := &domain.ExpandNode{
+		Type:   "union",
+		Object: key,
+	}
+
+	rules, ok := service.rules(namespace, relation)
+	if !ok {
+		return node, nil
+	}
+
+	for _, rule := range rules {
+		switch {
+		case rule.This:
+			tuples, err := service.relationTupleRepository.Read(ctx, domain.RelationTupleFilter{
+				Namespace: namespace,
+				ObjectID:  objectID,
+				Relation:  relation,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			leaf := domain.ExpandNode{
+				Type:   "this",
+				Object: key,
+			}
+			for _, t := range tuples {
+				leaf.Subjects = append(leaf.Subjects, t.Subject.String())
+				if t.Subject.Relation != "" {
+					child, err := service.expand(ctx, t.Subject.Namespace, t.Subject.ID, t.Subject.Relation, depth+1)
+					if err != nil {
+						return nil, err
+					}
+					leaf.Children = append(leaf.Children, *child)
+				}
+			}
+			node.Children = append(node.Children, leaf)
+
+		case rule.ComputedRelation != "":
+			child, err := service.expand(ctx, namespace, objectID, rule.ComputedRelation, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, domain.ExpandNode{
+				Type:     "computed_relation",
+				Object:   key,
+				Children: []domain.ExpandNode{*child},
+			})
+
+		case rule.TupleToUserset != nil:
+			tuples, err := service.relationTupleRepository.Read(ctx, domain.RelationTupleFilter{
+				Namespace: namespace,
+				ObjectID:  objectID,
+				Relation:  rule.TupleToUserset.Tupleset,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			ttu := domain.ExpandNode{
+				Type:   "tuple_to_userset",
+				Object: namespace + ":" + objectID + "#" + rule.TupleToUserset.Tupleset,
+			}
+			for _, t := range tuples {
+				child, err := service.expand(ctx, t.Subject.Namespace, t.Subject.ID, rule.TupleToUserset.ComputedRelation, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				ttu.Children = append(ttu.Children, *child)
+			}
+			node.Children = append(node.Children, ttu)
+		}
+	}
+
+	return node, nil
+}
+
+// rules mengambil aturan relasi dari schema, relasi tanpa aturan dianggap "this"
+func (service *relationService) rules(namespace string, relation string) ([]domain.RewriteRule, bool) {
+	definition, ok := service.schema[namespace]
+	if !ok {
+		return nil, false
+	}
+
+	rewrite, ok := definition.Relations[relation]
+	if !ok {
+		return nil, false
+	}
+
+	if len(rewrite.Union) == 0 {
+		return []domain.RewriteRule{{This: true}}, true
+	}
+
+	return rewrite.Union, true
+}
+
+// parseTuple memvalidasi request lalu memastikan namespace & relasi ada di schema
+func (service *relationService) parseTuple(req domain.RelationTupleRequest) (domain.RelationTuple, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return domain.RelationTuple{}, err
+	}
+
+	tuple, err := domain.ParseRelationTuple(req.Tuple)
+	if err != nil {
+		return domain.RelationTuple{}, err
+	}
+
+	if _, ok := service.rules(tuple.Namespace, tuple.Relation); !ok {
+		return domain.RelationTuple{}, fmt.Errorf("relasi %s#%s tidak terdaftar di schema", tuple.Namespace, tuple.Relation)
+	}
+	if _, ok := service.schema[tuple.Subject.Namespace]; !ok {
+		return domain.RelationTuple{}, fmt.Errorf("namespace %s tidak terdaftar di schema", tuple.Subject.Namespace)
+	}
+	if tuple.Subject.Relation != "" {
+		if _, ok := service.rules(tuple.Subject.Namespace, tuple.Subject.Relation); !ok {
+			return domain.RelationTuple{}, fmt.Errorf("relasi %s#%s tidak terdaftar di schema", tuple.Subject.Namespace, tuple.Subject.Relation)
+		}
+	}
+
+	return tuple, nil
+}
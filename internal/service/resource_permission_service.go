@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"golang-auth/internal/domain"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type resourcePermissionService struct {
+	resourcePermissionRepository domain.ResourcePermissionRepository
+	permissionRepository         domain.PermissionRepository
+	validate                     *validator.Validate
+}
+
+func NewResourcePermissionService(resourcePermissionRepository domain.ResourcePermissionRepository, permissionRepository domain.PermissionRepository, validate *validator.Validate) domain.ResourcePermissionService {
+	return &resourcePermissionService{
+		resourcePermissionRepository: resourcePermissionRepository,
+		permissionRepository:         permissionRepository,
+		validate:                     validate,
+	}
+}
+
+func (service *resourcePermissionService) Grant(ctx context.Context, req domain.ResourcePermissionRequest) error {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	return service.resourcePermissionRepository.Grant(ctx, &domain.ResourcePermission{
+		SubjectType:  req.SubjectType,
+		SubjectID:    req.SubjectID,
+		PermissionID: req.PermissionID,
+		ResourceType: req.ResourceType,
+		ResourceID:   req.ResourceID,
+	})
+}
+
+func (service *resourcePermissionService) Revoke(ctx context.Context, req domain.ResourcePermissionRequest) error {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	return service.resourcePermissionRepository.Revoke(ctx, &domain.ResourcePermission{
+		SubjectType:  req.SubjectType,
+		SubjectID:    req.SubjectID,
+		PermissionID: req.PermissionID,
+		ResourceType: req.ResourceType,
+		ResourceID:   req.ResourceID,
+	})
+}
+
+func (service *resourcePermissionService) FindByResource(ctx context.Context, resourceType string, resourceID string) ([]domain.ResourcePermission, error) {
+	return service.resourcePermissionRepository.FindByResource(ctx, resourceType, resourceID)
+}
+
+func (service *resourcePermissionService) Can(ctx context.Context, userID uuid.UUID, permission string, resourceType string, resourceID string) (bool, error) {
+
+	// permission global berlaku untuk semua resource
+	global, err := service.hasGlobalPermission(ctx, userID, permission)
+	if err != nil || global {
+		return global, err
+	}
+
+	return service.resourcePermissionRepository.HasResourcePermission(ctx, userID, permission, resourceType, resourceID, domain.GuardFromContext(ctx))
+}
+
+func (service *resourcePermissionService) ListResourceIDs(ctx context.Context, userID uuid.UUID, permission string, resourceType string) ([]string, bool, error) {
+
+	global, err := service.hasGlobalPermission(ctx, userID, permission)
+	if err != nil {
+		return nil, false, err
+	}
+	if global {
+		return nil, true, nil
+	}
+
+	ids, err := service.resourcePermissionRepository.GetResourceIDs(ctx, userID, permission, resourceType, domain.GuardFromContext(ctx))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return ids, false, nil
+}
+
+func (service *resourcePermissionService) FilterResourceIDs(ctx context.Context, userID uuid.UUID, permission string, resourceType string, resourceIDs []string) ([]string, error) {
+
+	allowedIDs, all, err := service.ListResourceIDs(ctx, userID, permission, resourceType)
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		return resourceIDs, nil
+	}
+
+	// pakai map biar pencarian O(1)
+	allowed := make(map[string]struct{}, len(allowedIDs))
+	for _, id := range allowedIDs {
+		allowed[id] = struct{}{}
+	}
+
+	filtered := make([]string, 0, len(resourceIDs))
+	for _, id := range resourceIDs {
+		if _, ok := allowed[id]; ok {
+			filtered = append(filtered, id)
+		}
+	}
+
+	return filtered, nil
+}
+
+func (service *resourcePermissionService) hasGlobalPermission(ctx context.Context, userID uuid.UUID, permission string) (bool, error) {
+	permissions, err := service.permissionRepository.GetPermissionsByUserID(ctx, userID, domain.GuardFromContext(ctx))
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range permissions {
+		if p == permission {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"golang-auth/internal/domain"
 	"time"
 
@@ -12,13 +14,19 @@ import (
 
 type roleService struct {
 	roleRepository domain.RoleRepository
+	permissionRepository domain.PermissionRepository
+	sodConstraintRepository domain.SoDConstraintRepository
+	templates []domain.RoleTemplate
 	db *sql.DB
 	validate *validator.Validate
 }
 
-func NewRoleService(roleRepository domain.RoleRepository, db *sql.DB, validate *validator.Validate) domain.RoleService {
+func NewRoleService(roleRepository domain.RoleRepository, permissionRepository domain.PermissionRepository, sodConstraintRepository domain.SoDConstraintRepository, templates []domain.RoleTemplate, db *sql.DB, validate *validator.Validate) domain.RoleService {
 	return &roleService{
 		roleRepository: roleRepository,
+		permissionRepository: permissionRepository,
+		sodConstraintRepository: sodConstraintRepository,
+		templates: templates,
 		db: db,
 		validate: validate,
 	}
@@ -32,6 +40,22 @@ func (service *roleService) Create(ctx context.Context, reqRole domain.RoleCreat
 		return err
 	}
 
+	err = validateConditions(reqRole.Conditions, reqRole.PermissionIDs)
+	if err != nil {
+		return err
+	}
+
+	guard := domain.GuardOrDefault(reqRole.Guard)
+	err = service.checkPermissionGuards(ctx, guard, reqRole.PermissionIDs)
+	if err != nil {
+		return err
+	}
+
+	err = service.checkPermissionConflicts(ctx, reqRole.PermissionIDs)
+	if err != nil {
+		return err
+	}
+
 	tx, err := service.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -47,6 +71,7 @@ func (service *roleService) Create(ctx context.Context, reqRole domain.RoleCreat
 	err = repoTx.Create(ctx, &domain.Role{
 		ID: uuid7,
 		Name: reqRole.Name,
+		Guard: guard,
 		CreatedAt: now,
 		UpdatedAt: now,
 	})
@@ -56,7 +81,9 @@ func (service *roleService) Create(ctx context.Context, reqRole domain.RoleCreat
 
 	// assign permission ke role (kalau ada isinya, jalani query-nya)
 	if len(reqRole.PermissionIDs) > 0 {
-		err = repoTx.AssignPermission(ctx, uuid7, reqRole.PermissionIDs)
+		err = repoTx.AssignPermission(ctx, uuid7, reqRole.PermissionIDs, domain.AssignmentOptions{
+			Conditions: reqRole.Conditions,
+		})
 		if err != nil {
 			return err
 		}
@@ -71,6 +98,16 @@ func (service *roleService) Update(ctx context.Context, req domain.RoleUpdateReq
 		return err
 	}
 
+	err = validateConditions(req.Conditions, req.PermissionIDs)
+	if err != nil {
+		return err
+	}
+
+	err = service.checkPermissionConflicts(ctx, req.PermissionIDs)
+	if err != nil {
+		return err
+	}
+
 	tx, err := service.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -79,12 +116,43 @@ func (service *roleService) Update(ctx context.Context, req domain.RoleUpdateReq
 
 	repoTx := service.roleRepository.WithTx(tx)
 
+	// tolak kalau role sudah diubah admin lain sejak dibaca (If-Match)
+	err = repoTx.BumpVersion(ctx, req.ID, req.Version)
+	if err != nil {
+		return err
+	}
+
+	role, err := repoTx.FindById(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	// guard kosong berarti tidak diubah, bukan kembali ke DefaultGuard
+	guard := role.Guard
+	if req.Guard != "" && req.Guard != role.Guard {
+		// pemegang role & permission yang terpasang masih terikat ke guard lama
+		assigned, err := repoTx.IsAssigned(ctx, req.ID)
+		if err != nil {
+			return err
+		}
+		if assigned || len(role.Permissions) > 0 {
+			return errors.New("guard role tidak bisa diubah selama masih ada user atau permission yang terpasang")
+		}
+		guard = req.Guard
+	}
+
+	err = service.checkPermissionGuards(ctx, guard, req.PermissionIDs)
+	if err != nil {
+		return err
+	}
+
 	now := time.Now()
 
 	// update data role
 	err = repoTx.Update(ctx, &domain.Role{
 		ID: req.ID,
 		Name: req.Name,
+		Guard: guard,
 		UpdatedAt: now,
 	})
 	if err != nil {
@@ -98,14 +166,151 @@ func (service *roleService) Update(ctx context.Context, req domain.RoleUpdateReq
 		return err
 	}
 	// 2. attach
-	err = repoTx.AssignPermission(ctx, req.ID, req.PermissionIDs)
+	err = repoTx.AssignPermission(ctx, req.ID, req.PermissionIDs, domain.AssignmentOptions{
+		Conditions: req.Conditions,
+	})
+	if err != nil {
+		return err
+	}
+
+	// permission baru bisa membuat user pemilik role ini melanggar SoD bersama permission lain miliknya
+	violations, err := service.sodConstraintRepository.WithTx(tx).FindViolationsByRoleID(ctx, req.ID)
 	if err != nil {
 		return err
 	}
+	if len(violations) > 0 {
+		return sodViolationError(violations[0])
+	}
 	
 	return tx.Commit()
 }
 
+// menambah permission ke role tanpa menyentuh permission lain yang sudah terpasang
+func (service *roleService) AttachPermissions(ctx context.Context, req domain.RoleAttachPermissionRequest) (*domain.RoleWithUsersAndPermissions, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateConditions(req.Conditions, req.PermissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := service.roleRepository.FindById(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.checkPermissionGuards(ctx, role.Guard, req.PermissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// konflik dicek terhadap gabungan permission lama & baru
+	permissionIDs := append([]uuid.UUID{}, req.PermissionIDs...)
+	for _, permission := range role.Permissions {
+		permissionIDs = append(permissionIDs, permission.ID)
+	}
+	err = service.checkPermissionConflicts(ctx, permissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.roleRepository.WithTx(tx)
+
+	err = repoTx.BumpVersion(ctx, req.ID, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repoTx.AttachPermissions(ctx, req.ID, req.PermissionIDs, domain.AssignmentOptions{
+		Conditions: req.Conditions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	violations, err := service.sodConstraintRepository.WithTx(tx).FindViolationsByRoleID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(violations) > 0 {
+		return nil, sodViolationError(violations[0])
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return service.roleRepository.FindById(ctx, req.ID)
+}
+
+func (service *roleService) DetachPermissions(ctx context.Context, req domain.RoleDetachPermissionRequest) (*domain.RoleWithUsersAndPermissions, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.roleRepository.WithTx(tx)
+
+	err = repoTx.BumpVersion(ctx, req.ID, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repoTx.DetachPermissions(ctx, req.ID, req.PermissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return service.roleRepository.FindById(ctx, req.ID)
+}
+
+// role hanya boleh berisi permission dari guard yang sama, supaya namespace guard tidak bercampur
+func (service *roleService) checkPermissionGuards(ctx context.Context, guard string, permissionIDs []uuid.UUID) error {
+	permissions, err := service.permissionRepository.FindByIDs(ctx, permissionIDs)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if permission.Guard != guard {
+			return fmt.Errorf("permission %s milik guard %s, tidak bisa dipakai role dengan guard %s", permission.Name, permission.Guard, guard)
+		}
+	}
+	return nil
+}
+
+// satu role tidak boleh berisi dua permission yang saling eksklusif
+func (service *roleService) checkPermissionConflicts(ctx context.Context, permissionIDs []uuid.UUID) error {
+	conflicts, err := service.sodConstraintRepository.FindConflicts(ctx, domain.SoDTypePermission, permissionIDs)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return sodConflictError(conflicts[0])
+	}
+	return nil
+}
+
 func (service *roleService) FindById(ctx context.Context, id uuid.UUID) (*domain.RoleWithUsersAndPermissions, error) {
 	
 	res, err := service.roleRepository.FindById(ctx, id)
@@ -124,8 +329,12 @@ func (service *roleService) FindAll(ctx context.Context) ([]domain.Role, error)
 	return res, nil
 }
 
+func (service *roleService) FindPage(ctx context.Context, spec domain.QuerySpec) ([]domain.Role, *domain.PageMeta, error) {
+	return service.roleRepository.FindPage(ctx, spec)
+}
+
 func (service *roleService) GetRoleByUserID(ctx context.Context, userID uuid.UUID) ([]string, error) {
-	res, err := service.roleRepository.GetRoleByUserID(ctx, userID)
+	res, err := service.roleRepository.GetRoleByUserID(ctx, userID, domain.GuardFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -135,4 +344,279 @@ func (service *roleService) GetRoleByUserID(ctx context.Context, userID uuid.UUI
 func (service *roleService) Delete(ctx context.Context, id uuid.UUID) error {
 	err := service.roleRepository.Delete(ctx, id)
 	return err
-}
\ No newline at end of file
+}
+// role hasil clone mewarisi guard, organisasi, permission & kondisinya, tapi tidak terikat ke template asal
+func (service *roleService) Clone(ctx context.Context, req domain.RoleCloneRequest) (*domain.Role, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := service.roleRepository.FindById(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissionIDs := make([]uuid.UUID, 0, len(source.Permissions))
+	conditions := make(map[uuid.UUID]string)
+	for _, permission := range source.Permissions {
+		permissionIDs = append(permissionIDs, permission.ID)
+		if permission.Condition != "" {
+			conditions[permission.ID] = permission.Condition
+		}
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.roleRepository.WithTx(tx)
+
+	uuid7, _ := uuid.NewV7()
+	now := time.Now()
+	role := &domain.Role{
+		ID: uuid7,
+		Name: req.Name,
+		Guard: source.Guard,
+		OrganizationID: source.OrganizationID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = repoTx.Create(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(permissionIDs) > 0 {
+		err = repoTx.AssignPermission(ctx, uuid7, permissionIDs, domain.AssignmentOptions{
+			Conditions: conditions,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (service *roleService) Templates() []domain.RoleTemplate {
+	return service.templates
+}
+
+// membuat role dari template, global (name default = key template) atau khusus satu organisasi
+func (service *roleService) CreateFromTemplate(ctx context.Context, req domain.RoleFromTemplateRequest) (*domain.Role, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := service.findTemplate(req.TemplateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	guard := domain.GuardOrDefault(req.Guard)
+	permissionIDs, err := service.resolveTemplatePermissions(ctx, template, guard)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.checkPermissionConflicts(ctx, permissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = template.Key
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.roleRepository.WithTx(tx)
+
+	uuid7, _ := uuid.NewV7()
+	now := time.Now()
+	role := &domain.Role{
+		ID: uuid7,
+		Name: name,
+		Guard: guard,
+		TemplateKey: template.Key,
+		OrganizationID: req.OrganizationID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = repoTx.Create(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repoTx.AssignPermission(ctx, uuid7, permissionIDs, domain.AssignmentOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// menyamakan permission semua role turunan template dengan isi template saat ini.
+// kondisi pada permission yang masih ada di template dipertahankan
+func (service *roleService) ReapplyTemplate(ctx context.Context, templateKey string) ([]domain.Role, error) {
+	template, err := service.findTemplate(templateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := service.roleRepository.FindByTemplateKey(ctx, template.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	// permission template di-resolve sekali per guard
+	permissionsByGuard := make(map[string][]uuid.UUID)
+	for _, role := range roles {
+		if _, ok := permissionsByGuard[role.Guard]; ok {
+			continue
+		}
+		permissionIDs, err := service.resolveTemplatePermissions(ctx, template, role.Guard)
+		if err != nil {
+			return nil, err
+		}
+		err = service.checkPermissionConflicts(ctx, permissionIDs)
+		if err != nil {
+			return nil, err
+		}
+		permissionsByGuard[role.Guard] = permissionIDs
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.roleRepository.WithTx(tx)
+	sodTx := service.sodConstraintRepository.WithTx(tx)
+
+	for _, role := range roles {
+		detail, err := repoTx.FindById(ctx, role.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		permissionIDs := permissionsByGuard[role.Guard]
+		wanted := make(map[uuid.UUID]bool, len(permissionIDs))
+		for _, id := range permissionIDs {
+			wanted[id] = true
+		}
+		conditions := make(map[uuid.UUID]string)
+		for _, permission := range detail.Permissions {
+			if permission.Condition != "" && wanted[permission.ID] {
+				conditions[permission.ID] = permission.Condition
+			}
+		}
+
+		err = repoTx.BumpVersion(ctx, role.ID, 0)
+		if err != nil {
+			return nil, err
+		}
+		err = repoTx.RemoveAllPermissions(ctx, role.ID)
+		if err != nil {
+			return nil, err
+		}
+		err = repoTx.AssignPermission(ctx, role.ID, permissionIDs, domain.AssignmentOptions{
+			Conditions: conditions,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		violations, err := sodTx.FindViolationsByRoleID(ctx, role.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(violations) > 0 {
+			return nil, sodViolationError(violations[0])
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	if roles == nil {
+		roles = []domain.Role{}
+	}
+	return roles, nil
+}
+
+func (service *roleService) findTemplate(key string) (domain.RoleTemplate, error) {
+	for _, template := range service.templates {
+		if template.Key == key {
+			return template, nil
+		}
+	}
+	return domain.RoleTemplate{}, errors.New("template role tidak ditemukan")
+}
+
+// nama permission di template diterjemahkan ke id permission pada guard yang dipilih
+func (service *roleService) resolveTemplatePermissions(ctx context.Context, template domain.RoleTemplate, guard string) ([]uuid.UUID, error) {
+	permissions, err := service.permissionRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]uuid.UUID)
+	for _, permission := range permissions {
+		if permission.Guard == guard {
+			byName[permission.Name] = permission.ID
+		}
+	}
+
+	permissionIDs := make([]uuid.UUID, 0, len(template.Permissions))
+	for _, name := range template.Permissions {
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("permission %s pada template %s belum ada di guard %s", name, template.Key, guard)
+		}
+		permissionIDs = append(permissionIDs, id)
+	}
+	return permissionIDs, nil
+}
+
+// role khusus organisasi hanya boleh diberikan di organisasi pemiliknya,
+// orgID nil berarti assignment global
+func checkRoleScope(ctx context.Context, roleRepository domain.RoleRepository, roleIDs []uuid.UUID, orgID *uuid.UUID) error {
+	roles, err := roleRepository.FindByIDs(ctx, roleIDs)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if role.OrganizationID == nil {
+			continue
+		}
+		if orgID == nil || *role.OrganizationID != *orgID {
+			return fmt.Errorf("role %s khusus untuk organisasi lain", role.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"golang-auth/internal/domain"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type sodConstraintService struct {
+	sodConstraintRepository domain.SoDConstraintRepository
+	db                      *sql.DB
+	validate                *validator.Validate
+}
+
+func NewSoDConstraintService(sodConstraintRepository domain.SoDConstraintRepository, db *sql.DB, validate *validator.Validate) domain.SoDConstraintService {
+	return &sodConstraintService{
+		sodConstraintRepository: sodConstraintRepository,
+		db:                      db,
+		validate:                validate,
+	}
+}
+
+// Create menambah constraint baru. user yang sudah terlanjur melanggar tidak diubah,
+// tapi akan muncul di laporan FindViolations
+func (service *sodConstraintService) Create(ctx context.Context, req domain.SoDConstraintCreateRequest) (*domain.SoDConstraint, error) {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	uuid7, _ := uuid.NewV7()
+
+	err = service.sodConstraintRepository.Create(ctx, &domain.SoDConstraint{
+		ID:          uuid7,
+		Name:        req.Name,
+		Type:        req.Type,
+		FirstID:     req.FirstID,
+		SecondID:    req.SecondID,
+		Description: req.Description,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return service.sodConstraintRepository.FindByID(ctx, uuid7)
+}
+
+func (service *sodConstraintService) Delete(ctx context.Context, id uuid.UUID) error {
+	return service.sodConstraintRepository.Delete(ctx, id)
+}
+
+func (service *sodConstraintService) FindAll(ctx context.Context) ([]domain.SoDConstraint, error) {
+	return service.sodConstraintRepository.FindAll(ctx)
+}
+
+func (service *sodConstraintService) FindViolations(ctx context.Context) ([]domain.SoDViolation, error) {
+	return service.sodConstraintRepository.FindViolations(ctx, nil)
+}
+
+// checkSoDViolations dipanggil di dalam transaksi setelah assignment ditulis,
+// kalau ada pelanggaran transaksi dibatalkan oleh pemanggil
+func checkSoDViolations(ctx context.Context, repo domain.SoDConstraintRepository, userID uuid.UUID) error {
+	violations, err := repo.FindViolations(ctx, []uuid.UUID{userID})
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return sodViolationError(violations[0])
+	}
+	return nil
+}
+
+func sodViolationError(violation domain.SoDViolation) error {
+	c := violation.Constraint
+	return fmt.Errorf("melanggar separation of duties \"%s\": user %s tidak boleh memiliki %s \"%s\" dan \"%s\" sekaligus",
+		c.Name, violation.Username, c.Type, c.FirstName, c.SecondName)
+}
+
+func sodConflictError(c domain.SoDConstraint) error {
+	return fmt.Errorf("melanggar separation of duties \"%s\": permission \"%s\" dan \"%s\" tidak boleh berada di role yang sama",
+		c.Name, c.FirstName, c.SecondName)
+}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"golang-auth/internal/domain"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// key atribut harus bisa ditulis langsung di ekspresi kondisi sebagai user.<key>
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// batas panjang nilai, atribut unique mengikuti panjang kolom unique_value
+const (
+	maxAttributeValueLength       = 1000
+	maxUniqueAttributeValueLength = 255
+)
+
+type userAttributeService struct {
+	userAttributeRepository domain.UserAttributeRepository
+	userRepository          domain.UserRepository
+	db                      *sql.DB
+	validate                *validator.Validate
+}
+
+func NewUserAttributeService(userAttributeRepository domain.UserAttributeRepository, userRepository domain.UserRepository, db *sql.DB, validate *validator.Validate) domain.UserAttributeService {
+	return &userAttributeService{
+		userAttributeRepository: userAttributeRepository,
+		userRepository:          userRepository,
+		db:                      db,
+		validate:                validate,
+	}
+}
+
+func (service *userAttributeService) FindDefinitions(ctx context.Context) ([]domain.UserAttributeDefinition, error) {
+	return service.userAttributeRepository.FindDefinitions(ctx)
+}
+
+func (service *userAttributeService) CreateDefinition(ctx context.Context, req domain.UserAttributeDefinitionCreateRequest) (*domain.UserAttributeDefinition, error) {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+	if !attributeKeyPattern.MatchString(req.Key) {
+		return nil, errors.New("key atribut hanya boleh huruf kecil, angka dan underscore, diawali huruf")
+	}
+	if slices.Contains(domain.ReservedAttributeKeys, req.Key) {
+		return nil, fmt.Errorf("key %s sudah dipakai atribut bawaan user", req.Key)
+	}
+	err = service.checkRule(req.Type, req.Rule)
+	if err != nil {
+		return nil, err
+	}
+
+	uuid7, _ := uuid.NewV7()
+	now := time.Now()
+
+	definition := &domain.UserAttributeDefinition{
+		ID:           uuid7,
+		Key:          req.Key,
+		Label:        req.Label,
+		Type:         req.Type,
+		Required:     req.Required,
+		Unique:       req.Unique,
+		UserEditable: req.UserEditable,
+		Rule:         req.Rule,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	err = service.userAttributeRepository.CreateDefinition(ctx, definition)
+	if err != nil {
+		return nil, err
+	}
+
+	return definition, nil
+}
+
+// UpdateDefinition tidak memvalidasi ulang nilai lama terhadap rule baru, nilai lama baru dicek saat diubah.
+// perubahan unique langsung diterapkan ke nilai yang ada, gagal kalau sudah ada duplikat
+func (service *userAttributeService) UpdateDefinition(ctx context.Context, req domain.UserAttributeDefinitionUpdateRequest) (*domain.UserAttributeDefinition, error) {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.userAttributeRepository.WithTx(tx)
+
+	definition, err := repoTx.FindDefinitionByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	err = service.checkRule(definition.Type, req.Rule)
+	if err != nil {
+		return nil, err
+	}
+
+	uniqueChanged := definition.Unique != req.Unique
+	definition.Label = req.Label
+	definition.Required = req.Required
+	definition.Unique = req.Unique
+	definition.UserEditable = req.UserEditable
+	definition.Rule = req.Rule
+	definition.UpdatedAt = time.Now()
+
+	err = repoTx.UpdateDefinition(ctx, definition)
+	if err != nil {
+		return nil, err
+	}
+
+	if uniqueChanged {
+		err = repoTx.SyncUnique(ctx, definition.ID, definition.Unique)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return definition, nil
+}
+
+// nilai atribut ikut terhapus (cascade), kondisi yang memakai user.<key> akan mendapat null
+func (service *userAttributeService) DeleteDefinition(ctx context.Context, id uuid.UUID) error {
+	return service.userAttributeRepository.DeleteDefinition(ctx, id)
+}
+
+func (service *userAttributeService) SetUserAttributes(ctx context.Context, req domain.UserAttributesUpdateRequest) (map[string]any, error) {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.userAttributeRepository.WithTx(tx)
+
+	definitions, err := repoTx.FindDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := prepareAttributes(service.validate, definitions, req.Attributes, req.SelfService)
+	if err != nil {
+		return nil, err
+	}
+	err = writeAttributes(ctx, repoTx, req.UserID, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	// atribut wajib yang belum pernah diisi juga harus ikut dikirim
+	values, err := repoTx.FindValues(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]any, len(values))
+	for _, value := range values {
+		result[value.Key] = value.Typed()
+	}
+	err = checkRequiredAttributes(definitions, result, req.SelfService)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.userRepository.WithTx(tx).BumpVersion(ctx, req.UserID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// attributeChange adalah satu atribut yang sudah divalidasi, value nil berarti atribut dihapus
+type attributeChange struct {
+	definition domain.UserAttributeDefinition
+	value      *string
+}
+
+// prepareAttributes memvalidasi semua key & nilai sebelum ada yang ditulis. dipakai saat atribut diubah
+// dan saat user dibuat (register, undangan, admin, import). selfService hanya boleh mengisi atribut user_editable
+func prepareAttributes(validate *validator.Validate, definitions []domain.UserAttributeDefinition, attributes map[string]any, selfService bool) ([]attributeChange, error) {
+	byKey := make(map[string]domain.UserAttributeDefinition, len(definitions))
+	for _, definition := range definitions {
+		byKey[definition.Key] = definition
+	}
+
+	// semua key dicek dulu supaya error-nya tidak tergantung urutan map
+	for key := range attributes {
+		definition, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("atribut %s tidak dikenal", key)
+		}
+		if selfService && !definition.UserEditable {
+			return nil, fmt.Errorf("atribut %s hanya bisa diubah admin", key)
+		}
+	}
+
+	changes := make([]attributeChange, 0, len(attributes))
+	for key, raw := range attributes {
+		definition := byKey[key]
+
+		if raw == nil {
+			if definition.Required {
+				return nil, fmt.Errorf("atribut %s wajib diisi", key)
+			}
+			changes = append(changes, attributeChange{definition: definition})
+			continue
+		}
+
+		value, err := normalizeAttributeValue(validate, definition, raw)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, attributeChange{definition: definition, value: &value})
+	}
+
+	return changes, nil
+}
+
+func writeAttributes(ctx context.Context, repoTx domain.UserAttributeRepository, userID uuid.UUID, changes []attributeChange) error {
+	for _, change := range changes {
+		if change.value == nil {
+			err := repoTx.DeleteValue(ctx, userID, change.definition.ID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := repoTx.SetValue(ctx, domain.UserAttributeValue{
+			UserID:       userID,
+			DefinitionID: change.definition.ID,
+			Value:        *change.value,
+		}, change.definition.Unique)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRequiredAttributes memastikan semua atribut wajib ada di present. selfService (user sendiri, register,
+// terima undangan) hanya dituntut mengisi atribut wajib yang user_editable, sisanya diisi admin
+func checkRequiredAttributes(definitions []domain.UserAttributeDefinition, present map[string]any, selfService bool) error {
+	for _, definition := range definitions {
+		if !definition.Required || (selfService && !definition.UserEditable) {
+			continue
+		}
+		if value, ok := present[definition.Key]; !ok || value == nil {
+			return fmt.Errorf("atribut %s wajib diisi", definition.Key)
+		}
+	}
+	return nil
+}
+
+// normalizeAttributeValue mencocokkan tipe JSON dengan tipe definisi, menjalankan rule validator,
+// lalu mengubahnya ke string untuk disimpan
+func normalizeAttributeValue(validate *validator.Validate, definition domain.UserAttributeDefinition, raw any) (string, error) {
+	var typed any
+	var value string
+
+	switch definition.Type {
+	case domain.AttributeTypeString:
+		s, ok := raw.(string)
+		if !ok {
+			return "", fmt.Errorf("atribut %s harus berupa teks", definition.Key)
+		}
+		typed, value = s, s
+	case domain.AttributeTypeNumber:
+		f, ok := raw.(float64)
+		if !ok {
+			return "", fmt.Errorf("atribut %s harus berupa angka", definition.Key)
+		}
+		typed, value = f, strconv.FormatFloat(f, 'f', -1, 64)
+	case domain.AttributeTypeBoolean:
+		b, ok := raw.(bool)
+		if !ok {
+			return "", fmt.Errorf("atribut %s harus berupa boolean", definition.Key)
+		}
+		typed, value = b, strconv.FormatBool(b)
+	case domain.AttributeTypeDate:
+		s, ok := raw.(string)
+		if !ok {
+			return "", fmt.Errorf("atribut %s harus berupa tanggal YYYY-MM-DD", definition.Key)
+		}
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return "", fmt.Errorf("atribut %s harus berupa tanggal YYYY-MM-DD", definition.Key)
+		}
+		typed, value = s, s
+	default:
+		return "", fmt.Errorf("tipe atribut %s tidak dikenal", definition.Type)
+	}
+
+	maxLength := maxAttributeValueLength
+	if definition.Unique {
+		maxLength = maxUniqueAttributeValueLength
+	}
+	if len(value) > maxLength {
+		return "", fmt.Errorf("atribut %s maksimal %d karakter", definition.Key, maxLength)
+	}
+
+	if definition.Rule != "" {
+		failedTag, err := runAttributeRule(validate, typed, definition.Rule)
+		if err != nil {
+			return "", err
+		}
+		if failedTag != "" {
+			return "", fmt.Errorf("atribut %s tidak memenuhi rule %s", definition.Key, failedTag)
+		}
+	}
+
+	return value, nil
+}
+
+// checkRule memastikan rule bisa dipakai validator untuk tipe tsb, dicoba dengan nilai contoh
+// supaya tag yang tidak dikenal ketahuan saat definisi disimpan, bukan saat user mengisi
+func (service *userAttributeService) checkRule(attributeType string, rule string) error {
+	if rule == "" {
+		return nil
+	}
+
+	samples := map[string]any{
+		domain.AttributeTypeString:  "",
+		domain.AttributeTypeNumber:  float64(0),
+		domain.AttributeTypeBoolean: false,
+		domain.AttributeTypeDate:    "",
+	}
+
+	_, err := runAttributeRule(service.validate, samples[attributeType], rule)
+	if err != nil {
+		return fmt.Errorf("rule tidak valid: %s", err.Error())
+	}
+	return nil
+}
+
+// runAttributeRule menjalankan validator.Var. failedTag terisi kalau nilai tidak memenuhi rule,
+// err berarti rule-nya sendiri tidak bisa dipakai (tag tidak dikenal membuat validator panic)
+func runAttributeRule(validate *validator.Validate, value any, rule string) (failedTag string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	err = validate.Var(value, rule)
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) && len(validationErrors) > 0 {
+		return validationErrors[0].Tag(), nil
+	}
+	return "", err
+}
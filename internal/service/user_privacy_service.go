@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"golang-auth/internal/domain"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type userPrivacyService struct {
+	userRepository               domain.UserRepository
+	tokenRepository              domain.PersonalAccessTokenRepository
+	auditRepository              domain.AuditRepository
+	accessRequestRepository      domain.AccessRequestRepository
+	invitationRepository         domain.InvitationRepository
+	organizationRepository       domain.OrganizationRepository
+	resourcePermissionRepository domain.ResourcePermissionRepository
+	relationTupleRepository      domain.RelationTupleRepository
+	db                           *sql.DB
+	validate                     *validator.Validate
+}
+
+func NewUserPrivacyService(userRepository domain.UserRepository, tokenRepository domain.PersonalAccessTokenRepository, auditRepository domain.AuditRepository, accessRequestRepository domain.AccessRequestRepository, invitationRepository domain.InvitationRepository, organizationRepository domain.OrganizationRepository, resourcePermissionRepository domain.ResourcePermissionRepository, relationTupleRepository domain.RelationTupleRepository, db *sql.DB, validate *validator.Validate) domain.UserPrivacyService {
+	return &userPrivacyService{
+		userRepository:               userRepository,
+		tokenRepository:              tokenRepository,
+		auditRepository:              auditRepository,
+		accessRequestRepository:      accessRequestRepository,
+		invitationRepository:         invitationRepository,
+		organizationRepository:       organizationRepository,
+		resourcePermissionRepository: resourcePermissionRepository,
+		relationTupleRepository:      relationTupleRepository,
+		db:                           db,
+		validate:                     validate,
+	}
+}
+
+func (service *userPrivacyService) Export(ctx context.Context, userID uuid.UUID, actorID uuid.UUID) (*domain.UserDataExport, error) {
+
+	user, err := service.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == domain.UserStatusErased {
+		return nil, errors.New("data user sudah dihapus permanen")
+	}
+
+	tokens, err := service.tokenRepository.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := service.auditRepository.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessRequests, err := service.accessRequestRepository.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	organizations, err := service.exportOrganizations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resourcePermissions, err := service.resourcePermissionRepository.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tuples, err := service.relationTupleRepository.Read(ctx, domain.RelationTupleFilter{
+		SubjectNamespace: domain.UserRelationNamespace,
+		SubjectID:        userID.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invitations, err := service.invitationRepository.FindByUser(ctx, userID, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &domain.UserDataExport{
+		GeneratedAt:         time.Now(),
+		Profile:             user,
+		Organizations:       organizations,
+		ResourcePermissions: resourcePermissions,
+		RelationTuples:      tuples,
+		Invitations:         invitations,
+		Tokens:              make([]domain.UserDataToken, 0, len(tokens)),
+		LoginHistory:        []domain.AuditLog{},
+		AuditEntries:        []domain.AuditLog{},
+		AccessRequests:      accessRequests,
+	}
+	if archive.AccessRequests == nil {
+		archive.AccessRequests = []domain.AccessRequest{}
+	}
+	if archive.ResourcePermissions == nil {
+		archive.ResourcePermissions = []domain.ResourcePermission{}
+	}
+	if archive.RelationTuples == nil {
+		archive.RelationTuples = []domain.RelationTuple{}
+	}
+
+	for _, token := range tokens {
+		archive.Tokens = append(archive.Tokens, domain.UserDataToken{
+			ID:             token.ID,
+			Name:           token.TokenName,
+			Guard:          token.Guard,
+			OrganizationID: token.OrganizationID,
+			LastUsedAt:     token.LastUsedAt,
+			ExpiresAt:      token.ExpiresAt,
+			CreatedAt:      token.CreatedAt,
+		})
+	}
+
+	for _, log := range logs {
+		if log.Action == domain.AuditActionLogin {
+			archive.LoginHistory = append(archive.LoginHistory, log)
+			continue
+		}
+		archive.AuditEntries = append(archive.AuditEntries, log)
+	}
+
+	// export dicatat setelah archive disusun supaya tidak ikut masuk ke archive-nya sendiri
+	err = service.auditRepository.Create(ctx, &domain.AuditLog{
+		ActorID:     &actorID,
+		Action:      "user.data_exported",
+		SubjectType: "user",
+		SubjectID:   userID.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// keanggotaan organisasi beserta role & permission yang hanya berlaku di organisasi tsb
+func (service *userPrivacyService) exportOrganizations(ctx context.Context, userID uuid.UUID) ([]domain.UserDataOrganization, error) {
+	organizations, err := service.organizationRepository.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := make([]domain.UserDataOrganization, 0, len(organizations))
+	for _, organization := range organizations {
+		member, err := service.organizationRepository.FindMember(ctx, organization.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, domain.UserDataOrganization{
+			Organization: organization,
+			IsAdmin:      member.IsAdmin,
+			Roles:        member.Roles,
+			Permissions:  member.Permissions,
+			JoinedAt:     member.JoinedAt,
+		})
+	}
+
+	return memberships, nil
+}
+
+func (service *userPrivacyService) Erase(ctx context.Context, req domain.UserEraseRequest) error {
+
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.userRepository.WithTx(tx)
+
+	user, err := repoTx.FindByID(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	if user.Status == domain.UserStatusErased {
+		return errors.New("data user sudah dihapus permanen")
+	}
+
+	// menghapus akun sendiri harus konfirmasi password
+	self := req.ID == req.ActorID
+	if self {
+		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+		if err != nil {
+			return errors.New("Password salah")
+		}
+	}
+
+	// email asli dibutuhkan untuk membersihkan undangan & log yang menyimpannya
+	originalEmail := user.Email
+
+	// username & email diganti placeholder unik yang diturunkan dari id, baris user tetap ada untuk referensi audit log
+	placeholder := fmt.Sprintf("%x", user.ID[:])
+	now := time.Now()
+	user.Username = "erased-" + placeholder
+	user.Email = placeholder + "@erased.invalid"
+	user.Status = domain.UserStatusErased
+	user.DeletedAt = &now
+	user.UpdatedAt = now
+
+	err = repoTx.Erase(ctx, user)
+	if err != nil {
+		return err
+	}
+	err = repoTx.BumpVersion(ctx, user.ID, 0)
+	if err != nil {
+		return err
+	}
+
+	auditTx := service.auditRepository.WithTx(tx)
+
+	// log dibersihkan sebelum undangan dianonimkan karena pencariannya memakai email asli
+	err = auditTx.AnonymizeUser(ctx, user.ID, originalEmail)
+	if err != nil {
+		return err
+	}
+	err = service.invitationRepository.WithTx(tx).AnonymizeEmail(ctx, user.ID, originalEmail, user.Email)
+	if err != nil {
+		return err
+	}
+	err = service.accessRequestRepository.WithTx(tx).AnonymizeByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = auditTx.Create(ctx, &domain.AuditLog{
+		ActorID:     &req.ActorID,
+		Action:      "user.erased",
+		SubjectType: "user",
+		SubjectID:   user.ID.String(),
+		Metadata:    map[string]any{"reason": req.Reason, "self": self},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	// sama seperti perubahan status, token dicabut setelah commit
+	return service.tokenRepository.DeleteByUserID(ctx, user.ID)
+}
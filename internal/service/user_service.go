@@ -4,7 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"golang-auth/internal/domain"
+	"golang-auth/internal/pkg/identifier"
+	"golang-auth/internal/pkg/mailer"
+	"log/slog"
+	"sort"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -14,25 +19,51 @@ import (
 
 type userService struct {
 	userRepository domain.UserRepository
+	roleRepository domain.RoleRepository
+	auditRepository domain.AuditRepository
+	sodConstraintRepository domain.SoDConstraintRepository
+	tokenRepository domain.PersonalAccessTokenRepository
+	userAttributeRepository domain.UserAttributeRepository
+	mailer mailer.Mailer
 	db *sql.DB
 	validate *validator.Validate
 }
 
-func NewUserService(userRepository domain.UserRepository, db *sql.DB, validate *validator.Validate) domain.UserService {
+// masa berlaku token verifikasi email baru
+const emailVerificationTTL = 24 * time.Hour
+
+func NewUserService(userRepository domain.UserRepository, roleRepository domain.RoleRepository, auditRepository domain.AuditRepository, sodConstraintRepository domain.SoDConstraintRepository, tokenRepository domain.PersonalAccessTokenRepository, userAttributeRepository domain.UserAttributeRepository, mailer mailer.Mailer, db *sql.DB, validate *validator.Validate) domain.UserService {
 	return &userService{
 		userRepository: userRepository,
+		roleRepository: roleRepository,
+		auditRepository: auditRepository,
+		sodConstraintRepository: sodConstraintRepository,
+		tokenRepository: tokenRepository,
+		userAttributeRepository: userAttributeRepository,
+		mailer: mailer,
 		db: db,
 		validate: validate,
 	}
 }
 
 func (service *userService) Create(ctx context.Context, req domain.UserCreateRequest) error {
+	return service.CreateWith(ctx, req, nil)
+}
+
+func (service *userService) CreateWith(ctx context.Context, req domain.UserCreateRequest, fn func(tx *sql.Tx, user *domain.User) error) error {
 	
 	err := service.validate.Struct(req)
 	if err != nil {
 		return err
 	}
 
+	if req.ActorID != nil {
+		err = checkHeldGrants(ctx, service.userRepository, *req.ActorID, req.RoleIDs, req.PermissionIDs)
+		if err != nil {
+			return err
+		}
+	}
+
 	tx, err := service.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -50,6 +81,21 @@ func (service *userService) Create(ctx context.Context, req domain.UserCreateReq
 		return err
 	}
 
+	// atribut divalidasi sebelum user ditulis, atribut wajib harus ikut dikirim saat user dibuat
+	attributeTx := service.userAttributeRepository.WithTx(tx)
+	definitions, err := attributeTx.FindDefinitions(ctx)
+	if err != nil {
+		return err
+	}
+	attributes, err := prepareAttributes(service.validate, definitions, req.Attributes, req.SelfService)
+	if err != nil {
+		return err
+	}
+	err = checkRequiredAttributes(definitions, req.Attributes, req.SelfService)
+	if err != nil {
+		return err
+	}
+
 	uuid7, _ := uuid.NewV7()
 	now := time.Now()
 
@@ -59,21 +105,27 @@ func (service *userService) Create(ctx context.Context, req domain.UserCreateReq
 	}
 
 	// buat user
-	err = repoTx.Create(ctx, &domain.User{
+	user := &domain.User{
 		ID: uuid7,
 		Username: req.Username,
 		Email: req.Email,
 		Password: string(hashedPassword),
 		CreatedAt: now,
 		UpdatedAt: now,
-	})
+	}
+	err = repoTx.Create(ctx, user)
 	if err != nil {
 		return err
 	}
 
 	// assign role (kalau role-nya ada isinya jalanin querynya)
 	if len(req.RoleIDs) > 0 {
-		err = repoTx.AssignRoles(ctx, uuid7, req.RoleIDs)
+		err = checkRoleScope(ctx, service.roleRepository, req.RoleIDs, nil)
+		if err != nil {
+			return err
+		}
+
+		err = repoTx.AssignRoles(ctx, uuid7, req.RoleIDs, domain.AssignmentOptions{})
 		if err != nil {
 			return err
 		}
@@ -81,7 +133,24 @@ func (service *userService) Create(ctx context.Context, req domain.UserCreateReq
 
 	// assign permission (kalau permission-nya ada isinya jalanin querynya)
 	if len(req.PermissionIDs) > 0 {
-		err = repoTx.AssignPermissions(ctx, uuid7, req.PermissionIDs)
+		err = repoTx.AssignPermissions(ctx, uuid7, req.PermissionIDs, domain.AssignmentOptions{})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeAttributes(ctx, attributeTx, uuid7, attributes)
+	if err != nil {
+		return err
+	}
+
+	err = checkSoDViolations(ctx, service.sodConstraintRepository.WithTx(tx), uuid7)
+	if err != nil {
+		return err
+	}
+
+	if fn != nil {
+		err = fn(tx, user)
 		if err != nil {
 			return err
 		}
@@ -116,6 +185,12 @@ func (service *userService) Update(ctx context.Context, req domain.UserUpdateReq
 		return err
 	}
 
+	// tolak kalau user sudah diubah admin lain sejak dibaca (If-Match)
+	err = repoTx.BumpVersion(ctx, req.ID, req.Version)
+	if err != nil {
+		return err
+	}
+
 	now := time.Now()
 	err = repoTx.Update(ctx, &domain.User{
 		ID: req.ID,
@@ -138,6 +213,21 @@ func (service *userService) AssignRoles(ctx context.Context, id uuid.UUID, req d
 		return err
 	}
 
+	err = validateConditions(req.Conditions, req.RoleIDs)
+	if err != nil {
+		return err
+	}
+
+	err = validateSchedules(req.Schedules, req.RoleIDs)
+	if err != nil {
+		return err
+	}
+
+	err = checkRoleScope(ctx, service.roleRepository, req.RoleIDs, nil)
+	if err != nil {
+		return err
+	}
+
 	tx, err := service.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -145,6 +235,11 @@ func (service *userService) AssignRoles(ctx context.Context, id uuid.UUID, req d
 	defer tx.Rollback()
 
 	repoTx := service.userRepository.WithTx(tx)
+
+	err = repoTx.BumpVersion(ctx, id, req.Version)
+	if err != nil {
+		return err
+	}
 	
 	// remove all roles
 	err = repoTx.RemoveAllRoles(ctx, id)
@@ -153,7 +248,16 @@ func (service *userService) AssignRoles(ctx context.Context, id uuid.UUID, req d
 	}
 
 	// add all roles
-	err = repoTx.AssignRoles(ctx, id, req.RoleIDs)
+	err = repoTx.AssignRoles(ctx, id, req.RoleIDs, domain.AssignmentOptions{
+		Conditions: req.Conditions,
+		Schedules: req.Schedules,
+	})
+	if err != nil {
+		return err
+	}
+
+	// tolak kalau kombinasi role / permission user jadi melanggar separation of duties
+	err = checkSoDViolations(ctx, service.sodConstraintRepository.WithTx(tx), id)
 	if err != nil {
 		return err
 	}
@@ -167,6 +271,16 @@ func (service *userService) AssignPermissions(ctx context.Context, id uuid.UUID,
 		return err
 	}
 
+	err = validateConditions(req.Conditions, req.PermissionIDs)
+	if err != nil {
+		return err
+	}
+
+	err = validateSchedules(req.Schedules, req.PermissionIDs)
+	if err != nil {
+		return err
+	}
+
 	tx, err := service.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -174,6 +288,11 @@ func (service *userService) AssignPermissions(ctx context.Context, id uuid.UUID,
 	defer tx.Rollback()
 
 	repoTx := service.userRepository.WithTx(tx)
+
+	err = repoTx.BumpVersion(ctx, id, req.Version)
+	if err != nil {
+		return err
+	}
 	
 	// remove all permission
 	err = repoTx.RemoveAllPermissions(ctx, id)
@@ -182,7 +301,15 @@ func (service *userService) AssignPermissions(ctx context.Context, id uuid.UUID,
 	}
 	
 	// add all permission
-	err = repoTx.AssignPermissions(ctx, id, req.PermissionIDs)
+	err = repoTx.AssignPermissions(ctx, id, req.PermissionIDs, domain.AssignmentOptions{
+		Conditions: req.Conditions,
+		Schedules: req.Schedules,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = checkSoDViolations(ctx, service.sodConstraintRepository.WithTx(tx), id)
 	if err != nil {
 		return err
 	}
@@ -190,6 +317,118 @@ func (service *userService) AssignPermissions(ctx context.Context, id uuid.UUID,
 	return tx.Commit()
 }
 
+// menambah role ke user tanpa menyentuh role lain yang sudah terpasang
+func (service *userService) AttachRoles(ctx context.Context, req domain.UserAttachRoleRequest) (*domain.User, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateConditions(req.Conditions, req.RoleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateSchedules(req.Schedules, req.RoleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkRoleScope(ctx, service.roleRepository, req.RoleIDs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.mutateAssignments(ctx, req.ID, req.Version, true, func(repoTx domain.UserRepository) error {
+		return repoTx.AttachRoles(ctx, req.ID, req.RoleIDs, domain.AssignmentOptions{
+			Conditions: req.Conditions,
+			Schedules: req.Schedules,
+		})
+	})
+}
+
+func (service *userService) DetachRoles(ctx context.Context, req domain.UserDetachRoleRequest) (*domain.User, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.mutateAssignments(ctx, req.ID, req.Version, false, func(repoTx domain.UserRepository) error {
+		return repoTx.DetachRoles(ctx, req.ID, req.RoleIDs)
+	})
+}
+
+func (service *userService) AttachPermissions(ctx context.Context, req domain.UserAttachPermissionRequest) (*domain.User, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateConditions(req.Conditions, req.PermissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateSchedules(req.Schedules, req.PermissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.mutateAssignments(ctx, req.ID, req.Version, true, func(repoTx domain.UserRepository) error {
+		return repoTx.AttachPermissions(ctx, req.ID, req.PermissionIDs, domain.AssignmentOptions{
+			Conditions: req.Conditions,
+			Schedules: req.Schedules,
+		})
+	})
+}
+
+func (service *userService) DetachPermissions(ctx context.Context, req domain.UserDetachPermissionRequest) (*domain.User, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.mutateAssignments(ctx, req.ID, req.Version, false, func(repoTx domain.UserRepository) error {
+		return repoTx.DetachPermissions(ctx, req.ID, req.PermissionIDs)
+	})
+}
+
+// mutateAssignments menjalankan satu perubahan assignment dalam transaksi: cek & naikkan versi,
+// jalankan mutate, cek SoD (hanya perlu saat menambah), lalu kembalikan user setelah perubahan
+func (service *userService) mutateAssignments(ctx context.Context, id uuid.UUID, version int, checkSoD bool, mutate func(repoTx domain.UserRepository) error) (*domain.User, error) {
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.userRepository.WithTx(tx)
+
+	err = repoTx.BumpVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	err = mutate(repoTx)
+	if err != nil {
+		return nil, err
+	}
+
+	if checkSoD {
+		err = checkSoDViolations(ctx, service.sodConstraintRepository.WithTx(tx), id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return service.userRepository.FindByID(ctx, id)
+}
+
 func (service *userService) FindByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	res, err := service.userRepository.FindByID(ctx, id)
 	if err != nil {
@@ -206,9 +445,466 @@ func (service *userService) FindByEmail(ctx context.Context, email string) (*dom
 	return res, nil
 }
 
-func (service *userService) Delete(ctx context.Context, id uuid.UUID) error {
-	err := service.userRepository.Delete(ctx, id)
-	return err
+func (service *userService) FindByLogin(ctx context.Context, login string) (*domain.User, error) {
+	if identifier.IsEmail(login) {
+		return service.userRepository.FindByEmail(ctx, login)
+	}
+	return service.userRepository.FindByUsername(ctx, login)
+}
+
+func (service *userService) FindPage(ctx context.Context, spec domain.QuerySpec) ([]domain.User, *domain.PageMeta, error) {
+	return service.userRepository.FindPage(ctx, spec)
+}
+
+func (service *userService) Search(ctx context.Context, query domain.UserSearchQuery, spec domain.QuerySpec) ([]domain.User, *domain.PageMeta, error) {
+	err := service.validate.Struct(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return service.userRepository.Search(ctx, query, spec)
+}
+
+func (service *userService) Suspend(ctx context.Context, req domain.UserSuspendRequest) error {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		return errors.New("batas waktu suspend harus di masa depan")
+	}
+
+	return service.changeStatus(ctx, req.ID, req.ActorID, func(user *domain.User) error {
+		if user.Status != domain.UserStatusActive && user.Status != domain.UserStatusSuspended {
+			return fmt.Errorf("user berstatus %s tidak bisa di-suspend", user.Status)
+		}
+		user.Status = domain.UserStatusSuspended
+		user.SuspendedReason = req.Reason
+		user.SuspendedUntil = req.Until
+		return nil
+	}, map[string]any{"reason": req.Reason, "until": req.Until})
+}
+
+func (service *userService) Deactivate(ctx context.Context, req domain.UserStatusRequest) error {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	return service.changeStatus(ctx, req.ID, req.ActorID, func(user *domain.User) error {
+		if user.Status == domain.UserStatusDeactivated || user.Status == domain.UserStatusDeleted {
+			return fmt.Errorf("user sudah berstatus %s", user.Status)
+		}
+		user.Status = domain.UserStatusDeactivated
+		return nil
+	}, map[string]any{"reason": req.Reason})
+}
+
+// soft delete, baris user tetap ada supaya audit log & riwayat tetap utuh dan bisa di-restore
+func (service *userService) Delete(ctx context.Context, req domain.UserStatusRequest) error {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	return service.changeStatus(ctx, req.ID, req.ActorID, func(user *domain.User) error {
+		if user.Status == domain.UserStatusDeleted {
+			return errors.New("user sudah dihapus")
+		}
+		now := time.Now()
+		user.Status = domain.UserStatusDeleted
+		user.DeletedAt = &now
+		return nil
+	}, map[string]any{"reason": req.Reason})
+}
+
+func (service *userService) Restore(ctx context.Context, req domain.UserStatusRequest) error {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	return service.changeStatus(ctx, req.ID, req.ActorID, func(user *domain.User) error {
+		if user.Status == domain.UserStatusActive {
+			return errors.New("user sudah aktif")
+		}
+		user.Status = domain.UserStatusActive
+		user.DeletedAt = nil
+		return nil
+	}, map[string]any{"reason": req.Reason})
+}
+
+// changeStatus menjalankan transisi status dalam satu transaksi beserta audit log-nya.
+// token user dicabut setelah commit kalau status baru bukan active, jadi sesi yang sedang berjalan langsung berhenti
+func (service *userService) changeStatus(ctx context.Context, id uuid.UUID, actorID uuid.UUID, transition func(user *domain.User) error, metadata map[string]any) error {
+	if id == actorID {
+		return errors.New("tidak bisa mengubah status akun sendiri")
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.userRepository.WithTx(tx)
+
+	user, err := repoTx.FindStatus(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.Status == domain.UserStatusErased {
+		return errors.New("data user sudah dihapus permanen")
+	}
+	previous := user.Status
+
+	// alasan & batas suspend hanya berlaku selama status suspended
+	user.SuspendedReason, user.SuspendedUntil = "", nil
+	err = transition(user)
+	if err != nil {
+		return err
+	}
+	user.UpdatedAt = time.Now()
+
+	err = repoTx.UpdateStatus(ctx, user)
+	if err != nil {
+		return err
+	}
+	err = repoTx.BumpVersion(ctx, id, 0)
+	if err != nil {
+		return err
+	}
+
+	action := "user." + user.Status
+	if user.Status == domain.UserStatusActive {
+		action = "user.restored"
+	}
+	metadata["previous_status"] = previous
+	err = service.auditRepository.WithTx(tx).Create(ctx, &domain.AuditLog{
+		ActorID: &actorID,
+		Action: action,
+		SubjectType: "user",
+		SubjectID: id.String(),
+		Metadata: metadata,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	if user.Status != domain.UserStatusActive {
+		err = service.tokenRepository.DeleteByUserID(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (service *userService) CheckActive(ctx context.Context, id uuid.UUID) error {
+	user, err := service.userRepository.FindStatus(ctx, id)
+	if err != nil {
+		return err
+	}
+	return user.ActiveError(time.Now())
+}
+
+func (service *userService) RecordLogin(ctx context.Context, id uuid.UUID, metadata map[string]any) error {
+	return service.auditRepository.Create(ctx, &domain.AuditLog{
+		ActorID: &id,
+		Action: domain.AuditActionLogin,
+		SubjectType: "user",
+		SubjectID: id.String(),
+		Metadata: metadata,
+	})
+}
+
+// importCandidate adalah baris yang lolos validasi, id sudah ditentukan supaya pelanggaran SoD bisa dipetakan ke barisnya
+type importCandidate struct {
+	id         uuid.UUID
+	row        domain.UserImportRow
+	roleIDs    []uuid.UUID
+	attributes []attributeChange
+}
+
+func (service *userService) Import(ctx context.Context, rows []domain.UserImportRow, opts domain.UserImportOptions) (*domain.UserImportResult, error) {
+	if len(rows) > domain.MaxImportRows {
+		return nil, fmt.Errorf("maksimal %d user per import", domain.MaxImportRows)
+	}
+	if !domain.IsValidGuard(opts.Guard) {
+		return nil, errors.New("Guard tidak valid")
+	}
+	guard := domain.GuardOrDefault(opts.Guard)
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = domain.DefaultImportBatchSize
+	}
+	opts.BatchSize = min(opts.BatchSize, domain.MaxImportBatchSize)
+
+	importID, _ := uuid.NewV7()
+	result := &domain.UserImportResult{
+		ImportID: importID,
+		DryRun:   opts.DryRun,
+		Total:    len(rows),
+		Errors:   []domain.UserImportError{},
+	}
+	fail := func(row domain.UserImportRow, err error) {
+		result.Errors = append(result.Errors, domain.UserImportError{Line: row.Line, Email: row.Email, Message: err.Error(), Err: err})
+	}
+
+	// role dicari berdasarkan nama, hanya role global pada guard import
+	roles, err := service.roleRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs := map[string]uuid.UUID{}
+	for _, role := range roles {
+		if role.Guard == guard && role.OrganizationID == nil {
+			roleIDs[role.Name] = role.ID
+		}
+	}
+
+	// import lewat API hanya boleh memberikan role yang dimiliki actor
+	var heldRoleIDs map[uuid.UUID]bool
+	if opts.ActorID != nil {
+		held, err := service.userRepository.FindHeldRoleIDs(ctx, *opts.ActorID, domain.GuardFromContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		heldRoleIDs = make(map[uuid.UUID]bool, len(held))
+		for _, id := range held {
+			heldRoleIDs[id] = true
+		}
+	}
+
+	// atribut wajib juga berlaku untuk user hasil import
+	definitions, err := service.userAttributeRepository.FindDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// tahap 1: validasi semua baris tanpa menyentuh database
+	var candidates []importCandidate
+	seenEmails, seenUsernames := map[string]bool{}, map[string]bool{}
+	for _, row := range rows {
+		err := service.validate.Struct(row)
+		if err != nil {
+			fail(row, err)
+			continue
+		}
+
+		email, username := identifier.NormalizeEmail(row.Email), identifier.Skeleton(row.Username)
+		if seenEmails[email] {
+			fail(row, errors.New("email muncul lebih dari sekali di file import"))
+			continue
+		}
+		if seenUsernames[username] {
+			fail(row, errors.New("username muncul lebih dari sekali di file import"))
+			continue
+		}
+		seenEmails[email], seenUsernames[username] = true, true
+
+		candidate := importCandidate{row: row}
+		for _, name := range row.Roles {
+			id, ok := roleIDs[name]
+			if !ok {
+				err = fmt.Errorf("role %s tidak ditemukan pada guard %s", name, guard)
+				break
+			}
+			if heldRoleIDs != nil && !heldRoleIDs[id] {
+				err = fmt.Errorf("tidak bisa memberikan role %s yang tidak Anda miliki", name)
+				break
+			}
+			candidate.roleIDs = append(candidate.roleIDs, id)
+		}
+		if err != nil {
+			fail(row, err)
+			continue
+		}
+
+		candidate.attributes, err = prepareAttributes(service.validate, definitions, row.Attributes, false)
+		if err == nil {
+			err = checkRequiredAttributes(definitions, row.Attributes, false)
+		}
+		if err != nil {
+			fail(row, err)
+			continue
+		}
+
+		candidate.id, _ = uuid.NewV7()
+		candidates = append(candidates, candidate)
+	}
+
+	// tahap 2: tulis per batch, batch yang gagal tidak membatalkan batch lain yang sudah commit
+	for start := 0; start < len(candidates); start += opts.BatchSize {
+		batch := candidates[start:min(start+opts.BatchSize, len(candidates))]
+
+		created, err := service.importBatch(ctx, importID, batch, opts, fail)
+		if err != nil {
+			return nil, err
+		}
+		result.Created += created
+	}
+
+	result.Failed = len(result.Errors)
+	sort.SliceStable(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Line < result.Errors[j].Line
+	})
+
+	return result, nil
+}
+
+// importBatch membuang baris yang email / username-nya sudah terpakai, lalu menulis sisanya dalam satu transaksi.
+// baris yang melanggar SoD dikeluarkan dan batch diulang tanpa baris tersebut
+func (service *userService) importBatch(ctx context.Context, importID uuid.UUID, batch []importCandidate, opts domain.UserImportOptions, fail func(row domain.UserImportRow, err error)) (int, error) {
+	emails := make([]string, len(batch))
+	usernames := make([]string, len(batch))
+	for i, candidate := range batch {
+		emails[i], usernames[i] = identifier.NormalizeEmail(candidate.row.Email), identifier.Skeleton(candidate.row.Username)
+	}
+
+	takenEmails, takenUsernames, err := service.userRepository.FindTakenIdentifiers(ctx, emails, usernames)
+	if err != nil {
+		return 0, err
+	}
+
+	var pending []importCandidate
+	for _, candidate := range batch {
+		switch {
+		case takenEmails[identifier.NormalizeEmail(candidate.row.Email)]:
+			fail(candidate.row, errors.New("Email sudah digunakan"))
+		case takenUsernames[identifier.Skeleton(candidate.row.Username)]:
+			fail(candidate.row, errors.New("Username sudah digunakan"))
+		default:
+			pending = append(pending, candidate)
+		}
+	}
+
+	for len(pending) > 0 {
+		violations, err := service.writeImportBatch(ctx, importID, pending, opts)
+		if err != nil {
+			// error database (misal duplikat karena import lain berjalan bersamaan), seluruh batch gagal
+			for _, candidate := range pending {
+				fail(candidate.row, err)
+			}
+			return 0, nil
+		}
+		if len(violations) == 0 {
+			return len(pending), nil
+		}
+
+		violating := map[uuid.UUID]domain.SoDViolation{}
+		for _, violation := range violations {
+			if _, ok := violating[violation.UserID]; !ok {
+				violating[violation.UserID] = violation
+			}
+		}
+
+		var remaining []importCandidate
+		for _, candidate := range pending {
+			if violation, ok := violating[candidate.id]; ok {
+				fail(candidate.row, sodViolationError(violation))
+			} else {
+				remaining = append(remaining, candidate)
+			}
+		}
+		if len(remaining) == len(pending) {
+			return 0, errors.New("pelanggaran SoD bukan milik batch import ini")
+		}
+		pending = remaining
+	}
+
+	return 0, nil
+}
+
+// writeImportBatch mengembalikan pelanggaran SoD tanpa commit. saat dry run transaksi selalu di-rollback,
+// jadi hasilnya akurat (termasuk SoD) tanpa ada yang tersimpan
+func (service *userService) writeImportBatch(ctx context.Context, importID uuid.UUID, batch []importCandidate, opts domain.UserImportOptions) ([]domain.SoDViolation, error) {
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.userRepository.WithTx(tx)
+	now := time.Now()
+
+	ids := make([]uuid.UUID, len(batch))
+	for i, candidate := range batch {
+		ids[i] = candidate.id
+
+		// hashing bcrypt adalah bagian paling lambat, dilewati saat dry run karena tidak ada yang disimpan
+		password := "dry-run"
+		if !opts.DryRun {
+			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(candidate.row.Password), bcrypt.DefaultCost)
+			if err != nil {
+				return nil, err
+			}
+			password = string(hashedPassword)
+		}
+
+		err = repoTx.Create(ctx, &domain.User{
+			ID:        candidate.id,
+			Username:  candidate.row.Username,
+			Email:     candidate.row.Email,
+			Password:  password,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(candidate.roleIDs) > 0 {
+			err = repoTx.AssignRoles(ctx, candidate.id, candidate.roleIDs, domain.AssignmentOptions{})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = writeAttributes(ctx, service.userAttributeRepository.WithTx(tx), candidate.id, candidate.attributes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	violations, err := service.sodConstraintRepository.WithTx(tx).FindViolations(ctx, ids)
+	if err != nil || len(violations) > 0 {
+		return violations, err
+	}
+
+	err = service.auditRepository.WithTx(tx).Create(ctx, &domain.AuditLog{
+		ActorID:     opts.ActorID,
+		Action:      "user.imported",
+		SubjectType: "user_import",
+		SubjectID:   importID.String(),
+		Metadata: map[string]any{
+			"user_ids": ids,
+			"count":    len(ids),
+			"guard":    domain.GuardOrDefault(opts.Guard),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return nil, nil
+	}
+
+	return nil, tx.Commit()
+}
+
+func (service *userService) Export(ctx context.Context, guard string, fn func(row domain.UserExportRow) error) error {
+	if !domain.IsValidGuard(guard) {
+		return errors.New("Guard tidak valid")
+	}
+	return service.userRepository.Export(ctx, domain.GuardOrDefault(guard), fn)
 }
 
 func (service *userService) ChangePassword(ctx context.Context, id uuid.UUID, req domain.UserChangePasswordRequest) error {
@@ -239,3 +935,240 @@ func (service *userService) ChangePassword(ctx context.Context, id uuid.UUID, re
 	
 	return err
 }
+
+func (service *userService) UpdateProfile(ctx context.Context, req domain.UserProfileUpdateRequest) (*domain.User, error) {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.userRepository.WithTx(tx)
+
+	user, err := repoTx.FindByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repoTx.BumpVersion(ctx, req.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// email belum diganti di sini, hanya username
+	err = repoTx.Update(ctx, &domain.User{
+		ID: req.ID,
+		Username: req.Username,
+		Email: user.Email,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var verificationToken string
+	if req.Email != user.Email {
+		existingUser, err := repoTx.FindByEmail(ctx, req.Email)
+		if err == nil && existingUser.ID != req.ID {
+			return nil, errors.New("email sudah digunakan oleh pengguna lain")
+		} else if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		verificationToken, err = generateSecureToken(32)
+		if err != nil {
+			return nil, err
+		}
+
+		err = repoTx.SetPendingEmail(ctx, req.ID, req.Email, hashToken(verificationToken), time.Now().Add(emailVerificationTTL))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	// token dikirim ke alamat baru, membuktikan user memang pemilik email tersebut
+	if verificationToken != "" {
+		err = service.mailer.Send(ctx, mailer.Message{
+			To:      []string{req.Email},
+			Subject: "Verifikasi email baru",
+			Body: fmt.Sprintf("Halo %s,\n\nGunakan token berikut untuk memverifikasi email baru Anda (berlaku %s):\n\n%s\n\nKirim token ini ke POST /api/v1/email/verify. Abaikan email ini kalau Anda tidak merasa mengubah email.",
+				req.Username, emailVerificationTTL, verificationToken),
+		})
+		if err != nil {
+			slog.Error("Gagal mengirim email verifikasi", "user_id", req.ID.String(), "error", err)
+		}
+	}
+
+	return service.userRepository.FindByID(ctx, req.ID)
+}
+
+func (service *userService) VerifyEmail(ctx context.Context, req domain.EmailVerifyRequest) error {
+	err := service.validate.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.userRepository.WithTx(tx)
+
+	user, err := repoTx.FindByEmailVerification(ctx, hashToken(req.Token))
+	if err != nil {
+		return err
+	}
+
+	// email bisa saja sudah dipakai user lain sejak token dikirim
+	existingUser, err := repoTx.FindByEmail(ctx, user.PendingEmail)
+	if err == nil && existingUser.ID != user.ID {
+		return errors.New("email sudah digunakan oleh pengguna lain")
+	} else if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	err = repoTx.ConfirmPendingEmail(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = repoTx.BumpVersion(ctx, user.ID, 0)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (service *userService) SweepExpiredAssignments(ctx context.Context) (int, error) {
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	repoTx := service.userRepository.WithTx(tx)
+	auditTx := service.auditRepository.WithTx(tx)
+
+	expired, err := repoTx.FindExpiredAssignments(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, assignment := range expired {
+		err = repoTx.DeleteExpiredAssignment(ctx, assignment)
+		if err != nil {
+			return 0, err
+		}
+		err = repoTx.BumpVersion(ctx, assignment.UserID, 0)
+		if err != nil {
+			return 0, err
+		}
+
+		// actor kosong, karna yang menghapus adalah sistem
+		err = auditTx.Create(ctx, &domain.AuditLog{
+			Action: fmt.Sprintf("user.%s.expired", assignment.Type),
+			SubjectType: "user",
+			SubjectID: assignment.UserID.String(),
+			Metadata: map[string]any{
+				"type": assignment.Type,
+				"id": assignment.ID.String(),
+				"name": assignment.Name,
+				"expires_at": assignment.ExpiresAt,
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(expired) > 0 {
+		slog.Info("ASSIGNMENT_SWEEP", slog.Int("removed", len(expired)))
+	}
+
+	return len(expired), nil
+}
+
+// validateSchedules memastikan jadwal hanya untuk id yang ikut di-assign dan rentang waktunya masuk akal
+func validateSchedules(schedules map[uuid.UUID]domain.AssignmentSchedule, ids []uuid.UUID) error {
+	assigned := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		assigned[id] = true
+	}
+
+	now := time.Now()
+	for id, schedule := range schedules {
+		if !assigned[id] {
+			return fmt.Errorf("masa berlaku untuk %s tidak ada di daftar yang di-assign", id)
+		}
+		if schedule.ExpiresAt == nil {
+			continue
+		}
+		if !schedule.ExpiresAt.After(now) {
+			return fmt.Errorf("expires_at untuk %s harus di masa depan", id)
+		}
+		if schedule.StartsAt != nil && !schedule.ExpiresAt.After(*schedule.StartsAt) {
+			return fmt.Errorf("expires_at untuk %s harus setelah starts_at", id)
+		}
+	}
+
+	return nil
+}
+
+// ukuran halaman backfill identifier ter-normalisasi
+const normalizeBatchSize = 500
+
+func (service *userService) NormalizeIdentifiers(ctx context.Context) (int, []string, error) {
+	var updated int
+	var conflicts []string
+
+	after := uuid.Nil
+	for {
+		users, err := service.userRepository.FindUnnormalized(ctx, after, normalizeBatchSize)
+		if err != nil {
+			return updated, conflicts, err
+		}
+
+		for i := range users {
+			user := &users[i]
+			after = user.ID
+
+			err := service.userRepository.SetNormalizedIdentifiers(ctx, user, false)
+			if err != nil {
+				// biasanya skeleton kembar dengan user lain, user lama tetap disimpan tanpa skeleton
+				slog.Warn("Identifier user bentrok dengan user lain", "user_id", user.ID, "username", user.Username, "error", err)
+				conflicts = append(conflicts, user.Username)
+
+				err = service.userRepository.SetNormalizedIdentifiers(ctx, user, true)
+				if err != nil {
+					// email / username sama persis setelah normalisasi, harus dibereskan admin
+					slog.Error("Gagal normalisasi identifier user", "user_id", user.ID, "error", err)
+					continue
+				}
+			}
+			updated++
+		}
+
+		if len(users) < normalizeBatchSize {
+			return updated, conflicts, nil
+		}
+	}
+}
@@ -0,0 +1,13 @@
+CREATE TABLE organizations (
+    id          BINARY(16)   NOT NULL,
+    name        VARCHAR(100) NOT NULL,
+    slug        VARCHAR(100) NOT NULL,
+    created_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+                                      ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_organizations      PRIMARY KEY (id),
+    CONSTRAINT uq_organizations_slug UNIQUE      (slug)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1 @@
+DROP TABLE organization_members;
\ No newline at end of file
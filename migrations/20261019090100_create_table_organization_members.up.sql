@@ -0,0 +1,18 @@
+CREATE TABLE organization_members (
+    organization_id BINARY(16) NOT NULL,
+    user_id         BINARY(16) NOT NULL,
+    is_admin        BOOLEAN    NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMP  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_organization_members              PRIMARY KEY (organization_id, user_id),
+    CONSTRAINT fk_organization_members_organization FOREIGN KEY (organization_id)
+        REFERENCES organizations(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_organization_members_user         FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
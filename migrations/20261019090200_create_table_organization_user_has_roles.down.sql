@@ -0,0 +1 @@
+DROP TABLE organization_user_has_roles;
\ No newline at end of file
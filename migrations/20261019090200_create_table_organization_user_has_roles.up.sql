@@ -0,0 +1,17 @@
+CREATE TABLE organization_user_has_roles (
+    organization_id BINARY(16) NOT NULL,
+    user_id         BINARY(16) NOT NULL,
+    role_id         BINARY(16) NOT NULL,
+
+    CONSTRAINT pk_organization_user_has_roles        PRIMARY KEY (organization_id, user_id, role_id),
+    CONSTRAINT fk_organization_user_has_roles_member FOREIGN KEY (organization_id, user_id)
+        REFERENCES organization_members(organization_id, user_id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_organization_user_has_roles_role   FOREIGN KEY (role_id)
+        REFERENCES roles(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
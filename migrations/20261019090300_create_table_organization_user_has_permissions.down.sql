@@ -0,0 +1 @@
+DROP TABLE organization_user_has_permissions;
\ No newline at end of file
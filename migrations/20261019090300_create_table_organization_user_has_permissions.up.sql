@@ -0,0 +1,17 @@
+CREATE TABLE organization_user_has_permissions (
+    organization_id BINARY(16) NOT NULL,
+    user_id         BINARY(16) NOT NULL,
+    permission_id   BINARY(16) NOT NULL,
+
+    CONSTRAINT pk_organization_user_has_permissions            PRIMARY KEY (organization_id, user_id, permission_id),
+    CONSTRAINT fk_organization_user_has_permissions_member     FOREIGN KEY (organization_id, user_id)
+        REFERENCES organization_members(organization_id, user_id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_organization_user_has_permissions_permission FOREIGN KEY (permission_id)
+        REFERENCES permissions(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,3 @@
+ALTER TABLE personal_access_tokens
+    DROP FOREIGN KEY fk_personal_access_tokens_organization,
+    DROP COLUMN organization_id;
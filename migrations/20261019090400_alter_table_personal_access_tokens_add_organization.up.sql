@@ -0,0 +1,5 @@
+ALTER TABLE personal_access_tokens
+    ADD COLUMN organization_id BINARY(16) NULL AFTER user_id,
+    ADD CONSTRAINT fk_personal_access_tokens_organization FOREIGN KEY (organization_id)
+        REFERENCES organizations(id)
+        ON DELETE CASCADE;
@@ -0,0 +1,20 @@
+CREATE TABLE user_has_resource_permissions (
+    user_id       BINARY(16)   NOT NULL,
+    permission_id BINARY(16)   NOT NULL,
+    resource_type VARCHAR(50)  NOT NULL,
+    resource_id   VARCHAR(100) NOT NULL,
+    created_at    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_user_has_resource_permissions            PRIMARY KEY (user_id, permission_id, resource_type, resource_id),
+    CONSTRAINT fk_user_has_resource_permissions_user       FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_user_has_resource_permissions_permission FOREIGN KEY (permission_id)
+        REFERENCES permissions(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    INDEX idx_user_has_resource_permissions_resource (resource_type, resource_id)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1 @@
+DROP TABLE role_has_resource_permissions;
\ No newline at end of file
@@ -0,0 +1,20 @@
+CREATE TABLE role_has_resource_permissions (
+    role_id       BINARY(16)   NOT NULL,
+    permission_id BINARY(16)   NOT NULL,
+    resource_type VARCHAR(50)  NOT NULL,
+    resource_id   VARCHAR(100) NOT NULL,
+    created_at    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_role_has_resource_permissions            PRIMARY KEY (role_id, permission_id, resource_type, resource_id),
+    CONSTRAINT fk_role_has_resource_permissions_role       FOREIGN KEY (role_id)
+        REFERENCES roles(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_role_has_resource_permissions_permission FOREIGN KEY (permission_id)
+        REFERENCES permissions(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    INDEX idx_role_has_resource_permissions_resource (resource_type, resource_id)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1 @@
+DROP TABLE relation_tuples;
\ No newline at end of file
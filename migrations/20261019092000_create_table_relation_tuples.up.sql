@@ -0,0 +1,14 @@
+CREATE TABLE relation_tuples (
+    namespace         VARCHAR(50)  NOT NULL,
+    object_id         VARCHAR(100) NOT NULL,
+    relation          VARCHAR(50)  NOT NULL,
+    subject_namespace VARCHAR(50)  NOT NULL,
+    subject_id        VARCHAR(100) NOT NULL,
+    subject_relation  VARCHAR(50)  NOT NULL DEFAULT '',
+    created_at        TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_relation_tuples PRIMARY KEY (namespace, object_id, relation, subject_namespace, subject_id, subject_relation),
+    INDEX idx_relation_tuples_subject (subject_namespace, subject_id, subject_relation)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,2 @@
+ALTER TABLE user_has_roles
+    DROP COLUMN condition_expression;
@@ -0,0 +1,2 @@
+ALTER TABLE user_has_permissions
+    DROP COLUMN condition_expression;
@@ -0,0 +1,2 @@
+ALTER TABLE user_has_permissions
+    ADD COLUMN condition_expression TEXT NULL;
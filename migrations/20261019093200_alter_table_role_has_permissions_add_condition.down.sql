@@ -0,0 +1,2 @@
+ALTER TABLE role_has_permissions
+    DROP COLUMN condition_expression;
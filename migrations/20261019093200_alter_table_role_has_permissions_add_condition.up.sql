@@ -0,0 +1,2 @@
+ALTER TABLE role_has_permissions
+    ADD COLUMN condition_expression TEXT NULL;
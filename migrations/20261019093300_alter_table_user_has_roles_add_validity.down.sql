@@ -0,0 +1,4 @@
+ALTER TABLE user_has_roles
+    DROP INDEX idx_user_has_roles_expires_at,
+    DROP COLUMN expires_at,
+    DROP COLUMN starts_at;
@@ -0,0 +1,4 @@
+ALTER TABLE user_has_permissions
+    ADD COLUMN starts_at  DATETIME NULL,
+    ADD COLUMN expires_at DATETIME NULL,
+    ADD INDEX idx_user_has_permissions_expires_at (expires_at);
@@ -0,0 +1,16 @@
+CREATE TABLE audit_logs (
+    id            BINARY(16)   NOT NULL,
+    actor_id      BINARY(16)   NULL,
+    action        VARCHAR(100) NOT NULL,
+    subject_type  VARCHAR(50)  NOT NULL,
+    subject_id    VARCHAR(191) NOT NULL,
+    metadata      JSON         NULL,
+    created_at    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_audit_logs PRIMARY KEY (id),
+    INDEX idx_audit_logs_subject (subject_type, subject_id),
+    INDEX idx_audit_logs_action  (action),
+    INDEX idx_audit_logs_actor   (actor_id)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
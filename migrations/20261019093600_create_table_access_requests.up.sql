@@ -0,0 +1,39 @@
+CREATE TABLE access_requests (
+    id               BINARY(16)   NOT NULL,
+    user_id          BINARY(16)   NOT NULL,
+    request_type     VARCHAR(20)  NOT NULL,
+    role_id          BINARY(16)   NULL,
+    permission_id    BINARY(16)   NULL,
+    justification    TEXT         NOT NULL,
+    duration_minutes INT UNSIGNED NOT NULL,
+    status           VARCHAR(20)  NOT NULL DEFAULT 'pending',
+    reviewer_id      BINARY(16)   NULL,
+    review_note      VARCHAR(500) NULL,
+    reviewed_at      DATETIME     NULL,
+    expires_at       DATETIME     NULL,
+    created_at       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+                                           ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_access_requests            PRIMARY KEY (id),
+    CONSTRAINT fk_access_requests_user       FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_access_requests_role       FOREIGN KEY (role_id)
+        REFERENCES roles(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_access_requests_permission FOREIGN KEY (permission_id)
+        REFERENCES permissions(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_access_requests_reviewer   FOREIGN KEY (reviewer_id)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE,
+    INDEX idx_access_requests_status (status, created_at),
+    INDEX idx_access_requests_user   (user_id, created_at)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1 @@
+DROP TABLE sod_constraints;
@@ -0,0 +1,34 @@
+CREATE TABLE sod_constraints (
+    id                   BINARY(16)   NOT NULL,
+    name                 VARCHAR(100) NOT NULL,
+    constraint_type      VARCHAR(20)  NOT NULL,
+    first_role_id        BINARY(16)   NULL,
+    second_role_id       BINARY(16)   NULL,
+    first_permission_id  BINARY(16)   NULL,
+    second_permission_id BINARY(16)   NULL,
+    description          VARCHAR(500) NULL,
+    created_at           TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_sod_constraints                   PRIMARY KEY (id),
+    CONSTRAINT uq_sod_constraints_name              UNIQUE      (name),
+    CONSTRAINT uq_sod_constraints_roles             UNIQUE      (first_role_id, second_role_id),
+    CONSTRAINT uq_sod_constraints_permissions       UNIQUE      (first_permission_id, second_permission_id),
+    CONSTRAINT fk_sod_constraints_first_role        FOREIGN KEY (first_role_id)
+        REFERENCES roles(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_sod_constraints_second_role       FOREIGN KEY (second_role_id)
+        REFERENCES roles(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_sod_constraints_first_permission  FOREIGN KEY (first_permission_id)
+        REFERENCES permissions(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_sod_constraints_second_permission FOREIGN KEY (second_permission_id)
+        REFERENCES permissions(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,4 @@
+ALTER TABLE permissions
+    DROP INDEX idx_permissions_group_name,
+    DROP COLUMN group_name,
+    DROP COLUMN description;
@@ -0,0 +1,4 @@
+ALTER TABLE permissions
+    ADD COLUMN description VARCHAR(255) NULL AFTER name,
+    ADD COLUMN group_name  VARCHAR(100) NULL AFTER description,
+    ADD INDEX idx_permissions_group_name (group_name);
@@ -0,0 +1,4 @@
+ALTER TABLE roles
+    DROP INDEX uq_roles_name_guard,
+    DROP COLUMN guard_name,
+    ADD CONSTRAINT uq_roles_name UNIQUE (name);
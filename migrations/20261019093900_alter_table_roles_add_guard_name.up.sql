@@ -0,0 +1,4 @@
+ALTER TABLE roles
+    ADD COLUMN guard_name VARCHAR(50) NOT NULL DEFAULT 'api' AFTER name,
+    DROP INDEX uq_roles_name,
+    ADD CONSTRAINT uq_roles_name_guard UNIQUE (name, guard_name);
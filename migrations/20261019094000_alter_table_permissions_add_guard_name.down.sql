@@ -0,0 +1,4 @@
+ALTER TABLE permissions
+    DROP INDEX uq_permissions_name_guard,
+    DROP COLUMN guard_name,
+    ADD CONSTRAINT uq_permissions_name UNIQUE (name);
@@ -0,0 +1,4 @@
+ALTER TABLE permissions
+    ADD COLUMN guard_name VARCHAR(50) NOT NULL DEFAULT 'api' AFTER name,
+    DROP INDEX uq_permissions_name,
+    ADD CONSTRAINT uq_permissions_name_guard UNIQUE (name, guard_name);
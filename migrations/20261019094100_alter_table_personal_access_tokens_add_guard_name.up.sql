@@ -0,0 +1,2 @@
+ALTER TABLE personal_access_tokens
+    ADD COLUMN guard_name VARCHAR(50) NOT NULL DEFAULT 'api' AFTER organization_id;
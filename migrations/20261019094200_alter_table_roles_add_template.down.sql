@@ -0,0 +1,5 @@
+ALTER TABLE roles
+    DROP FOREIGN KEY fk_roles_organization,
+    DROP INDEX idx_roles_template_key,
+    DROP COLUMN organization_id,
+    DROP COLUMN template_key;
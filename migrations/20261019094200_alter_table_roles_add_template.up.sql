@@ -0,0 +1,7 @@
+ALTER TABLE roles
+    ADD COLUMN template_key    VARCHAR(50) NULL AFTER guard_name,
+    ADD COLUMN organization_id BINARY(16)  NULL AFTER template_key,
+    ADD INDEX idx_roles_template_key (template_key),
+    ADD CONSTRAINT fk_roles_organization FOREIGN KEY (organization_id)
+        REFERENCES organizations(id)
+        ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+ALTER TABLE roles
+    ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1 AFTER organization_id;
@@ -0,0 +1,2 @@
+ALTER TABLE users
+    ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1 AFTER password;
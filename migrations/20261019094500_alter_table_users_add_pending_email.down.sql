@@ -0,0 +1,5 @@
+ALTER TABLE users
+    DROP INDEX uq_users_email_verification_hash,
+    DROP COLUMN email_verification_expires_at,
+    DROP COLUMN email_verification_hash,
+    DROP COLUMN pending_email;
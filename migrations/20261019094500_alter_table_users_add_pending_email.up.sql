@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN pending_email                 VARCHAR(255) NULL AFTER email,
+    ADD COLUMN email_verification_hash       CHAR(64)     NULL AFTER pending_email,
+    ADD COLUMN email_verification_expires_at TIMESTAMP    NULL AFTER email_verification_hash,
+    ADD CONSTRAINT uq_users_email_verification_hash UNIQUE (email_verification_hash);
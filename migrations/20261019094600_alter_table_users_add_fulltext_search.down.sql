@@ -0,0 +1,2 @@
+ALTER TABLE users
+    DROP INDEX ft_users_username_email;
@@ -0,0 +1,3 @@
+-- parser ngram supaya potongan username / email (minimal 2 karakter) bisa ditemukan
+ALTER TABLE users
+    ADD FULLTEXT INDEX ft_users_username_email (username, email) WITH PARSER ngram;
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN status           VARCHAR(20)  NOT NULL DEFAULT 'active' AFTER password,
+    ADD COLUMN suspended_reason VARCHAR(255) NULL AFTER status,
+    ADD COLUMN suspended_until  TIMESTAMP    NULL AFTER suspended_reason,
+    ADD COLUMN deleted_at       TIMESTAMP    NULL AFTER suspended_until,
+    ADD INDEX idx_users_status (status);
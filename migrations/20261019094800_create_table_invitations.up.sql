@@ -0,0 +1,31 @@
+CREATE TABLE invitations (
+    id               BINARY(16)   NOT NULL,
+    email            VARCHAR(255) NOT NULL,
+    token_hash       CHAR(64)     NOT NULL,
+    inviter_id       BINARY(16)   NULL,
+    role_ids         JSON         NOT NULL,
+    permission_ids   JSON         NOT NULL,
+    status           VARCHAR(20)  NOT NULL DEFAULT 'pending',
+    accepted_user_id BINARY(16)   NULL,
+    accepted_at      DATETIME     NULL,
+    revoked_at       DATETIME     NULL,
+    expires_at       DATETIME     NOT NULL,
+    created_at       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+                                           ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_invitations               PRIMARY KEY (id),
+    CONSTRAINT uq_invitations_token_hash    UNIQUE (token_hash),
+    CONSTRAINT fk_invitations_inviter       FOREIGN KEY (inviter_id)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_invitations_accepted_user FOREIGN KEY (accepted_user_id)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE,
+    INDEX idx_invitations_email  (email, status),
+    INDEX idx_invitations_status (status, created_at)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,18 @@
+CREATE TABLE user_attribute_definitions (
+    id            BINARY(16)   NOT NULL,
+    attribute_key VARCHAR(50)  NOT NULL,
+    label         VARCHAR(100) NOT NULL,
+    type          VARCHAR(20)  NOT NULL,
+    is_required   BOOLEAN      NOT NULL DEFAULT FALSE,
+    is_unique     BOOLEAN      NOT NULL DEFAULT FALSE,
+    user_editable BOOLEAN      NOT NULL DEFAULT FALSE,
+    rule          VARCHAR(255) NULL,
+    created_at    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+                                        ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_user_attr_definitions     PRIMARY KEY (id),
+    CONSTRAINT uq_user_attr_definitions_key UNIQUE (attribute_key)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
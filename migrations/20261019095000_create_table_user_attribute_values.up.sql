@@ -0,0 +1,23 @@
+-- unique_value hanya terisi untuk atribut yang is_unique, NULL boleh berulang
+CREATE TABLE user_attribute_values (
+    user_id       BINARY(16)    NOT NULL,
+    definition_id BINARY(16)    NOT NULL,
+    value         VARCHAR(1000) NOT NULL,
+    unique_value  VARCHAR(255)  NULL,
+    created_at    TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP
+                                         ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_user_attr_values            PRIMARY KEY (user_id, definition_id),
+    CONSTRAINT uq_user_attr_values_unique     UNIQUE (definition_id, unique_value),
+    CONSTRAINT fk_user_attr_values_user       FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_user_attr_values_definition FOREIGN KEY (definition_id)
+        REFERENCES user_attribute_definitions(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,7 @@
+ALTER TABLE users
+    DROP INDEX uq_users_username_skeleton,
+    DROP INDEX uq_users_username_normalized,
+    DROP INDEX uq_users_email_normalized,
+    DROP COLUMN username_skeleton,
+    DROP COLUMN username_normalized,
+    DROP COLUMN email_normalized;
@@ -0,0 +1,8 @@
+-- diisi aplikasi (NFKC + case folding), baris lama di-backfill saat API start atau lewat `users normalize`
+ALTER TABLE users
+    ADD COLUMN email_normalized    VARCHAR(255) NULL AFTER email,
+    ADD COLUMN username_normalized VARCHAR(255) NULL AFTER username,
+    ADD COLUMN username_skeleton   VARCHAR(255) NULL AFTER username_normalized,
+    ADD CONSTRAINT uq_users_email_normalized UNIQUE (email_normalized),
+    ADD CONSTRAINT uq_users_username_normalized UNIQUE (username_normalized),
+    ADD CONSTRAINT uq_users_username_skeleton UNIQUE (username_skeleton);
@@ -0,0 +1,3 @@
+-- guard pengundang saat undangan dibuat, role & permission-nya dicek ulang di guard ini saat diterima
+ALTER TABLE invitations
+    ADD COLUMN guard_name VARCHAR(50) NOT NULL DEFAULT 'api' AFTER inviter_id;